@@ -0,0 +1,934 @@
+package border0test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/lib/types/selector"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Requester is an in-memory fake of client.Requester, for unit tests of code which takes a
+// client.Requester. It keeps the same state as Server does, and returns the same errors
+// as a client.APIClient would: client.Error values (wrapped the same way, e.g. deleting a
+// resource which does not exist is not an error), except that they are never wrapped in a
+// client.RequestError.
+//
+// Calls can be observed and failed with hooks, or failed with injected errors.
+type Requester struct {
+	store      *store
+	token      string
+	issue      func(jwt.MapClaims) string
+	revoke     func(...string)
+	serverInfo func() client.ServerInfo
+
+	mu     sync.Mutex
+	calls  []Call
+	hooks  []Hook
+	errors []*injectedError
+}
+
+// ensure Requester implements client.Requester at compile-time.
+var _ client.Requester = (*Requester)(nil)
+
+// NewRequester returns a new fake client.Requester, with a fake organization of its own.
+func NewRequester() *Requester {
+	store := newStore(uuid.NewString(), defaultOrgSubdomain)
+	key := []byte(uuid.NewString())
+	issue := func(claims jwt.MapClaims) string { return store.signToken(key, claims) }
+	return &Requester{
+		store:  store,
+		token:  issue(jwt.MapClaims{"user_email": "admin@" + store.orgSubdomain + ".com", "role": client.RoleAdmin}),
+		issue:  issue,
+		revoke: func(...string) {},
+		serverInfo: func() client.ServerInfo {
+			return client.ServerInfo{DataConsistency: &client.DataConsistency{}}
+		},
+	}
+}
+
+// Requester returns a fake client.Requester sharing the state of the server, so that changes
+// made with either are seen by both. Tokens created with it are accepted by the server.
+func (s *Server) Requester() *Requester {
+	return &Requester{
+		store:  s.store,
+		token:  s.token,
+		issue:  s.issueToken,
+		revoke: s.revokeTokens,
+		serverInfo: func() client.ServerInfo {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return client.ServerInfo{DataConsistency: &client.DataConsistency{RxAfterTxDelayMS: s.readAfterWriteMS}}
+		},
+	}
+}
+
+// Call is a call of a method of a Requester.
+type Call struct {
+	Method string // name of the client.Requester method, e.g. "CreateSocket"
+	Args   []any  // arguments of the call, without the context
+}
+
+// Hook is called before every call of a method of a Requester. When it returns an error,
+// the call fails with it, without changing any state.
+type Hook func(ctx context.Context, call Call) error
+
+// AddHook adds a hook called before every call. Hooks are called in the order they were added.
+func (r *Requester) AddHook(hook Hook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Calls returns the calls made so far, in order.
+func (r *Requester) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+type injectedError struct {
+	method string
+	err    error
+	times  int
+	failed int
+}
+
+// InjectError makes calls of the given method fail with the given error, the given number
+// of times (every time if zero). Injected errors are handled the same as errors from the
+// Border0 API, e.g. a client.Error with a 404 code makes DeleteSocket succeed, and makes
+// Socket fail with a "socket not found" error.
+func (r *Requester) InjectError(method string, err error, times int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, &injectedError{method: method, err: err, times: times})
+}
+
+// ClearErrors removes all the injected errors.
+func (r *Requester) ClearErrors() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = nil
+}
+
+// before records a call, and returns the error it must fail with, if any.
+func (r *Requester) before(ctx context.Context, method string, args ...any) error {
+	call := Call{Method: method, Args: args}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	hooks := append([]Hook(nil), r.hooks...)
+	var injected error
+	for _, e := range r.errors {
+		if e.method != method || (e.times > 0 && e.failed >= e.times) {
+			continue
+		}
+		e.failed++
+		injected = e.err
+		break
+	}
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, call); err != nil {
+			return err
+		}
+	}
+	if injected != nil {
+		return injected
+	}
+	return ctx.Err()
+}
+
+// do records a call and runs it, unless it must fail.
+func do[T any](ctx context.Context, r *Requester, method string, args []any, run func() (T, error)) (T, error) {
+	if err := r.before(ctx, method, args...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return run()
+}
+
+// exec is do for calls without output.
+func exec(ctx context.Context, r *Requester, method string, args []any, run func() error) error {
+	_, err := do(ctx, r, method, args, func() (struct{}, error) { return struct{}{}, run() })
+	return err
+}
+
+// ignoreNotFound returns nil if the given error is a 404, like deletes of the client do.
+func ignoreNotFound(err error) error {
+	if client.NotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// TokenClaims returns the claims of the token of the fake organization's admin.
+func (r *Requester) TokenClaims() (jwt.MapClaims, error) {
+	if err := r.before(context.Background(), "TokenClaims"); err != nil {
+		return nil, err
+	}
+	claims, err := client.ParseClaims(r.token)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Raw, nil
+}
+
+// Claims returns the typed claims of the token of the fake organization's admin.
+func (r *Requester) Claims(ctx context.Context) (*client.Claims, error) {
+	if err := r.before(ctx, "Claims"); err != nil {
+		return nil, err
+	}
+	return client.ParseClaims(r.token)
+}
+
+// IsAuthenticated returns true, the fake is always authenticated.
+func (r *Requester) IsAuthenticated(ctx context.Context, opts ...client.IsAuthenticatedOption) (bool, error) {
+	args := make([]any, 0, len(opts))
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return do(ctx, r, "IsAuthenticated", args, func() (bool, error) { return true, nil })
+}
+
+// Authenticate does nothing, the fake is always authenticated.
+func (r *Requester) Authenticate(ctx context.Context, opts ...auth.Option) error {
+	args := make([]any, 0, len(opts))
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return exec(ctx, r, "Authenticate", args, func() error { return nil })
+}
+
+// ServerInfo returns the server info.
+func (r *Requester) ServerInfo(ctx context.Context) (*client.ServerInfo, error) {
+	out, err := do(ctx, r, "ServerInfo", nil, func() (client.ServerInfo, error) { return r.serverInfo(), nil })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExchangeWebIdentityToken exchanges a web identity token for a token of a service account.
+func (r *Requester) ExchangeWebIdentityToken(ctx context.Context, input *client.WebIdentityTokenExchangeInput) (*client.WebIdentityTokenExchangeOutput, error) {
+	out, err := do(ctx, r, "ExchangeWebIdentityToken", []any{input}, func() (client.WebIdentityTokenExchangeOutput, error) {
+		serviceAccount, err := r.store.webIdentityServiceAccount(*input)
+		if err != nil {
+			return client.WebIdentityTokenExchangeOutput{}, err
+		}
+		token := r.issue(jwt.MapClaims{"service_account_id": serviceAccount.ID, "service_account_name": serviceAccount.Name, "role": serviceAccount.Role})
+		return client.WebIdentityTokenExchangeOutput{Token: token}, nil
+	})
+	if err != nil {
+		if apiErr, ok := client.BadRequest(err); ok {
+			return nil, errors.New(apiErr.Message)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ---------------------------------------------------------------------------------------
+// sockets
+// ---------------------------------------------------------------------------------------
+
+// Socket fetches a socket by id or name.
+func (r *Requester) Socket(ctx context.Context, idOrName string) (*client.Socket, error) {
+	out, err := do(ctx, r, "Socket", []any{idOrName}, func() (client.Socket, error) { return r.store.socket(idOrName) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("socket [%s] not found: %w", idOrName, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Sockets fetches all sockets matching the given filters.
+func (r *Requester) Sockets(ctx context.Context, filters ...client.SocketFilter) ([]client.Socket, error) {
+	args := make([]any, 0, len(filters))
+	for _, filter := range filters {
+		args = append(args, filter)
+	}
+	sockets, err := do(ctx, r, "Sockets", args, func() ([]client.Socket, error) {
+		return r.listSockets(client.SocketFilterValues(filters...))
+	})
+	if err != nil {
+		return nil, err
+	}
+	var all []client.Socket
+	return append(all, sockets...), nil
+}
+
+// SocketsPaginator returns a paginator to iterate pages of sockets matching the given
+// filters. Every page fetched is recorded as a call, with the page number and size as
+// arguments.
+func (r *Requester) SocketsPaginator(ctx context.Context, pageSize int, filters ...client.SocketFilter) *client.Paginator[client.Socket] {
+	return r.ResumeSocketsPaginator(ctx, client.Checkpoint{PageSize: pageSize, Filters: client.SocketFilterValues(filters...)})
+}
+
+// ResumeSocketsPaginator returns a sockets paginator which resumes from the given checkpoint.
+// Every page fetched is recorded as a SocketsPaginator call, with the page number and size
+// as arguments.
+func (r *Requester) ResumeSocketsPaginator(_ context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Socket] {
+	return client.ResumePaginator(checkpoint, func(ctx context.Context, number, size int) ([]client.Socket, int, error) {
+		out, err := do(ctx, r, "SocketsPaginator", []any{number, size}, func() (page[client.Socket], error) {
+			sockets, err := r.listSockets(checkpoint.Filters)
+			if err != nil {
+				return page[client.Socket]{}, err
+			}
+			return pageOf(sockets, number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// listSockets lists the sockets matching the given filters (see client.SocketFilterValues),
+// and the tag selector of the filters, which the client applies instead of the API.
+func (r *Requester) listSockets(filters url.Values) ([]client.Socket, error) {
+	tags, err := selector.Parse(filters.Get("tag_selector"))
+	if err != nil {
+		return nil, err
+	}
+	sockets := r.store.listSockets(socketFilterFrom(filters))
+	return slices.DeleteFunc(sockets, func(s client.Socket) bool { return !tags.Matches(s.Tags) }), nil
+}
+
+// CreateSocket creates a socket.
+func (r *Requester) CreateSocket(ctx context.Context, in *client.Socket) (*client.Socket, error) {
+	out, err := do(ctx, r, "CreateSocket", []any{in}, func() (client.Socket, error) { return r.store.createSocket(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateSocket updates a socket.
+func (r *Requester) UpdateSocket(ctx context.Context, idOrName string, in *client.Socket) (*client.Socket, error) {
+	out, err := do(ctx, r, "UpdateSocket", []any{idOrName, in}, func() (client.Socket, error) {
+		return r.store.updateSocket(idOrName, clone(*in))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EnsureSocket creates the desired socket if it does not exist, and updates it if it has
+// drifted, see client.ReconcileSocket.
+func (r *Requester) EnsureSocket(ctx context.Context, desired *client.Socket, opts ...client.ReconcileOption) (*client.EnsureSocketReport, error) {
+	args := []any{desired}
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return do(ctx, r, "EnsureSocket", args, func() (*client.EnsureSocketReport, error) {
+		if desired.Name == "" {
+			return nil, errors.New("the desired socket has no name")
+		}
+		current, err := r.store.socket(desired.Name)
+		if client.NotFound(err) {
+			created, err := r.store.createSocket(clone(*desired))
+			if err != nil {
+				return nil, err
+			}
+			return &client.EnsureSocketReport{Socket: &created, Created: true}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		update, changes, err := client.ReconcileSocket(&current, desired, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			return &client.EnsureSocketReport{Socket: &current}, nil
+		}
+		updated, err := r.store.updateSocket(current.SocketID, *update)
+		if err != nil {
+			return nil, err
+		}
+		return &client.EnsureSocketReport{Socket: &updated, Changes: changes}, nil
+	})
+}
+
+// DeleteSocket deletes a socket. If the socket does not exist, no error is returned.
+func (r *Requester) DeleteSocket(ctx context.Context, idOrName string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteSocket", []any{idOrName}, func() error { return r.store.deleteSocket(idOrName) }))
+}
+
+// SocketConnectors fetches the connectors linked to a socket.
+func (r *Requester) SocketConnectors(ctx context.Context, idOrName string) (*client.SocketConnectors, error) {
+	out, err := do(ctx, r, "SocketConnectors", []any{idOrName}, func() (client.SocketConnectors, error) {
+		return r.store.socketConnectors(idOrName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SocketUpstreamConfigs fetches the upstream configurations of a socket.
+func (r *Requester) SocketUpstreamConfigs(ctx context.Context, idOrName string) (*client.SocketUpstreamConfigs, error) {
+	out, err := do(ctx, r, "SocketUpstreamConfigs", []any{idOrName}, func() (client.SocketUpstreamConfigs, error) {
+		return r.store.socketUpstreamConfigs(idOrName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SignSocketKey signs an SSH key for a socket.
+func (r *Requester) SignSocketKey(ctx context.Context, idOrName string, in *client.SocketKeyToSign) (*client.SignedSocketKey, error) {
+	out, err := do(ctx, r, "SignSocketKey", []any{idOrName, in}, func() (client.SignedSocketKey, error) {
+		return r.store.signSocketKey(idOrName, clone(*in))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ---------------------------------------------------------------------------------------
+// policies
+// ---------------------------------------------------------------------------------------
+
+// Policy fetches a policy by id.
+func (r *Requester) Policy(ctx context.Context, id string) (*client.Policy, error) {
+	out, err := do(ctx, r, "Policy", []any{id}, func() (client.Policy, error) { return r.store.policy(id) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("policy [%s] not found: %w", id, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Policies fetches all policies.
+func (r *Requester) Policies(ctx context.Context) ([]client.Policy, error) {
+	return do(ctx, r, "Policies", nil, func() ([]client.Policy, error) { return r.store.listPolicies(), nil })
+}
+
+// PoliciesPaginator returns a paginator to iterate pages of policies. Every page fetched
+// is recorded as a call, with the page number and size as arguments.
+func (r *Requester) PoliciesPaginator(_ context.Context, pageSize int) *client.Paginator[client.Policy] {
+	return client.NewPaginator(pageSize, func(ctx context.Context, number, size int) ([]client.Policy, int, error) {
+		out, err := do(ctx, r, "PoliciesPaginator", []any{number, size}, func() (page[client.Policy], error) {
+			return pageOf(r.store.listPolicies(), number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// PoliciesByNames fetches the policies with the given names, all of which must exist.
+func (r *Requester) PoliciesByNames(ctx context.Context, names ...string) ([]client.Policy, error) {
+	args := make([]any, 0, len(names))
+	for _, name := range names {
+		args = append(args, name)
+	}
+	return do(ctx, r, "PoliciesByNames", args, func() ([]client.Policy, error) {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no policy names provided")
+		}
+		var out []client.Policy
+		for _, name := range names {
+			policy, err := r.store.policyByName(name)
+			if err != nil {
+				if client.NotFound(err) {
+					return nil, fmt.Errorf("policy [%s] does not exist, please create the policy first", name)
+				}
+				return nil, err
+			}
+			out = append(out, policy)
+		}
+		return out, nil
+	})
+}
+
+// CreatePolicy creates a policy.
+func (r *Requester) CreatePolicy(ctx context.Context, in *client.Policy) (*client.Policy, error) {
+	out, err := do(ctx, r, "CreatePolicy", []any{in}, func() (client.Policy, error) { return r.store.createPolicy(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePolicy updates a policy.
+func (r *Requester) UpdatePolicy(ctx context.Context, id string, in *client.Policy) (*client.Policy, error) {
+	out, err := do(ctx, r, "UpdatePolicy", []any{id, in}, func() (client.Policy, error) { return r.store.updatePolicy(id, clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePolicy deletes a policy. If the policy does not exist, no error is returned.
+func (r *Requester) DeletePolicy(ctx context.Context, id string) error {
+	return ignoreNotFound(exec(ctx, r, "DeletePolicy", []any{id}, func() error { return r.store.deletePolicy(id) }))
+}
+
+// AttachPolicyToSocket attaches a policy to a socket.
+func (r *Requester) AttachPolicyToSocket(ctx context.Context, policyID string, socketID string) error {
+	return exec(ctx, r, "AttachPolicyToSocket", []any{policyID, socketID}, func() error {
+		return r.store.updatePolicySockets(policyID, attachments("add", socketID))
+	})
+}
+
+// RemovePolicyFromSocket detaches a policy from a socket.
+func (r *Requester) RemovePolicyFromSocket(ctx context.Context, policyID string, socketID string) error {
+	return exec(ctx, r, "RemovePolicyFromSocket", []any{policyID, socketID}, func() error {
+		return r.store.updatePolicySockets(policyID, attachments("remove", socketID))
+	})
+}
+
+// AttachPoliciesToSocket attaches policies to a socket.
+func (r *Requester) AttachPoliciesToSocket(ctx context.Context, policyIDs []string, socketID string) error {
+	return exec(ctx, r, "AttachPoliciesToSocket", []any{policyIDs, socketID}, func() error {
+		return r.store.updateSocketPolicies(socketID, attachments("add", policyIDs...))
+	})
+}
+
+// RemovePoliciesFromSocket detaches policies from a socket.
+func (r *Requester) RemovePoliciesFromSocket(ctx context.Context, policyIDs []string, socketID string) error {
+	return exec(ctx, r, "RemovePoliciesFromSocket", []any{policyIDs, socketID}, func() error {
+		return r.store.updateSocketPolicies(socketID, attachments("remove", policyIDs...))
+	})
+}
+
+// attachments returns the given action for each of the given ids.
+func attachments(action string, ids ...string) client.PolicySocketAttachments {
+	in := client.PolicySocketAttachments{Actions: []client.PolicySocketAttachment{}}
+	for _, id := range ids {
+		in.Actions = append(in.Actions, client.PolicySocketAttachment{Action: action, ID: id})
+	}
+	return in
+}
+
+// ---------------------------------------------------------------------------------------
+// connectors
+// ---------------------------------------------------------------------------------------
+
+// Connector fetches a connector by id.
+func (r *Requester) Connector(ctx context.Context, id string) (*client.Connector, error) {
+	out, err := do(ctx, r, "Connector", []any{id}, func() (client.Connector, error) { return r.store.connector(id) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("connector [%s] not found: %w", id, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Connectors fetches all connectors.
+func (r *Requester) Connectors(ctx context.Context) (*client.Connectors, error) {
+	out, err := do(ctx, r, "Connectors", nil, func() (client.Connectors, error) { return r.store.listConnectors(), nil })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConnectorsPaginator returns a paginator to iterate pages of connectors. Every page
+// fetched is recorded as a call, with the page number and size as arguments.
+func (r *Requester) ConnectorsPaginator(_ context.Context, pageSize int) *client.Paginator[client.Connector] {
+	return client.NewPaginator(pageSize, func(ctx context.Context, number, size int) ([]client.Connector, int, error) {
+		out, err := do(ctx, r, "ConnectorsPaginator", []any{number, size}, func() (page[client.Connector], error) {
+			return pageOf(r.store.listConnectors().List, number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// CreateConnector creates a connector.
+func (r *Requester) CreateConnector(ctx context.Context, in *client.Connector) (*client.Connector, error) {
+	out, err := do(ctx, r, "CreateConnector", []any{in}, func() (client.Connector, error) { return r.store.createConnector(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateConnector updates a connector.
+func (r *Requester) UpdateConnector(ctx context.Context, in *client.Connector) (*client.Connector, error) {
+	out, err := do(ctx, r, "UpdateConnector", []any{in}, func() (client.Connector, error) { return r.store.updateConnector(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteConnector deletes a connector and its tokens. If the connector does not exist, no
+// error is returned.
+func (r *Requester) DeleteConnector(ctx context.Context, id string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteConnector", []any{id}, func() error {
+		tokens, err := r.store.deleteConnector(id)
+		r.revoke(tokens...)
+		return err
+	}))
+}
+
+// ConnectorTokens fetches the tokens of a connector.
+func (r *Requester) ConnectorTokens(ctx context.Context, connectorID string) (*client.ConnectorTokens, error) {
+	out, err := do(ctx, r, "ConnectorTokens", []any{connectorID}, func() (client.ConnectorTokens, error) {
+		return r.store.connectorTokensOf(connectorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConnectorTokensPaginator returns a paginator to iterate pages of tokens of a connector.
+// Every page fetched is recorded as a call, with the page number and size as arguments.
+func (r *Requester) ConnectorTokensPaginator(_ context.Context, connectorID string, pageSize int) *client.Paginator[client.ConnectorToken] {
+	return client.NewPaginator(pageSize, func(ctx context.Context, number, size int) ([]client.ConnectorToken, int, error) {
+		out, err := do(ctx, r, "ConnectorTokensPaginator", []any{number, size}, func() (page[client.ConnectorToken], error) {
+			tokens, err := r.store.connectorTokensOf(connectorID)
+			if err != nil {
+				return page[client.ConnectorToken]{}, err
+			}
+			return pageOf(tokens.List, number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// ConnectorToken fetches a token of a connector.
+func (r *Requester) ConnectorToken(ctx context.Context, connectorID string, tokenID string) (*client.ConnectorToken, error) {
+	out, err := do(ctx, r, "ConnectorToken", []any{connectorID, tokenID}, func() (client.ConnectorToken, error) {
+		return r.store.connectorToken(connectorID, tokenID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateConnectorToken creates a token for a connector.
+func (r *Requester) CreateConnectorToken(ctx context.Context, in *client.ConnectorToken) (*client.ConnectorToken, error) {
+	out, err := do(ctx, r, "CreateConnectorToken", []any{in}, func() (client.ConnectorToken, error) {
+		token := r.issue(jwt.MapClaims{"connector_id": in.ConnectorID})
+		out, err := r.store.createConnectorToken(clone(*in), token)
+		if err != nil {
+			r.revoke(token)
+		}
+		return out, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteConnectorToken deletes a token of a connector. If the token does not exist, no
+// error is returned.
+func (r *Requester) DeleteConnectorToken(ctx context.Context, connectorID, tokenID string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteConnectorToken", []any{connectorID, tokenID}, func() error {
+		token, err := r.store.deleteConnectorToken(connectorID, tokenID)
+		r.revoke(token)
+		return err
+	}))
+}
+
+// ---------------------------------------------------------------------------------------
+// users
+// ---------------------------------------------------------------------------------------
+
+// User fetches a user by id.
+func (r *Requester) User(ctx context.Context, id string) (*client.User, error) {
+	out, err := do(ctx, r, "User", []any{id}, func() (client.User, error) { return r.store.user(id) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("user with ID [%s] not found: %w", id, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Users fetches all users.
+func (r *Requester) Users(ctx context.Context) (*client.Users, error) {
+	users, err := do(ctx, r, "Users", nil, func() ([]client.User, error) { return r.store.listUsers(), nil })
+	if err != nil {
+		return nil, err
+	}
+	var all []client.User
+	return &client.Users{List: append(all, users...)}, nil
+}
+
+// UsersPaginator returns a paginator to iterate pages of users. Every page fetched is
+// recorded as a call, with the page number and size as arguments.
+func (r *Requester) UsersPaginator(ctx context.Context, pageSize int) *client.Paginator[client.User] {
+	return r.ResumeUsersPaginator(ctx, client.Checkpoint{PageSize: pageSize})
+}
+
+// ResumeUsersPaginator returns a users paginator which resumes from the given checkpoint.
+// Every page fetched is recorded as a UsersPaginator call, with the page number and size
+// as arguments.
+func (r *Requester) ResumeUsersPaginator(_ context.Context, checkpoint client.Checkpoint) *client.Paginator[client.User] {
+	return client.ResumePaginator(checkpoint, func(ctx context.Context, number, size int) ([]client.User, int, error) {
+		out, err := do(ctx, r, "UsersPaginator", []any{number, size}, func() (page[client.User], error) {
+			return pageOf(r.store.listUsers(), number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// CreateUser creates a user.
+func (r *Requester) CreateUser(ctx context.Context, in *client.User, opts ...client.UserOption) (*client.User, error) {
+	args := []any{in}
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	out, err := do(ctx, r, "CreateUser", args, func() (client.User, error) { return r.store.createUser(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUser updates a user.
+func (r *Requester) UpdateUser(ctx context.Context, in *client.User) (*client.User, error) {
+	out, err := do(ctx, r, "UpdateUser", []any{in}, func() (client.User, error) { return r.store.updateUser(clone(*in)) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("user with ID [%s] not found: %w", in.ID, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteUser deletes a user. If the user does not exist, no error is returned.
+func (r *Requester) DeleteUser(ctx context.Context, id string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteUser", []any{id}, func() error { return r.store.deleteUser(id) }))
+}
+
+// ---------------------------------------------------------------------------------------
+// groups
+// ---------------------------------------------------------------------------------------
+
+// Group fetches a group by id.
+func (r *Requester) Group(ctx context.Context, id string) (*client.Group, error) {
+	out, err := do(ctx, r, "Group", []any{id}, func() (client.Group, error) { return r.store.group(id) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("group with ID [%s] not found: %w", id, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Groups fetches all groups.
+func (r *Requester) Groups(ctx context.Context) (*client.Groups, error) {
+	groups, err := do(ctx, r, "Groups", nil, func() ([]client.Group, error) { return r.store.listGroups(), nil })
+	if err != nil {
+		return nil, err
+	}
+	var all []client.Group
+	return &client.Groups{List: append(all, groups...)}, nil
+}
+
+// GroupsPaginator returns a paginator to iterate pages of groups. Every page fetched is
+// recorded as a call, with the page number and size as arguments.
+func (r *Requester) GroupsPaginator(ctx context.Context, pageSize int) *client.Paginator[client.Group] {
+	return r.ResumeGroupsPaginator(ctx, client.Checkpoint{PageSize: pageSize})
+}
+
+// ResumeGroupsPaginator returns a groups paginator which resumes from the given checkpoint.
+// Every page fetched is recorded as a GroupsPaginator call, with the page number and size
+// as arguments.
+func (r *Requester) ResumeGroupsPaginator(_ context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Group] {
+	return client.ResumePaginator(checkpoint, func(ctx context.Context, number, size int) ([]client.Group, int, error) {
+		out, err := do(ctx, r, "GroupsPaginator", []any{number, size}, func() (page[client.Group], error) {
+			return pageOf(r.store.listGroups(), number, size), nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// CreateGroup creates a group.
+func (r *Requester) CreateGroup(ctx context.Context, in *client.Group) (*client.Group, error) {
+	out, err := do(ctx, r, "CreateGroup", []any{in}, func() (client.Group, error) { return r.store.createGroup(clone(*in)) })
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGroup updates a group.
+func (r *Requester) UpdateGroup(ctx context.Context, in *client.Group) (*client.Group, error) {
+	out, err := do(ctx, r, "UpdateGroup", []any{in}, func() (client.Group, error) { return r.store.updateGroup(clone(*in)) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("group with ID [%s] not found: %w", in.ID, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGroupMemberships sets the members of a group, and returns the updated group.
+func (r *Requester) UpdateGroupMemberships(ctx context.Context, in *client.Group, userIDs []string) (*client.Group, error) {
+	out, err := do(ctx, r, "UpdateGroupMemberships", []any{in, userIDs}, func() (client.Group, error) {
+		if err := r.store.updateGroupMemberships(in.ID, userIDs); err != nil {
+			return client.Group{}, err
+		}
+		return r.store.group(in.ID)
+	})
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("group with ID [%s] not found: %w", in.ID, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGroup deletes a group. If the group does not exist, no error is returned.
+func (r *Requester) DeleteGroup(ctx context.Context, id string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteGroup", []any{id}, func() error { return r.store.deleteGroup(id) }))
+}
+
+// ---------------------------------------------------------------------------------------
+// service accounts
+// ---------------------------------------------------------------------------------------
+
+// ServiceAccount fetches a service account by name.
+func (r *Requester) ServiceAccount(ctx context.Context, name string) (*client.ServiceAccount, error) {
+	out, err := do(ctx, r, "ServiceAccount", []any{name}, func() (client.ServiceAccount, error) { return r.store.serviceAccount(name) })
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("service account with name [%s] not found: %w", name, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateServiceAccount creates a service account.
+func (r *Requester) CreateServiceAccount(ctx context.Context, in *client.ServiceAccount) (*client.ServiceAccount, error) {
+	out, err := do(ctx, r, "CreateServiceAccount", []any{in}, func() (client.ServiceAccount, error) {
+		return r.store.createServiceAccount(clone(*in))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateServiceAccount updates a service account.
+func (r *Requester) UpdateServiceAccount(ctx context.Context, in *client.ServiceAccount) (*client.ServiceAccount, error) {
+	out, err := do(ctx, r, "UpdateServiceAccount", []any{in}, func() (client.ServiceAccount, error) {
+		return r.store.updateServiceAccount(in.Name, clone(*in))
+	})
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("service account with name [%s] not found: %w", in.Name, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteServiceAccount deletes a service account and its tokens. If the service account
+// does not exist, no error is returned.
+func (r *Requester) DeleteServiceAccount(ctx context.Context, name string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteServiceAccount", []any{name}, func() error {
+		tokens, err := r.store.deleteServiceAccount(name)
+		r.revoke(tokens...)
+		return err
+	}))
+}
+
+// ServiceAccountTokens fetches the tokens of a service account.
+func (r *Requester) ServiceAccountTokens(ctx context.Context, serviceAccountName string) (*client.ServiceAccountTokens, error) {
+	out, err := do(ctx, r, "ServiceAccountTokens", []any{serviceAccountName}, func() (client.ServiceAccountTokens, error) {
+		return r.store.serviceAccountTokensOf(serviceAccountName)
+	})
+	if err != nil {
+		if client.NotFound(err) {
+			return nil, fmt.Errorf("service account with name [%s] not found: %w", serviceAccountName, err)
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ServiceAccountTokensPaginator returns a paginator to iterate pages of tokens of a service
+// account. Every page fetched is recorded as a call, with the page number and size as arguments.
+func (r *Requester) ServiceAccountTokensPaginator(_ context.Context, serviceAccountName string, pageSize int) *client.Paginator[client.ServiceAccountToken] {
+	return client.NewPaginator(pageSize, func(ctx context.Context, number, size int) ([]client.ServiceAccountToken, int, error) {
+		out, err := do(ctx, r, "ServiceAccountTokensPaginator", []any{number, size}, func() (page[client.ServiceAccountToken], error) {
+			tokens, err := r.store.serviceAccountTokensOf(serviceAccountName)
+			if err != nil {
+				return page[client.ServiceAccountToken]{}, err
+			}
+			return pageOf(tokens.List, number, size), nil
+		})
+		if err != nil {
+			if client.NotFound(err) {
+				return nil, 0, fmt.Errorf("service account with name [%s] not found: %w", serviceAccountName, err)
+			}
+			return nil, 0, err
+		}
+		return out.List, out.Pagination.NextPage, nil
+	})
+}
+
+// CreateServiceAccountToken creates a token for a service account.
+func (r *Requester) CreateServiceAccountToken(ctx context.Context, serviceAccountName string, in *client.ServiceAccountToken) (*client.ServiceAccountToken, error) {
+	out, err := do(ctx, r, "CreateServiceAccountToken", []any{serviceAccountName, in}, func() (client.ServiceAccountToken, error) {
+		serviceAccount, _ := r.store.serviceAccount(serviceAccountName) // creating the token fails below if it does not exist
+		token := r.issue(jwt.MapClaims{"service_account_id": serviceAccount.ID, "service_account_name": serviceAccountName, "role": serviceAccount.Role})
+		out, err := r.store.createServiceAccountToken(serviceAccountName, clone(*in), token)
+		if err != nil {
+			r.revoke(token)
+		}
+		return out, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteServiceAccountToken deletes a token of a service account. If the token does not
+// exist, no error is returned.
+func (r *Requester) DeleteServiceAccountToken(ctx context.Context, serviceAccountName, tokenID string) error {
+	return ignoreNotFound(exec(ctx, r, "DeleteServiceAccountToken", []any{serviceAccountName, tokenID}, func() error {
+		token, err := r.store.deleteServiceAccountToken(serviceAccountName, tokenID)
+		r.revoke(token)
+		return err
+	}))
+}
@@ -0,0 +1,356 @@
+package border0test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/lib/types/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Requester_sockets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+
+	created, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.SocketID)
+
+	byName, err := api.Socket(ctx, "web")
+	require.NoError(t, err)
+	byID, err := api.Socket(ctx, created.SocketID)
+	require.NoError(t, err)
+	assert.Equal(t, created, byName)
+	assert.Equal(t, created, byID)
+
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	assert.True(t, client.IsConflict(err))
+
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "db", SocketType: "database"})
+	require.NoError(t, err)
+
+	databases, err := api.Sockets(ctx, client.WithType("database"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, socketNames(databases))
+	none, err := api.Sockets(ctx, client.WithName("none"))
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	var pages [][]string
+	for result := range api.SocketsPaginator(ctx, 1).Iter(ctx) {
+		require.NoError(t, result.Err)
+		pages = append(pages, socketNames(result.Items))
+	}
+	assert.Equal(t, [][]string{{"db"}, {"web"}}, pages)
+
+	paginator := api.SocketsPaginator(ctx, 1, client.WithType("http"))
+	_, err = paginator.Next(ctx)
+	require.NoError(t, err)
+	resumed, err := client.Collect(api.ResumeSocketsPaginator(ctx, paginator.Checkpoint()).Items(ctx))
+	require.NoError(t, err)
+	assert.Empty(t, resumed, "resumed paginators keep the filters")
+
+	require.NoError(t, api.DeleteSocket(ctx, "web"))
+	_, err = api.Socket(ctx, "web")
+	assert.True(t, client.NotFound(err))
+	assert.ErrorContains(t, err, "socket [web] not found")
+	assert.NoError(t, api.DeleteSocket(ctx, "web"), "deleting a socket which does not exist is not an error")
+}
+
+func Test_Requester_EnsureSocket(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+
+	report, err := api.EnsureSocket(ctx, &client.Socket{Name: "web", SocketType: "http", Tags: map[string]string{"env": "prod"}})
+	require.NoError(t, err)
+	assert.True(t, report.Created)
+	assert.NotEmpty(t, report.Socket.SocketID)
+
+	report, err = api.EnsureSocket(ctx, &client.Socket{Name: "web", SocketType: "http", Tags: map[string]string{"env": "prod"}})
+	require.NoError(t, err)
+	assert.False(t, report.Changed(), "the socket already matches")
+
+	report, err = api.EnsureSocket(ctx, &client.Socket{Name: "web", SocketType: "http", Description: "website"})
+	require.NoError(t, err)
+	assert.Equal(t, []client.SocketChange{{Field: "description", Current: nil, Desired: "website"}}, report.Changes, "tags left empty are not reconciled")
+
+	report, err = api.EnsureSocket(ctx, &client.Socket{Name: "web", SocketType: "http", Description: "website"}, client.ClearSocketFields("tags"))
+	require.NoError(t, err)
+	assert.Equal(t, []client.SocketChange{
+		{Field: "tags", Current: map[string]any{"env": "prod"}, Desired: nil},
+	}, report.Changes)
+	got, err := api.Socket(ctx, "web")
+	require.NoError(t, err)
+	assert.Equal(t, "website", got.Description)
+	assert.Empty(t, got.Tags, "fields to clear are cleared")
+	assert.Equal(t, report.Socket.SocketID, got.SocketID, "the socket id is not reconciled")
+}
+
+func Test_Requester_tagSelector(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+	for name, tags := range map[string]map[string]string{
+		"payments": {"env": "prod", "team": "payments"},
+		"risk":     {"env": "prod", "team": "risk", "legacy": "true"},
+		"search":   {"env": "staging", "team": "search"},
+	} {
+		_, err := api.CreateSocket(ctx, &client.Socket{Name: name, SocketType: "http", Tags: tags})
+		require.NoError(t, err)
+	}
+
+	selected, err := api.Sockets(ctx, client.WithTagSelector(selector.MustParse("env=prod,!legacy")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments"}, socketNames(selected))
+
+	paginated, err := client.Collect(api.SocketsPaginator(ctx, 1, client.WithTagSelector(selector.MustParse("team in (risk,search)"))).Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"risk", "search"}, socketNames(paginated))
+}
+
+func Test_Requester_policies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+
+	socket, err := api.CreateSocket(ctx, &client.Socket{Name: "ssh", SocketType: "ssh"})
+	require.NoError(t, err)
+	policy, err := api.CreatePolicy(ctx, &client.Policy{Name: "policy", PolicyData: testPolicyData})
+	require.NoError(t, err)
+
+	require.NoError(t, api.AttachPolicyToSocket(ctx, policy.ID, socket.SocketID))
+	socket, err = api.Socket(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{policy.ID}, policyIDs(socket.Policies))
+	policy, err = api.Policy(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{socket.SocketID}, policy.SocketIDs)
+
+	require.NoError(t, api.RemovePoliciesFromSocket(ctx, []string{policy.ID}, socket.Name))
+	policy, err = api.Policy(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Empty(t, policy.SocketIDs)
+
+	_, err = api.PoliciesByNames(ctx, "policy", "missing")
+	assert.EqualError(t, err, "policy [missing] does not exist, please create the policy first")
+}
+
+func Test_Requester_WatchGroups(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	api := NewRequester()
+	alice, err := api.CreateUser(ctx, &client.User{Email: "alice@example.com", DisplayName: "Alice", Role: "admin"})
+	require.NoError(t, err)
+	group, err := api.CreateGroup(ctx, &client.Group{DisplayName: "engineers"})
+	require.NoError(t, err)
+
+	watcher := client.WatchGroups(api).WithInterval(time.Millisecond).WithDebounce(time.Millisecond, time.Second)
+	var events []client.Event[client.Group]
+	for event, err := range watcher.Events(ctx) {
+		require.NoError(t, err)
+		events = append(events, event)
+		if len(events) == 1 {
+			_, err = api.UpdateGroupMemberships(ctx, group, []string{alice.ID})
+			require.NoError(t, err)
+		}
+		if len(events) == 2 {
+			break
+		}
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, client.EventAdded, events[0].Type)
+	assert.Equal(t, client.EventModified, events[1].Type, "group memberships changed")
+	assert.Equal(t, group.ID, events[1].ID)
+	assert.Empty(t, events[1].Previous.Members)
+	require.Len(t, events[1].Object.Members, 1)
+	assert.Equal(t, alice.ID, events[1].Object.Members[0].ID)
+}
+
+func Test_Requester_connectors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+
+	connector, err := api.CreateConnector(ctx, &client.Connector{Name: "connector"})
+	require.NoError(t, err)
+	socket, err := api.CreateSocket(ctx, &client.Socket{Name: "ssh", SocketType: "ssh", ConnectorIDs: []string{connector.ConnectorID}})
+	require.NoError(t, err)
+
+	linked, err := api.SocketConnectors(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Len(t, linked.List, 1)
+
+	_, err = api.CreateConnector(ctx, &client.Connector{Name: "other"})
+	require.NoError(t, err)
+	connectors, err := client.Collect(api.ConnectorsPaginator(ctx, 1).Items(ctx))
+	require.NoError(t, err)
+	assert.Len(t, connectors, 2)
+	for _, name := range []string{"first", "second"} {
+		_, err = api.CreateConnectorToken(ctx, &client.ConnectorToken{ConnectorID: connector.ConnectorID, Name: name})
+		require.NoError(t, err)
+	}
+	tokens, err := client.Collect(api.ConnectorTokensPaginator(ctx, connector.ConnectorID, 1).Items(ctx))
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+
+	require.NoError(t, api.DeleteConnector(ctx, connector.ConnectorID))
+	linked, err = api.SocketConnectors(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Empty(t, linked.List)
+	_, err = client.Collect(api.ConnectorTokensPaginator(ctx, connector.ConnectorID, 1).Items(ctx))
+	assert.True(t, client.NotFound(err))
+}
+
+func Test_Requester_hooks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	api := NewRequester()
+
+	var observed []string
+	api.AddHook(func(_ context.Context, call Call) error {
+		observed = append(observed, call.Method)
+		if call.Method == "CreatePolicy" {
+			return errors.New("policies are read-only")
+		}
+		return nil
+	})
+
+	_, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	_, err = api.CreatePolicy(ctx, &client.Policy{Name: "policy", PolicyData: testPolicyData})
+	assert.EqualError(t, err, "policies are read-only")
+	policies, err := api.Policies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, policies, "failed calls do not change any state")
+
+	assert.Equal(t, []string{"CreateSocket", "CreatePolicy", "Policies"}, observed)
+	calls := api.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, []any{&client.Socket{Name: "web", SocketType: "http"}}, calls[0].Args)
+}
+
+func Test_Requester_InjectError(t *testing.T) {
+	t.Parallel()
+
+	notFound := client.Error{Code: http.StatusNotFound, Message: "not found"}
+	unavailable := client.Error{Code: http.StatusServiceUnavailable, Message: "service unavailable"}
+
+	tests := []struct {
+		name    string
+		method  string
+		err     error
+		call    func(context.Context, *Requester) error
+		wantErr string
+	}{
+		{
+			name:   "not found deletes are not errors",
+			method: "DeleteSocket",
+			err:    notFound,
+			call: func(ctx context.Context, api *Requester) error {
+				return api.DeleteSocket(ctx, "web")
+			},
+		},
+		{
+			name:   "not found gets are wrapped",
+			method: "Socket",
+			err:    notFound,
+			call: func(ctx context.Context, api *Requester) error {
+				_, err := api.Socket(ctx, "web")
+				return err
+			},
+			wantErr: "socket [web] not found: 404: not found",
+		},
+		{
+			name:   "other errors are returned as they are",
+			method: "Sockets",
+			err:    unavailable,
+			call: func(ctx context.Context, api *Requester) error {
+				_, err := api.Sockets(ctx)
+				return err
+			},
+			wantErr: "503: service unavailable",
+		},
+		{
+			name:   "paginators fail when fetching pages",
+			method: "SocketsPaginator",
+			err:    unavailable,
+			call: func(ctx context.Context, api *Requester) error {
+				_, err := api.SocketsPaginator(ctx, 10).Next(ctx)
+				return err
+			},
+			wantErr: "503: service unavailable",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			api := NewRequester()
+			_, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+			require.NoError(t, err)
+			api.InjectError(test.method, test.err, 1)
+
+			err = test.call(ctx, api)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+			assert.NoError(t, test.call(ctx, api), "errors are only injected the given number of times")
+		})
+	}
+}
+
+func Test_Server_Requester(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer(WithReadAfterWriteDelay(250 * time.Millisecond))
+	defer srv.Close()
+	fake, api := srv.Requester(), srv.Client()
+
+	_, err := fake.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	socket, err := api.Socket(ctx, "web")
+	require.NoError(t, err)
+	assert.Equal(t, "web", socket.Name)
+
+	_, err = fake.CreateServiceAccount(ctx, &client.ServiceAccount{Name: "ci", Role: "member"})
+	require.NoError(t, err)
+	token, err := fake.CreateServiceAccountToken(ctx, "ci", &client.ServiceAccountToken{Name: "token"})
+	require.NoError(t, err)
+	_, err = srv.Client(client.WithAuthToken(token.Token)).Sockets(ctx)
+	require.NoError(t, err, "tokens created with the fake are accepted by the server")
+
+	require.NoError(t, fake.DeleteServiceAccount(ctx, "ci"))
+	_, err = srv.Client(client.WithAuthToken(token.Token)).Sockets(ctx)
+	assert.True(t, client.IsUnauthorized(err))
+
+	claims, err := fake.TokenClaims()
+	require.NoError(t, err)
+	assert.Equal(t, srv.OrgID(), claims["org_id"])
+	typed, err := fake.Claims(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, client.TokenTypeUser, typed.Type)
+	assert.NoError(t, typed.RequireManage(client.ResourceServiceAccounts), "the fake organization's admin manages everything")
+	info, err := fake.ServerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), info.DataConsistency.RxAfterTxDelayMS)
+}
@@ -0,0 +1,527 @@
+package border0test
+
+import (
+	"net/http"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// routes registers the handlers of the endpoints of the fake Border0 API.
+func (s *Server) routes(mux *http.ServeMux) {
+	// public endpoints
+	mux.HandleFunc("GET /serverinfo", s.serverInfo)
+	mux.HandleFunc("POST /device_authorizations", s.createDeviceAuthorization)
+	mux.HandleFunc("GET /device_authorizations", s.deviceAuthorizationStatus)
+	mux.HandleFunc("POST /auth/web_identity/exchange", s.exchangeWebIdentityToken)
+
+	// sockets
+	mux.HandleFunc("GET /socket/{idOrName}", s.authenticated(s.getSocket))
+	mux.HandleFunc("GET /sockets", s.authenticated(s.listSockets))
+	mux.HandleFunc("POST /socket", s.authenticated(s.createSocket))
+	mux.HandleFunc("PUT /socket/{idOrName}", s.authenticated(s.updateSocket))
+	mux.HandleFunc("DELETE /socket/{idOrName}", s.authenticated(s.deleteSocket))
+	mux.HandleFunc("GET /socket/{idOrName}/connectors", s.authenticated(s.socketConnectors))
+	mux.HandleFunc("GET /socket/{idOrName}/upstream_configurations", s.authenticated(s.socketUpstreamConfigs))
+	mux.HandleFunc("POST /socket/{idOrName}/signkey", s.authenticated(s.signSocketKey))
+	mux.HandleFunc("PUT /socket/{idOrName}/policy", s.authenticated(s.updateSocketPolicies))
+
+	// policies
+	mux.HandleFunc("GET /policy/{id}", s.authenticated(s.getPolicy))
+	mux.HandleFunc("GET /policies", s.authenticated(s.listPolicies))
+	mux.HandleFunc("GET /policies/find", s.authenticated(s.findPolicy))
+	mux.HandleFunc("POST /policies", s.authenticated(s.createPolicy))
+	mux.HandleFunc("PUT /policy/{id}", s.authenticated(s.updatePolicy))
+	mux.HandleFunc("DELETE /policy/{id}", s.authenticated(s.deletePolicy))
+	mux.HandleFunc("PUT /policy/{id}/socket", s.authenticated(s.updatePolicySockets))
+
+	// connectors
+	mux.HandleFunc("GET /connector/{id}", s.authenticated(s.getConnector))
+	mux.HandleFunc("GET /connectors", s.authenticated(s.listConnectors))
+	mux.HandleFunc("POST /connector", s.authenticated(s.createConnector))
+	mux.HandleFunc("PUT /connector", s.authenticated(s.updateConnector))
+	mux.HandleFunc("DELETE /connector/{id}", s.authenticated(s.deleteConnector))
+	mux.HandleFunc("GET /connector/{id}/tokens", s.authenticated(s.connectorTokens))
+	mux.HandleFunc("GET /connector/{id}/token/{tokenID}", s.authenticated(s.connectorToken))
+	mux.HandleFunc("POST /connector/token", s.authenticated(s.createConnectorToken))
+	mux.HandleFunc("DELETE /connector/{id}/token/{tokenID}", s.authenticated(s.deleteConnectorToken))
+
+	// users
+	mux.HandleFunc("GET /organizations/iam/users/{id}", s.authenticated(s.getUser))
+	mux.HandleFunc("GET /organizations/iam/users", s.authenticated(s.listUsers))
+	mux.HandleFunc("POST /organizations/iam/users", s.authenticated(s.createUser))
+	mux.HandleFunc("PUT /organizations/iam/users", s.authenticated(s.updateUser))
+	mux.HandleFunc("DELETE /organizations/iam/users/{id}", s.authenticated(s.deleteUser))
+
+	// groups
+	mux.HandleFunc("GET /organizations/iam/groups/{id}", s.authenticated(s.getGroup))
+	mux.HandleFunc("GET /organizations/iam/groups", s.authenticated(s.listGroups))
+	mux.HandleFunc("POST /organizations/iam/groups", s.authenticated(s.createGroup))
+	mux.HandleFunc("PUT /organizations/iam/groups", s.authenticated(s.updateGroup))
+	mux.HandleFunc("PUT /organizations/iam/groups/memberships", s.authenticated(s.updateGroupMemberships))
+	mux.HandleFunc("DELETE /organizations/iam/groups/{id}", s.authenticated(s.deleteGroup))
+
+	// service accounts
+	mux.HandleFunc("GET /organizations/iam/service_accounts/{name}", s.authenticated(s.getServiceAccount))
+	mux.HandleFunc("POST /organizations/iam/service_accounts", s.authenticated(s.createServiceAccount))
+	mux.HandleFunc("PUT /organizations/iam/service_accounts/{name}", s.authenticated(s.updateServiceAccount))
+	mux.HandleFunc("DELETE /organizations/iam/service_accounts/{name}", s.authenticated(s.deleteServiceAccount))
+	mux.HandleFunc("GET /organizations/iam/service_accounts/{name}/tokens", s.authenticated(s.serviceAccountTokens))
+	mux.HandleFunc("POST /organizations/iam/service_accounts/{name}/tokens", s.authenticated(s.createServiceAccountToken))
+	mux.HandleFunc("DELETE /organizations/iam/service_accounts/{name}/tokens/{tokenID}", s.authenticated(s.deleteServiceAccountToken))
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errorf(http.StatusNotFound, "route not found"))
+	})
+}
+
+// replicated writes a 404 response and returns false if the resource of the given kind
+// with the given identifier was created too recently to be replicated.
+func (s *Server) replicated(w http.ResponseWriter, kind, identifier string) bool {
+	if s.lagging(kind, identifier) {
+		writeError(w, errorf(http.StatusNotFound, "%s not found", kind))
+		return false
+	}
+	return true
+}
+
+// ---------------------------------------------------------------------------------------
+// public endpoints
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) serverInfo(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	delay := s.readAfterWriteMS
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, client.ServerInfo{
+		DataConsistency: &client.DataConsistency{RxAfterTxDelayMS: delay},
+	})
+}
+
+// deviceAuthorization is the body of device authorization responses.
+type deviceAuthorization struct {
+	Token string `json:"token,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+// createDeviceAuthorization starts a device authorization, which is authorized right away.
+func (s *Server) createDeviceAuthorization(w http.ResponseWriter, _ *http.Request) {
+	token := s.issueToken(jwt.MapClaims{"identifier": uuid.NewString(), "device_authorization": true})
+	writeJSON(w, http.StatusOK, deviceAuthorization{Token: token})
+}
+
+// deviceAuthorizationStatus returns a token for the device authorization whose token is
+// sent in the x-access-token header.
+func (s *Server) deviceAuthorizationStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	valid := s.tokens[r.Header.Get("x-access-token")]
+	s.mu.Unlock()
+	if !valid {
+		writeError(w, errorf(http.StatusUnauthorized, "unauthorized"))
+		return
+	}
+	writeJSON(w, http.StatusOK, deviceAuthorization{Token: s.token, State: "authorized"})
+}
+
+func (s *Server) exchangeWebIdentityToken(w http.ResponseWriter, r *http.Request) {
+	var in client.WebIdentityTokenExchangeInput
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	serviceAccount, err := s.store.webIdentityServiceAccount(in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	token := s.issueToken(jwt.MapClaims{"service_account_id": serviceAccount.ID, "service_account_name": serviceAccount.Name, "role": serviceAccount.Role})
+	writeJSON(w, http.StatusOK, client.WebIdentityTokenExchangeOutput{Token: token})
+}
+
+// ---------------------------------------------------------------------------------------
+// sockets
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getSocket(w http.ResponseWriter, r *http.Request) {
+	idOrName := r.PathValue("idOrName")
+	if !s.replicated(w, "socket", idOrName) {
+		return
+	}
+	out, err := s.store.socket(idOrName)
+	respond(w, out, err)
+}
+
+func (s *Server) listSockets(w http.ResponseWriter, r *http.Request) {
+	sockets := s.store.listSockets(socketFilterFrom(r.URL.Query()))
+	out, err := paginate(r, sockets)
+	respond(w, out, err)
+}
+
+func (s *Server) createSocket(w http.ResponseWriter, r *http.Request) {
+	var in client.Socket
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createSocket(in)
+	if err == nil {
+		s.created("socket", out.SocketID, out.Name)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updateSocket(w http.ResponseWriter, r *http.Request) {
+	var in client.Socket
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updateSocket(r.PathValue("idOrName"), in)
+	respond(w, out, err)
+}
+
+func (s *Server) deleteSocket(w http.ResponseWriter, r *http.Request) {
+	respond(w, struct{}{}, s.store.deleteSocket(r.PathValue("idOrName")))
+}
+
+func (s *Server) socketConnectors(w http.ResponseWriter, r *http.Request) {
+	out, err := s.store.socketConnectors(r.PathValue("idOrName"))
+	respond(w, out, err)
+}
+
+func (s *Server) socketUpstreamConfigs(w http.ResponseWriter, r *http.Request) {
+	out, err := s.store.socketUpstreamConfigs(r.PathValue("idOrName"))
+	respond(w, out, err)
+}
+
+func (s *Server) signSocketKey(w http.ResponseWriter, r *http.Request) {
+	var in client.SocketKeyToSign
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.signSocketKey(r.PathValue("idOrName"), in)
+	respond(w, out, err)
+}
+
+func (s *Server) updateSocketPolicies(w http.ResponseWriter, r *http.Request) {
+	var in client.PolicySocketAttachments
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	respond(w, struct{}{}, s.store.updateSocketPolicies(r.PathValue("idOrName"), in))
+}
+
+// ---------------------------------------------------------------------------------------
+// policies
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getPolicy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.replicated(w, "policy", id) {
+		return
+	}
+	out, err := s.store.policy(id)
+	respond(w, out, err)
+}
+
+func (s *Server) listPolicies(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.listPolicies())
+}
+
+func (s *Server) findPolicy(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if !s.replicated(w, "policy", name) {
+		return
+	}
+	out, err := s.store.policyByName(name)
+	respond(w, out, err)
+}
+
+func (s *Server) createPolicy(w http.ResponseWriter, r *http.Request) {
+	var in client.Policy
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createPolicy(in)
+	if err == nil {
+		s.created("policy", out.ID, out.Name)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updatePolicy(w http.ResponseWriter, r *http.Request) {
+	var in client.Policy
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updatePolicy(r.PathValue("id"), in)
+	respond(w, out, err)
+}
+
+func (s *Server) deletePolicy(w http.ResponseWriter, r *http.Request) {
+	respond(w, struct{}{}, s.store.deletePolicy(r.PathValue("id")))
+}
+
+func (s *Server) updatePolicySockets(w http.ResponseWriter, r *http.Request) {
+	var in client.PolicySocketAttachments
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	respond(w, struct{}{}, s.store.updatePolicySockets(r.PathValue("id"), in))
+}
+
+// ---------------------------------------------------------------------------------------
+// connectors
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getConnector(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.replicated(w, "connector", id) {
+		return
+	}
+	out, err := s.store.connector(id)
+	respond(w, out, err)
+}
+
+func (s *Server) listConnectors(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.listConnectors())
+}
+
+func (s *Server) createConnector(w http.ResponseWriter, r *http.Request) {
+	var in client.Connector
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createConnector(in)
+	if err == nil {
+		s.created("connector", out.ConnectorID)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updateConnector(w http.ResponseWriter, r *http.Request) {
+	var in client.Connector
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updateConnector(in)
+	respond(w, out, err)
+}
+
+func (s *Server) deleteConnector(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.store.deleteConnector(r.PathValue("id"))
+	s.revokeTokens(tokens...)
+	respond(w, struct{}{}, err)
+}
+
+func (s *Server) connectorTokens(w http.ResponseWriter, r *http.Request) {
+	out, err := s.store.connectorTokensOf(r.PathValue("id"))
+	respond(w, out, err)
+}
+
+func (s *Server) connectorToken(w http.ResponseWriter, r *http.Request) {
+	out, err := s.store.connectorToken(r.PathValue("id"), r.PathValue("tokenID"))
+	respond(w, out, err)
+}
+
+func (s *Server) createConnectorToken(w http.ResponseWriter, r *http.Request) {
+	var in client.ConnectorToken
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	token := s.issueToken(jwt.MapClaims{"connector_id": in.ConnectorID})
+	out, err := s.store.createConnectorToken(in, token)
+	if err != nil {
+		s.RevokeToken(token)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) deleteConnectorToken(w http.ResponseWriter, r *http.Request) {
+	token, err := s.store.deleteConnectorToken(r.PathValue("id"), r.PathValue("tokenID"))
+	s.revokeTokens(token)
+	respond(w, struct{}{}, err)
+}
+
+// ---------------------------------------------------------------------------------------
+// users
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.replicated(w, "user", id) {
+		return
+	}
+	out, err := s.store.user(id)
+	respond(w, out, err)
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	out, err := paginate(r, s.store.listUsers())
+	respond(w, out, err)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var in client.User
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createUser(in)
+	if err == nil {
+		s.created("user", out.ID)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	var in client.User
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updateUser(in)
+	respond(w, out, err)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	respond(w, struct{}{}, s.store.deleteUser(r.PathValue("id")))
+}
+
+// ---------------------------------------------------------------------------------------
+// groups
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.replicated(w, "group", id) {
+		return
+	}
+	out, err := s.store.group(id)
+	respond(w, out, err)
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	out, err := paginate(r, s.store.listGroups())
+	respond(w, out, err)
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var in client.Group
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createGroup(in)
+	if err == nil {
+		s.created("group", out.ID)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var in client.Group
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updateGroup(in)
+	respond(w, out, err)
+}
+
+// groupMemberships is the body of group membership updates.
+type groupMemberships struct {
+	ID    string   `json:"id"`
+	Users []string `json:"users"`
+}
+
+func (s *Server) updateGroupMemberships(w http.ResponseWriter, r *http.Request) {
+	var in groupMemberships
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	respond(w, struct{}{}, s.store.updateGroupMemberships(in.ID, in.Users))
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	respond(w, struct{}{}, s.store.deleteGroup(r.PathValue("id")))
+}
+
+// ---------------------------------------------------------------------------------------
+// service accounts
+// ---------------------------------------------------------------------------------------
+
+func (s *Server) getServiceAccount(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.replicated(w, "service account", name) {
+		return
+	}
+	out, err := s.store.serviceAccount(name)
+	respond(w, out, err)
+}
+
+func (s *Server) createServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var in client.ServiceAccount
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.createServiceAccount(in)
+	if err == nil {
+		s.created("service account", out.Name)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) updateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var in client.ServiceAccount
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := s.store.updateServiceAccount(r.PathValue("name"), in)
+	respond(w, out, err)
+}
+
+func (s *Server) deleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.store.deleteServiceAccount(r.PathValue("name"))
+	s.revokeTokens(tokens...)
+	respond(w, struct{}{}, err)
+}
+
+func (s *Server) serviceAccountTokens(w http.ResponseWriter, r *http.Request) {
+	out, err := s.store.serviceAccountTokensOf(r.PathValue("name"))
+	respond(w, out, err)
+}
+
+func (s *Server) createServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	var in client.ServiceAccountToken
+	if err := decode(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	name := r.PathValue("name")
+	serviceAccount, _ := s.store.serviceAccount(name) // creating the token fails below if it does not exist
+	token := s.issueToken(jwt.MapClaims{"service_account_id": serviceAccount.ID, "service_account_name": name, "role": serviceAccount.Role})
+	out, err := s.store.createServiceAccountToken(name, in, token)
+	if err != nil {
+		s.RevokeToken(token)
+	}
+	respond(w, out, err)
+}
+
+func (s *Server) deleteServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	token, err := s.store.deleteServiceAccountToken(r.PathValue("name"), r.PathValue("tokenID"))
+	s.revokeTokens(token)
+	respond(w, struct{}{}, err)
+}
@@ -0,0 +1,395 @@
+// Package border0test provides fakes of the Border0 API for testing code that uses the
+// Border0 Go SDK, without scripting every call with mocks.
+//
+// Server is an in-memory implementation of the Border0 API served over HTTP, to be used
+// with a real client.APIClient:
+//
+//	srv := border0test.NewServer()
+//	defer srv.Close()
+//
+//	api := srv.Client()
+//	socket, err := api.CreateSocket(ctx, &client.Socket{Name: "my-socket", SocketType: "http"})
+//
+// The server keeps realistic state (sockets, policies and their attachments, connectors and
+// their tokens, users, groups and service accounts), and faults like replication lag, rate
+// limiting and server errors can be injected into it.
+package border0test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// APIPath is the path the fake Border0 API is served under, same as the real one.
+const APIPath = "/api/v1"
+
+const (
+	defaultOrgSubdomain = "border0test"
+	defaultPageSize     = 100
+)
+
+// Server is an in-memory fake of the Border0 API, served by an httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	store *store
+
+	signingKey []byte
+	token      string
+
+	mu               sync.Mutex
+	tokens           map[string]bool // tokens accepted by the server
+	faults           []*Fault
+	requests         []Request
+	replicationLag   time.Duration
+	createdAt        map[string]time.Time // by kind and identifier of created resources
+	readAfterWriteMS int64
+}
+
+// Option is a function that configures a Server.
+type Option func(*Server)
+
+// WithOrgSubdomain sets the subdomain of the fake organization, which is also part of the
+// DNS names of its sockets. Defaults to "border0test".
+func WithOrgSubdomain(subdomain string) Option {
+	return func(s *Server) {
+		s.store.orgSubdomain = subdomain
+	}
+}
+
+// WithReplicationLag makes newly created resources (sockets, policies, connectors, users,
+// groups and service accounts) not found by reads of them for the given duration, like the
+// Border0 API does while a write is replicated across regions.
+func WithReplicationLag(lag time.Duration) Option {
+	return func(s *Server) {
+		s.replicationLag = lag
+	}
+}
+
+// WithReadAfterWriteDelay sets the read-after-write delay advertised in the server info.
+func WithReadAfterWriteDelay(delay time.Duration) Option {
+	return func(s *Server) {
+		s.readAfterWriteMS = delay.Milliseconds()
+	}
+}
+
+// NewServer starts and returns a new fake Border0 API server. The caller should call Close
+// when finished, to shut it down.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		store:      newStore(uuid.NewString(), defaultOrgSubdomain),
+		signingKey: []byte(uuid.NewString()),
+		tokens:     make(map[string]bool),
+		createdAt:  make(map[string]time.Time),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.token = s.issueToken(jwt.MapClaims{"user_email": "admin@" + s.store.orgSubdomain + ".com", "role": client.RoleAdmin})
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// BaseURL returns the base URL of the fake Border0 API, to be used with client.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + APIPath
+}
+
+// Token returns a token accepted by the server, for the admin of the fake organization.
+func (s *Server) Token() string {
+	return s.token
+}
+
+// OrgID returns the id of the fake organization.
+func (s *Server) OrgID() string {
+	return s.store.orgID
+}
+
+// Client returns a Border0 API client for the server, authenticated with Token. Retries
+// don't wait, and further options can be given to override the defaults.
+func (s *Server) Client(options ...client.Option) *client.APIClient {
+	defaults := []client.Option{
+		client.WithBaseURL(s.BaseURL()),
+		client.WithAuthToken(s.token),
+		client.WithBackoff(func(_, _ time.Duration, _ int) time.Duration { return 0 }),
+	}
+	return client.New(append(defaults, options...)...)
+}
+
+// issueToken returns a new token accepted by the server, with the given claims on top of
+// the claims of the fake organization.
+func (s *Server) issueToken(claims jwt.MapClaims) string {
+	token := s.store.signToken(s.signingKey, claims)
+	s.mu.Lock()
+	s.tokens[token] = true
+	s.mu.Unlock()
+	return token
+}
+
+// RevokeToken makes the server reject the given token from now on.
+func (s *Server) RevokeToken(token string) {
+	s.revokeTokens(token)
+}
+
+func (s *Server) revokeTokens(tokens ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range tokens {
+		delete(s.tokens, token)
+	}
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string // path relative to APIPath, with the query string if any
+	Body   json.RawMessage
+}
+
+// Requests returns the requests received by the server so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Fault is a failure to inject into the responses of the server.
+type Fault struct {
+	Method     string        // HTTP method of the requests to fail, all methods if empty
+	Path       string        // path.Match pattern of the paths (relative to APIPath, without query) to fail
+	StatusCode int           // status code to respond with
+	Message    string        // error message to respond with, defaults to the status text
+	RetryAfter time.Duration // Retry-After to respond with, if not zero
+	Times      int           // number of requests to fail, all requests if zero
+
+	failed int
+}
+
+// InjectFault makes the server fail requests matching the given fault. Faults are matched
+// in the order they were injected.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all the injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// matchFault returns the first injected fault matching the given request, if any.
+func (s *Server) matchFault(method, urlPath string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fault := range s.faults {
+		if fault.Method != "" && fault.Method != method {
+			continue
+		}
+		if ok, _ := path.Match(fault.Path, urlPath); !ok {
+			continue
+		}
+		if fault.Times > 0 && fault.failed >= fault.Times {
+			continue
+		}
+		fault.failed++
+		copied := *fault
+		return &copied
+	}
+	return nil
+}
+
+// created records that a resource of the given kind with the given identifiers (id, name,
+// etc.) was just created.
+func (s *Server) created(kind string, identifiers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, identifier := range identifiers {
+		s.createdAt[kind+"/"+identifier] = now
+	}
+}
+
+// lagging reports whether the resource of the given kind with the given identifier was
+// created too recently to be replicated, in which case it must not be found.
+func (s *Server) lagging(kind, identifier string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	createdAt, ok := s.createdAt[kind+"/"+identifier]
+	return ok && time.Since(createdAt) < s.replicationLag
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	s.routes(mux)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", uuid.NewString())
+
+		var body json.RawMessage
+		if r.Body != nil {
+			body, _ = io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		if len(body) == 0 {
+			body = nil
+		}
+		relative := strings.TrimPrefix(r.URL.Path, APIPath)
+		requestPath := relative
+		if r.URL.RawQuery != "" {
+			requestPath += "?" + r.URL.RawQuery
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: requestPath, Body: body})
+		s.mu.Unlock()
+
+		if fault := s.matchFault(r.Method, relative); fault != nil {
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+			}
+			message := fault.Message
+			if message == "" {
+				message = strings.ToLower(http.StatusText(fault.StatusCode))
+			}
+			writeError(w, errorf(fault.StatusCode, "%s", message))
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, APIPath+"/") {
+			writeError(w, errorf(http.StatusNotFound, "route not found"))
+			return
+		}
+		r.URL.Path = relative
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authenticated makes the given handler require a token accepted by the server.
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		valid := ok && s.tokens[token]
+		s.mu.Unlock()
+		if !valid {
+			writeError(w, errorf(http.StatusUnauthorized, "unauthorized"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// decode decodes the body of the given request into the output.
+func decode(r *http.Request, output any) error {
+	body, _ := io.ReadAll(r.Body)
+	if len(body) == 0 {
+		return invalid("request body is required")
+	}
+	if err := json.Unmarshal(body, output); err != nil {
+		return invalid(fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errorBody is the body of an error response of the Border0 API.
+type errorBody struct {
+	Code    int                 `json:"status_code"`
+	Message string              `json:"error_message"`
+	Details []client.FieldError `json:"details,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(client.Error)
+	if !ok {
+		apiErr = client.Error{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	writeJSON(w, apiErr.Code, errorBody{Code: apiErr.Code, Message: apiErr.Message, Details: apiErr.Details})
+}
+
+// respond writes the given output, or the given error if not nil.
+func respond[T any](w http.ResponseWriter, out T, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// pagination is the pagination metadata of the responses of paginated endpoints.
+type pagination struct {
+	CurrentPage    int `json:"current_page"`
+	NextPage       int `json:"next_page"`
+	TotalRecords   int `json:"total_records"`
+	TotalPages     int `json:"total_pages"`
+	RecordsPerPage int `json:"records_per_page"`
+	ActualPageSize int `json:"actual_page_size"`
+}
+
+// page is the response of paginated endpoints.
+type page[T any] struct {
+	Pagination pagination `json:"pagination"`
+	List       []T        `json:"list"`
+}
+
+// paginate returns the page of the given items requested by the page and page_size query
+// parameters of the given request.
+func paginate[T any](r *http.Request, items []T) (page[T], error) {
+	number, size := 1, defaultPageSize
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return page[T]{}, invalid("invalid page", client.FieldError{Field: "page", Message: "must be a positive integer"})
+		}
+		number = n
+	}
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return page[T]{}, invalid("invalid page size", client.FieldError{Field: "page_size", Message: "must be a positive integer"})
+		}
+		size = n
+	}
+	return pageOf(items, number, size), nil
+}
+
+// pageOf returns the page with the given number and size of the given items.
+func pageOf[T any](items []T, number, size int) page[T] {
+	totalPages := (len(items) + size - 1) / size
+	start := min((number-1)*size, len(items))
+	end := min(start+size, len(items))
+	out := page[T]{
+		Pagination: pagination{
+			CurrentPage:    number,
+			TotalRecords:   len(items),
+			TotalPages:     totalPages,
+			RecordsPerPage: size,
+			ActualPageSize: end - start,
+		},
+		List: append([]T{}, items[start:end]...),
+	}
+	if number < totalPages {
+		out.Pagination.NextPage = number + 1
+	}
+	return out
+}
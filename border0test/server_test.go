@@ -0,0 +1,427 @@
+package border0test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/lib/types/selector"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicyData = map[string]any{"version": "v1", "action": []string{"database", "ssh", "http", "tls"}}
+
+func Test_Server_sockets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	api := srv.Client()
+
+	created, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http", Tags: map[string]string{"env": "prod"}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.SocketID)
+	assert.Equal(t, "web-border0test.border0.io", created.DNS)
+
+	byName, err := api.Socket(ctx, "web")
+	require.NoError(t, err)
+	byID, err := api.Socket(ctx, created.SocketID)
+	require.NoError(t, err)
+	assert.Equal(t, created, byName)
+	assert.Equal(t, created, byID)
+
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	assert.True(t, client.IsConflict(err))
+
+	_, err = api.CreateSocket(ctx, &client.Socket{})
+	apiErr, ok := client.BadRequest(err)
+	require.True(t, ok)
+	assert.Equal(t, []client.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "socket_type", Message: "is required"},
+	}, apiErr.Details)
+
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "db", SocketType: "database", Tags: map[string]string{"role": "primary"}})
+	require.NoError(t, err)
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "db-replica", SocketType: "database", Tags: map[string]string{"role": "replica"}})
+	require.NoError(t, err)
+
+	all, err := api.Sockets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "db-replica", "web"}, socketNames(all))
+
+	databases, err := api.Sockets(ctx, client.WithType("database"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "db-replica"}, socketNames(databases))
+
+	searched, err := api.Sockets(ctx, client.WithSearch("replica"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-replica"}, socketNames(searched))
+
+	replicas, err := api.Sockets(ctx, client.WithTagSelector(selector.MustParse("role=replica")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-replica"}, socketNames(replicas))
+	notPrimaries, err := api.Sockets(ctx, client.WithTagSelector(selector.MustParse("role notin (primary)")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-replica", "web"}, socketNames(notPrimaries))
+
+	paginator := api.SocketsPaginator(ctx, 2)
+	var pages [][]string
+	for paginator.HasNext() {
+		items, err := paginator.Next(ctx)
+		require.NoError(t, err)
+		if len(items) > 0 {
+			pages = append(pages, socketNames(items))
+		}
+	}
+	assert.Equal(t, [][]string{{"db", "db-replica"}, {"web"}}, pages)
+
+	updated, err := api.UpdateSocket(ctx, "web", &client.Socket{Name: "web", SocketType: "http", Description: "updated"})
+	require.NoError(t, err)
+	assert.Equal(t, "updated", updated.Description)
+	assert.Equal(t, created.SocketID, updated.SocketID)
+
+	require.NoError(t, api.DeleteSocket(ctx, "web"))
+	_, err = api.Socket(ctx, "web")
+	assert.True(t, client.NotFound(err))
+}
+
+func Test_Server_policies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	api := srv.Client()
+
+	socket, err := api.CreateSocket(ctx, &client.Socket{Name: "ssh", SocketType: "ssh"})
+	require.NoError(t, err)
+	first, err := api.CreatePolicy(ctx, &client.Policy{Name: "first", PolicyData: testPolicyData})
+	require.NoError(t, err)
+	second, err := api.CreatePolicy(ctx, &client.Policy{Name: "second", PolicyData: testPolicyData})
+	require.NoError(t, err)
+	assert.Equal(t, srv.OrgID(), first.OrgID)
+
+	found, err := api.PoliciesByNames(ctx, "second", "first")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, policyIDs(found))
+
+	require.NoError(t, api.AttachPolicyToSocket(ctx, first.ID, socket.SocketID))
+	require.NoError(t, api.AttachPoliciesToSocket(ctx, []string{second.ID}, socket.SocketID))
+
+	got, err := api.Socket(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, policyIDs(got.Policies))
+	policy, err := api.Policy(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{socket.SocketID}, policy.SocketIDs)
+
+	require.NoError(t, api.RemovePolicyFromSocket(ctx, first.ID, socket.SocketID))
+	got, err = api.Socket(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.ID}, policyIDs(got.Policies))
+
+	require.NoError(t, api.DeletePolicy(ctx, second.ID))
+	got, err = api.Socket(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Policies)
+
+	err = api.AttachPolicyToSocket(ctx, second.ID, socket.SocketID)
+	assert.True(t, client.NotFound(err))
+}
+
+func Test_Server_connectors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	api := srv.Client()
+
+	connector, err := api.CreateConnector(ctx, &client.Connector{Name: "connector"})
+	require.NoError(t, err)
+	socket, err := api.CreateSocket(ctx, &client.Socket{Name: "ssh", SocketType: "ssh", ConnectorIDs: []string{connector.ConnectorID}})
+	require.NoError(t, err)
+
+	linked, err := api.SocketConnectors(ctx, socket.SocketID)
+	require.NoError(t, err)
+	require.Len(t, linked.List, 1)
+	assert.Equal(t, connector.ConnectorID, linked.List[0].ConnectorID)
+	assert.Equal(t, "connector", linked.List[0].ConnectorName)
+
+	token, err := api.CreateConnectorToken(ctx, &client.ConnectorToken{ConnectorID: connector.ConnectorID, Name: "token"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+	tokens, err := api.ConnectorTokens(ctx, connector.ConnectorID)
+	require.NoError(t, err)
+	require.Len(t, tokens.List, 1)
+	assert.Equal(t, token.ID, tokens.List[0].ID)
+	assert.Empty(t, tokens.List[0].Token, "token values are only returned when created")
+
+	require.NoError(t, api.DeleteConnector(ctx, connector.ConnectorID))
+	linked, err = api.SocketConnectors(ctx, socket.SocketID)
+	require.NoError(t, err)
+	assert.Empty(t, linked.List)
+	_, err = api.ConnectorTokens(ctx, connector.ConnectorID)
+	assert.True(t, client.NotFound(err))
+}
+
+func Test_Server_iam(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	api := srv.Client()
+
+	alice, err := api.CreateUser(ctx, &client.User{Email: "alice@example.com", DisplayName: "Alice", Role: "admin"})
+	require.NoError(t, err)
+	bob, err := api.CreateUser(ctx, &client.User{Email: "bob@example.com", DisplayName: "Bob", Role: "member"})
+	require.NoError(t, err)
+	_, err = api.CreateUser(ctx, &client.User{Email: "alice@example.com", DisplayName: "Alice", Role: "admin"})
+	assert.True(t, client.IsConflict(err))
+
+	group, err := api.CreateGroup(ctx, &client.Group{DisplayName: "engineers"})
+	require.NoError(t, err)
+	group, err = api.UpdateGroupMemberships(ctx, group, []string{alice.ID, bob.ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{alice.ID, bob.ID}, userIDs(group.Members))
+
+	require.NoError(t, api.DeleteUser(ctx, bob.ID))
+	group, err = api.Group(ctx, group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{alice.ID}, userIDs(group.Members))
+
+	account, err := api.CreateServiceAccount(ctx, &client.ServiceAccount{Name: "ci", Role: client.RoleReadOnly, Active: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, account.ID)
+	token, err := api.CreateServiceAccountToken(ctx, "ci", &client.ServiceAccountToken{Name: "token"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+
+	// service account tokens are accepted by the server
+	claims, err := srv.Client(client.WithAuthToken(token.Token)).TokenClaims()
+	require.NoError(t, err)
+	assert.Equal(t, srv.OrgID(), claims["org_id"])
+	typed, err := srv.Client(client.WithAuthToken(token.Token)).Claims(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, client.TokenTypeServiceAccount, typed.Type)
+	assert.EqualError(t, typed.RequireManage(client.ResourceUsers), "the Border0 token of service account [ci] has the role [read_only], which can't manage users")
+	_, err = srv.Client(client.WithAuthToken(token.Token)).Sockets(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, api.DeleteServiceAccountToken(ctx, "ci", token.ID))
+	_, err = srv.Client(client.WithAuthToken(token.Token)).Sockets(ctx)
+	assert.True(t, client.IsUnauthorized(err))
+	require.NoError(t, api.DeleteServiceAccount(ctx, "ci"))
+	_, err = api.ServiceAccount(ctx, "ci")
+	assert.True(t, client.NotFound(err))
+}
+
+func Test_Server_authentication(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+
+	_, err := srv.Client(client.WithAuthToken("invalid")).Sockets(ctx)
+	assert.True(t, client.IsUnauthorized(err))
+
+	api := srv.Client(client.WithAuthToken(""))
+	require.NoError(t, api.Authenticate(ctx, auth.WithOpenBrowser(false), auth.WithTokenWriting(false)))
+	_, err = api.Sockets(ctx)
+	require.NoError(t, err)
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"profiles": {"test": {}}}`), 0600))
+	api = srv.Client(client.WithAuthToken(""), client.WithProfileFromFile(configFile, "test"))
+	require.NoError(t, api.Authenticate(ctx, auth.WithOpenBrowser(false)))
+	profile, err := client.LoadProfile(configFile, "test")
+	require.NoError(t, err)
+	require.NotNil(t, profile.StoredToken, "the profile refers to its stored token")
+	assert.Empty(t, profile.Token, "tokens are kept out of the config file")
+	_, err = srv.Client(client.WithAuthToken(""), client.WithProfileFromFile(configFile, "test")).Sockets(ctx)
+	require.NoError(t, err)
+
+	srv.RevokeToken(srv.Token())
+	_, err = api.Sockets(ctx)
+	assert.True(t, client.IsUnauthorized(err))
+}
+
+func Test_Server_reauthentication(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}).SignedString([]byte("key"))
+	require.NoError(t, err)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(expired), 0600))
+
+	api := srv.Client(client.WithAuthToken(""), client.WithReauthentication(auth.WithOpenBrowser(false), auth.WithTokenStorageFilePath(tokenFile)))
+	authenticated, err := api.IsAuthenticated(ctx)
+	require.NoError(t, err)
+	assert.False(t, authenticated, "expired stored tokens are not re-authenticated to check it")
+
+	_, err = api.Sockets(ctx)
+	require.NoError(t, err)
+	authenticated, err = api.IsAuthenticated(ctx, client.WithAPIValidation(true))
+	require.NoError(t, err)
+	assert.True(t, authenticated)
+	stored, err := os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, srv.Token(), string(stored), "the new token is stored")
+}
+
+func Test_Server_faults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		fault        Fault
+		call         func(context.Context, *client.APIClient) error
+		wantErr      func(error) bool
+		wantRequests int
+	}{
+		{
+			name:  "server errors of idempotent requests are retried",
+			fault: Fault{Method: http.MethodGet, Path: "/sockets", StatusCode: http.StatusBadGateway, Times: 2},
+			call: func(ctx context.Context, api *client.APIClient) error {
+				_, err := api.Sockets(ctx)
+				return err
+			},
+			wantRequests: 3,
+		},
+		{
+			name:  "server errors of non-idempotent requests are not retried",
+			fault: Fault{Method: http.MethodPost, Path: "/socket", StatusCode: http.StatusInternalServerError, Times: 1},
+			call: func(ctx context.Context, api *client.APIClient) error {
+				_, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+				return err
+			},
+			wantErr:      func(err error) bool { return err != nil },
+			wantRequests: 1,
+		},
+		{
+			name:  "rate limited requests are retried",
+			fault: Fault{Path: "/sockets", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second, Times: 1},
+			call: func(ctx context.Context, api *client.APIClient) error {
+				_, err := api.Sockets(ctx)
+				return err
+			},
+			wantRequests: 2,
+		},
+		{
+			name:  "rate limited requests fail after too many retries",
+			fault: Fault{Path: "/socket/*", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute},
+			call: func(ctx context.Context, api *client.APIClient) error {
+				_, err := api.Socket(ctx, "web")
+				return err
+			},
+			wantErr:      client.IsRateLimited,
+			wantRequests: 11,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := NewServer()
+			defer srv.Close()
+			srv.InjectFault(test.fault)
+
+			err := test.call(context.Background(), srv.Client())
+			if test.wantErr != nil {
+				assert.True(t, test.wantErr(err), "unexpected error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, srv.Requests(), test.wantRequests)
+		})
+	}
+}
+
+func Test_Server_replicationLag(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer(WithReplicationLag(time.Second), WithReadAfterWriteDelay(250*time.Millisecond))
+	defer srv.Close()
+
+	info, err := srv.Client().ServerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &client.ServerInfo{DataConsistency: &client.DataConsistency{RxAfterTxDelayMS: 250}}, info)
+
+	api := srv.Client()
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	_, err = api.Socket(ctx, "web")
+	assert.True(t, client.NotFound(err), "reads right after writes are not found")
+
+	// with the real backoff, the lag is waited out by retries
+	patient := srv.Client(client.WithBackoff(func(_, _ time.Duration, _ int) time.Duration { return 500 * time.Millisecond }))
+	socket, err := patient.Socket(ctx, "web")
+	require.NoError(t, err)
+	assert.Equal(t, "web", socket.Name)
+}
+
+func Test_Server_Requests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := NewServer()
+	defer srv.Close()
+	api := srv.Client()
+
+	_, err := api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	_, err = api.Sockets(ctx, client.WithName("web"))
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, "/socket", requests[0].Path)
+	assert.JSONEq(t, `{"name":"web","socket_id":"","socket_type":"http","recording_enabled":false}`, string(requests[0].Body))
+	assert.Equal(t, http.MethodGet, requests[1].Method)
+	assert.Contains(t, requests[1].Path, "name=web")
+	assert.Nil(t, requests[1].Body)
+}
+
+func socketNames(sockets []client.Socket) []string {
+	names := make([]string, 0, len(sockets))
+	for _, socket := range sockets {
+		names = append(names, socket.Name)
+	}
+	return names
+}
+
+func policyIDs(policies []client.Policy) []string {
+	ids := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		ids = append(ids, policy.ID)
+	}
+	return ids
+}
+
+func userIDs(users []client.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
@@ -0,0 +1,1076 @@
+package border0test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// store holds the state of a fake Border0 organization. All of its methods are safe for
+// concurrent use, and they never return pointers into the state, only copies of it. Errors
+// returned are client.Error values, as they would be returned by the Border0 API.
+type store struct {
+	mu sync.Mutex
+
+	orgID        string
+	orgSubdomain string
+
+	sockets              map[string]*client.Socket // by socket id
+	upstreamConfigTimes  map[string][2]time.Time   // created and updated times, by socket id
+	policies             map[string]*client.Policy // by policy id
+	attachments          map[string][]string       // socket ids, by policy id
+	connectors           map[string]*client.Connector
+	connectorTokens      map[string]*client.ConnectorToken // by token id
+	users                map[string]*client.User
+	groups               map[string]*client.Group
+	groupMembers         map[string][]string                      // user ids, by group id
+	serviceAccounts      map[string]*client.ServiceAccount        // by name
+	serviceAccountTokens map[string][]*client.ServiceAccountToken // by service account name
+}
+
+func newStore(orgID, orgSubdomain string) *store {
+	return &store{
+		orgID:                orgID,
+		orgSubdomain:         orgSubdomain,
+		sockets:              make(map[string]*client.Socket),
+		upstreamConfigTimes:  make(map[string][2]time.Time),
+		policies:             make(map[string]*client.Policy),
+		attachments:          make(map[string][]string),
+		connectors:           make(map[string]*client.Connector),
+		connectorTokens:      make(map[string]*client.ConnectorToken),
+		users:                make(map[string]*client.User),
+		groups:               make(map[string]*client.Group),
+		groupMembers:         make(map[string][]string),
+		serviceAccounts:      make(map[string]*client.ServiceAccount),
+		serviceAccountTokens: make(map[string][]*client.ServiceAccountToken),
+	}
+}
+
+// errorf returns a client.Error with the given status code and formatted message.
+func errorf(code int, format string, args ...any) error {
+	return client.Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// invalid returns a 400 client.Error with the given field-level validation errors.
+func invalid(message string, details ...client.FieldError) error {
+	return client.Error{Code: http.StatusBadRequest, Message: message, Details: details}
+}
+
+// clone returns a deep copy of the given value, made by a JSON round trip so that copies
+// look exactly like what the Border0 API would return.
+func clone[T any](v T) T {
+	var out T
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("border0test: failed to encode %T: %v", v, err))
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		panic(fmt.Sprintf("border0test: failed to decode %T: %v", v, err))
+	}
+	return out
+}
+
+// signToken returns a token for the organization with the given claims, signed with the
+// given key.
+func (s *store) signToken(key []byte, claims jwt.MapClaims) string {
+	all := jwt.MapClaims{
+		"iss":           "border0test",
+		"org_id":        s.orgID,
+		"org_subdomain": s.orgSubdomain,
+		"iat":           time.Now().Unix(),
+		"exp":           time.Now().Add(24 * time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, all).SignedString(key)
+	if err != nil {
+		panic(fmt.Sprintf("border0test: failed to sign token: %v", err))
+	}
+	return token
+}
+
+// sortedValues returns copies of the values of the given map, sorted by the given key.
+func sortedValues[T any](m map[string]*T, key func(*T) string) []*T {
+	values := make([]*T, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	return values
+}
+
+// ---------------------------------------------------------------------------------------
+// sockets
+// ---------------------------------------------------------------------------------------
+
+// socketFilter filters sockets the same way as the Border0 API.
+type socketFilter struct {
+	name       string
+	search     string
+	socketType string
+}
+
+// socketFilterFrom returns the socket filter of the given query parameters.
+func socketFilterFrom(query url.Values) socketFilter {
+	return socketFilter{
+		name:       query.Get("name"),
+		search:     query.Get("search"),
+		socketType: query.Get("socket_type"),
+	}
+}
+
+func (f socketFilter) matches(s *client.Socket) bool {
+	if f.name != "" && s.Name != f.name {
+		return false
+	}
+	if f.socketType != "" && s.SocketType != f.socketType {
+		return false
+	}
+	if f.search != "" {
+		search := strings.ToLower(f.search)
+		if !strings.Contains(strings.ToLower(s.Name), search) &&
+			!strings.Contains(strings.ToLower(s.DisplayName), search) &&
+			!strings.Contains(strings.ToLower(s.Description), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// findSocket returns the socket with the given id or name. It must be called with the lock held.
+func (s *store) findSocket(idOrName string) (*client.Socket, error) {
+	if socket, ok := s.sockets[idOrName]; ok {
+		return socket, nil
+	}
+	for _, socket := range s.sockets {
+		if socket.Name == idOrName {
+			return socket, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "socket not found")
+}
+
+// renderSocket returns a copy of the given socket, with its attached policies. It must be
+// called with the lock held.
+func (s *store) renderSocket(socket *client.Socket) client.Socket {
+	out := clone(*socket)
+	for _, policy := range sortedValues(s.policies, func(p *client.Policy) string { return p.Name }) {
+		if slices.Contains(s.attachments[policy.ID], socket.SocketID) {
+			out.Policies = append(out.Policies, s.renderPolicy(policy))
+		}
+	}
+	return out
+}
+
+func (s *store) socket(idOrName string) (client.Socket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(idOrName)
+	if err != nil {
+		return client.Socket{}, err
+	}
+	return s.renderSocket(socket), nil
+}
+
+func (s *store) listSockets(filter socketFilter) []client.Socket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []client.Socket{}
+	for _, socket := range sortedValues(s.sockets, func(s *client.Socket) string { return s.Name }) {
+		if filter.matches(socket) {
+			out = append(out, s.renderSocket(socket))
+		}
+	}
+	return out
+}
+
+// validateSocket validates a socket to create or update. It must be called with the lock held.
+func (s *store) validateSocket(in *client.Socket, socketID string) error {
+	var details []client.FieldError
+	if in.Name == "" {
+		details = append(details, client.FieldError{Field: "name", Message: "is required"})
+	}
+	if in.SocketType == "" {
+		details = append(details, client.FieldError{Field: "socket_type", Message: "is required"})
+	}
+	for _, connectorID := range in.ConnectorIDs {
+		if _, ok := s.connectors[connectorID]; !ok {
+			details = append(details, client.FieldError{Field: "connector_ids", Message: fmt.Sprintf("connector %s does not exist", connectorID)})
+		}
+	}
+	if in.UpstreamConfig != nil {
+		if err := in.UpstreamConfig.Validate(); err != nil {
+			details = append(details, client.FieldError{Field: "upstream_configuration", Message: err.Error()})
+		}
+	}
+	if len(details) > 0 {
+		return invalid("invalid socket", details...)
+	}
+	for _, socket := range s.sockets {
+		if socket.Name == in.Name && socket.SocketID != socketID {
+			return errorf(http.StatusConflict, "socket with name %s already exists", in.Name)
+		}
+	}
+	return nil
+}
+
+func (s *store) createSocket(in client.Socket) (client.Socket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateSocket(&in, ""); err != nil {
+		return client.Socket{}, err
+	}
+	socket := clone(in)
+	socket.SocketID = uuid.NewString()
+	socket.Policies = nil
+	socket.DNS = fmt.Sprintf("%s-%s.border0.io", socket.Name, s.orgSubdomain)
+	s.sockets[socket.SocketID] = &socket
+	if socket.UpstreamConfig != nil {
+		now := time.Now()
+		s.upstreamConfigTimes[socket.SocketID] = [2]time.Time{now, now}
+	}
+	return s.renderSocket(&socket), nil
+}
+
+func (s *store) updateSocket(idOrName string, in client.Socket) (client.Socket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findSocket(idOrName)
+	if err != nil {
+		return client.Socket{}, err
+	}
+	if err := s.validateSocket(&in, existing.SocketID); err != nil {
+		return client.Socket{}, err
+	}
+	socket := clone(in)
+	socket.SocketID = existing.SocketID
+	socket.Policies = nil
+	socket.DNS = fmt.Sprintf("%s-%s.border0.io", socket.Name, s.orgSubdomain)
+	s.sockets[socket.SocketID] = &socket
+	if socket.UpstreamConfig == nil {
+		delete(s.upstreamConfigTimes, socket.SocketID)
+	} else {
+		now := time.Now()
+		times, ok := s.upstreamConfigTimes[socket.SocketID]
+		if !ok {
+			times[0] = now
+		}
+		times[1] = now
+		s.upstreamConfigTimes[socket.SocketID] = times
+	}
+	return s.renderSocket(&socket), nil
+}
+
+func (s *store) deleteSocket(idOrName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(idOrName)
+	if err != nil {
+		return err
+	}
+	delete(s.sockets, socket.SocketID)
+	delete(s.upstreamConfigTimes, socket.SocketID)
+	for policyID, socketIDs := range s.attachments {
+		s.attachments[policyID] = slices.DeleteFunc(socketIDs, func(id string) bool { return id == socket.SocketID })
+	}
+	return nil
+}
+
+func (s *store) socketConnectors(idOrName string) (client.SocketConnectors, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(idOrName)
+	if err != nil {
+		return client.SocketConnectors{}, err
+	}
+	out := client.SocketConnectors{List: []client.SocketConnector{}}
+	for i, connectorID := range socket.ConnectorIDs {
+		connector, ok := s.connectors[connectorID]
+		if !ok {
+			continue // deleted since
+		}
+		out.List = append(out.List, client.SocketConnector{
+			ID:            uint64(i + 1),
+			ConnectorID:   connectorID,
+			ConnectorName: connector.Name,
+			SocketID:      socket.SocketID,
+		})
+	}
+	return out, nil
+}
+
+func (s *store) socketUpstreamConfigs(idOrName string) (client.SocketUpstreamConfigs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(idOrName)
+	if err != nil {
+		return client.SocketUpstreamConfigs{}, err
+	}
+	out := client.SocketUpstreamConfigs{List: []client.SocketUpstreamConfig{}}
+	if socket.UpstreamConfig != nil {
+		times := s.upstreamConfigTimes[socket.SocketID]
+		out.List = append(out.List, client.SocketUpstreamConfig{
+			Config:    clone(*socket.UpstreamConfig),
+			CreatedAt: times[0],
+			UpdatedAt: times[1],
+		})
+	}
+	return out, nil
+}
+
+func (s *store) signSocketKey(idOrName string, in client.SocketKeyToSign) (client.SignedSocketKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(idOrName)
+	if err != nil {
+		return client.SignedSocketKey{}, err
+	}
+	if in.SSHPublicKey == "" {
+		return client.SignedSocketKey{}, invalid("invalid ssh public key", client.FieldError{Field: "ssh_public_key", Message: "is required"})
+	}
+	// not a real certificate, but it's unique to the socket and the key
+	cert := base64.StdEncoding.EncodeToString([]byte(socket.SocketID + " " + in.SSHPublicKey))
+	return client.SignedSocketKey{
+		SignedSSHCert: "ssh-ed25519-cert-v01@openssh.com " + cert,
+		HostKey:       "ssh-ed25519 " + base64.StdEncoding.EncodeToString([]byte(s.orgID)),
+	}, nil
+}
+
+// ---------------------------------------------------------------------------------------
+// policies
+// ---------------------------------------------------------------------------------------
+
+// renderPolicy returns a copy of the given policy, with the ids of the sockets it's
+// attached to. It must be called with the lock held.
+func (s *store) renderPolicy(policy *client.Policy) client.Policy {
+	out := clone(*policy)
+	out.SocketIDs = append([]string{}, s.attachments[policy.ID]...)
+	return out
+}
+
+func (s *store) findPolicy(id string) (*client.Policy, error) {
+	policy, ok := s.policies[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "policy not found")
+	}
+	return policy, nil
+}
+
+func (s *store) policy(id string) (client.Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, err := s.findPolicy(id)
+	if err != nil {
+		return client.Policy{}, err
+	}
+	return s.renderPolicy(policy), nil
+}
+
+func (s *store) policyByName(name string) (client.Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, policy := range s.policies {
+		if policy.Name == name {
+			return s.renderPolicy(policy), nil
+		}
+	}
+	return client.Policy{}, errorf(http.StatusNotFound, "policy not found")
+}
+
+func (s *store) listPolicies() []client.Policy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []client.Policy{}
+	for _, policy := range sortedValues(s.policies, func(p *client.Policy) string { return p.Name }) {
+		out = append(out, s.renderPolicy(policy))
+	}
+	return out
+}
+
+// validatePolicy validates a policy to create or update. It must be called with the lock held.
+func (s *store) validatePolicy(in *client.Policy, policyID string) error {
+	var details []client.FieldError
+	if in.Name == "" {
+		details = append(details, client.FieldError{Field: "name", Message: "is required"})
+	}
+	if in.PolicyData == nil {
+		details = append(details, client.FieldError{Field: "policy_data", Message: "is required"})
+	}
+	if len(details) > 0 {
+		return invalid("invalid policy", details...)
+	}
+	for _, policy := range s.policies {
+		if policy.Name == in.Name && policy.ID != policyID {
+			return errorf(http.StatusConflict, "policy with name %s already exists", in.Name)
+		}
+	}
+	return nil
+}
+
+func (s *store) createPolicy(in client.Policy) (client.Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validatePolicy(&in, ""); err != nil {
+		return client.Policy{}, err
+	}
+	policy := clone(in)
+	policy.ID = uuid.NewString()
+	policy.OrgID = s.orgID
+	policy.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	policy.SocketIDs = nil
+	if policy.Version == "" {
+		policy.Version = "v1"
+	}
+	s.policies[policy.ID] = &policy
+	return s.renderPolicy(&policy), nil
+}
+
+func (s *store) updatePolicy(id string, in client.Policy) (client.Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findPolicy(id)
+	if err != nil {
+		return client.Policy{}, err
+	}
+	if err := s.validatePolicy(&in, id); err != nil {
+		return client.Policy{}, err
+	}
+	policy := clone(in)
+	policy.ID = existing.ID
+	policy.OrgID = existing.OrgID
+	policy.CreatedAt = existing.CreatedAt
+	policy.SocketIDs = nil
+	if policy.Version == "" {
+		policy.Version = existing.Version
+	}
+	s.policies[id] = &policy
+	return s.renderPolicy(&policy), nil
+}
+
+func (s *store) deletePolicy(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findPolicy(id); err != nil {
+		return err
+	}
+	delete(s.policies, id)
+	delete(s.attachments, id)
+	return nil
+}
+
+// attach attaches or detaches a policy to or from a socket. It must be called with the lock held.
+func (s *store) attach(action, policyID, socketID string) error {
+	switch action {
+	case "add":
+		if !slices.Contains(s.attachments[policyID], socketID) {
+			s.attachments[policyID] = append(s.attachments[policyID], socketID)
+		}
+	case "remove":
+		s.attachments[policyID] = slices.DeleteFunc(s.attachments[policyID], func(id string) bool { return id == socketID })
+	default:
+		return invalid("invalid action", client.FieldError{Field: "action", Message: fmt.Sprintf("must be add or remove, got %q", action)})
+	}
+	return nil
+}
+
+// updatePolicySockets attaches and detaches a policy to and from sockets.
+func (s *store) updatePolicySockets(policyID string, in client.PolicySocketAttachments) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findPolicy(policyID); err != nil {
+		return err
+	}
+	for _, action := range in.Actions {
+		socket, err := s.findSocket(action.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.attach(action.Action, policyID, socket.SocketID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSocketPolicies attaches and detaches policies to and from a socket.
+func (s *store) updateSocketPolicies(socketIDOrName string, in client.PolicySocketAttachments) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	socket, err := s.findSocket(socketIDOrName)
+	if err != nil {
+		return err
+	}
+	for _, action := range in.Actions {
+		if _, err := s.findPolicy(action.ID); err != nil {
+			return err
+		}
+		if err := s.attach(action.Action, action.ID, socket.SocketID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------
+// connectors
+// ---------------------------------------------------------------------------------------
+
+func (s *store) findConnector(id string) (*client.Connector, error) {
+	connector, ok := s.connectors[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "connector not found")
+	}
+	return connector, nil
+}
+
+func (s *store) connector(id string) (client.Connector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connector, err := s.findConnector(id)
+	if err != nil {
+		return client.Connector{}, err
+	}
+	return clone(*connector), nil
+}
+
+func (s *store) listConnectors() client.Connectors {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := client.Connectors{List: []client.Connector{}}
+	for _, connector := range sortedValues(s.connectors, func(c *client.Connector) string { return c.Name }) {
+		out.List = append(out.List, clone(*connector))
+	}
+	return out
+}
+
+// validateConnector validates a connector to create or update. It must be called with the lock held.
+func (s *store) validateConnector(in *client.Connector, connectorID string) error {
+	if in.Name == "" {
+		return invalid("invalid connector", client.FieldError{Field: "name", Message: "is required"})
+	}
+	for _, connector := range s.connectors {
+		if connector.Name == in.Name && connector.ConnectorID != connectorID {
+			return errorf(http.StatusConflict, "connector with name %s already exists", in.Name)
+		}
+	}
+	return nil
+}
+
+func (s *store) createConnector(in client.Connector) (client.Connector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateConnector(&in, ""); err != nil {
+		return client.Connector{}, err
+	}
+	connector := clone(in)
+	connector.ConnectorID = uuid.NewString()
+	s.connectors[connector.ConnectorID] = &connector
+	return clone(connector), nil
+}
+
+func (s *store) updateConnector(in client.Connector) (client.Connector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findConnector(in.ConnectorID); err != nil {
+		return client.Connector{}, err
+	}
+	if err := s.validateConnector(&in, in.ConnectorID); err != nil {
+		return client.Connector{}, err
+	}
+	connector := clone(in)
+	s.connectors[connector.ConnectorID] = &connector
+	return clone(connector), nil
+}
+
+// deleteConnector deletes the connector with the given id and its tokens, and returns the
+// values of the deleted tokens.
+func (s *store) deleteConnector(id string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findConnector(id); err != nil {
+		return nil, err
+	}
+	delete(s.connectors, id)
+	var deleted []string
+	for tokenID, token := range s.connectorTokens {
+		if token.ConnectorID == id {
+			deleted = append(deleted, token.Token)
+			delete(s.connectorTokens, tokenID)
+		}
+	}
+	for _, socket := range s.sockets {
+		socket.ConnectorIDs = slices.DeleteFunc(socket.ConnectorIDs, func(connectorID string) bool { return connectorID == id })
+	}
+	return deleted, nil
+}
+
+func (s *store) connectorTokensOf(connectorID string) (client.ConnectorTokens, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connector, err := s.findConnector(connectorID)
+	if err != nil {
+		return client.ConnectorTokens{}, err
+	}
+	out := client.ConnectorTokens{List: []client.ConnectorToken{}, Connector: clone(*connector)}
+	for _, token := range sortedValues(s.connectorTokens, func(t *client.ConnectorToken) string { return t.Name }) {
+		if token.ConnectorID == connectorID {
+			listed := clone(*token)
+			listed.Token = "" // tokens are only returned when created
+			out.List = append(out.List, listed)
+		}
+	}
+	return out, nil
+}
+
+func (s *store) connectorToken(connectorID, tokenID string) (client.ConnectorToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.connectorTokens[tokenID]
+	if !ok || token.ConnectorID != connectorID {
+		return client.ConnectorToken{}, errorf(http.StatusNotFound, "connector token not found")
+	}
+	out := clone(*token)
+	out.Token = "" // tokens are only returned when created
+	return out, nil
+}
+
+func (s *store) createConnectorToken(in client.ConnectorToken, token string) (client.ConnectorToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findConnector(in.ConnectorID); err != nil {
+		return client.ConnectorToken{}, err
+	}
+	if in.Name == "" {
+		return client.ConnectorToken{}, invalid("invalid connector token", client.FieldError{Field: "name", Message: "is required"})
+	}
+	created := clone(in)
+	created.ID = uuid.NewString()
+	created.Token = token
+	created.CreatedBy = "border0test"
+	created.CreatedAt = client.FlexibleTime{Time: time.Now().Truncate(time.Second)}
+	s.connectorTokens[created.ID] = &created
+	return clone(created), nil
+}
+
+// deleteConnectorToken deletes the given connector token, and returns its value.
+func (s *store) deleteConnectorToken(connectorID, tokenID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.connectorTokens[tokenID]
+	if !ok || token.ConnectorID != connectorID {
+		return "", errorf(http.StatusNotFound, "connector token not found")
+	}
+	delete(s.connectorTokens, tokenID)
+	return token.Token, nil
+}
+
+// ---------------------------------------------------------------------------------------
+// users
+// ---------------------------------------------------------------------------------------
+
+func (s *store) findUser(id string) (*client.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "user not found")
+	}
+	return user, nil
+}
+
+func (s *store) user(id string) (client.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.findUser(id)
+	if err != nil {
+		return client.User{}, err
+	}
+	return clone(*user), nil
+}
+
+func (s *store) listUsers() []client.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []client.User{}
+	for _, user := range sortedValues(s.users, func(u *client.User) string { return u.Email }) {
+		out = append(out, clone(*user))
+	}
+	return out
+}
+
+// validateUser validates a user to create or update. It must be called with the lock held.
+func (s *store) validateUser(in *client.User, userID string) error {
+	if in.Email == "" {
+		return invalid("invalid user", client.FieldError{Field: "email", Message: "is required"})
+	}
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, in.Email) && user.ID != userID {
+			return errorf(http.StatusConflict, "user with email %s already exists", in.Email)
+		}
+	}
+	return nil
+}
+
+func (s *store) createUser(in client.User) (client.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateUser(&in, ""); err != nil {
+		return client.User{}, err
+	}
+	user := clone(in)
+	user.ID = uuid.NewString()
+	if user.UserType == "" {
+		user.UserType = "client"
+	}
+	s.users[user.ID] = &user
+	return clone(user), nil
+}
+
+func (s *store) updateUser(in client.User) (client.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findUser(in.ID)
+	if err != nil {
+		return client.User{}, err
+	}
+	if err := s.validateUser(&in, in.ID); err != nil {
+		return client.User{}, err
+	}
+	user := clone(in)
+	user.UserType = existing.UserType
+	user.DirectoryService = existing.DirectoryService
+	s.users[user.ID] = &user
+	return clone(user), nil
+}
+
+func (s *store) deleteUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findUser(id); err != nil {
+		return err
+	}
+	delete(s.users, id)
+	for groupID, userIDs := range s.groupMembers {
+		s.groupMembers[groupID] = slices.DeleteFunc(userIDs, func(userID string) bool { return userID == id })
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------
+// groups
+// ---------------------------------------------------------------------------------------
+
+func (s *store) findGroup(id string) (*client.Group, error) {
+	group, ok := s.groups[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "group not found")
+	}
+	return group, nil
+}
+
+// renderGroup returns a copy of the given group, with its members. It must be called
+// with the lock held.
+func (s *store) renderGroup(group *client.Group) client.Group {
+	out := clone(*group)
+	for _, userID := range s.groupMembers[group.ID] {
+		if user, ok := s.users[userID]; ok {
+			out.Members = append(out.Members, clone(*user))
+		}
+	}
+	return out
+}
+
+func (s *store) group(id string) (client.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, err := s.findGroup(id)
+	if err != nil {
+		return client.Group{}, err
+	}
+	return s.renderGroup(group), nil
+}
+
+func (s *store) listGroups() []client.Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []client.Group{}
+	for _, group := range sortedValues(s.groups, func(g *client.Group) string { return g.DisplayName }) {
+		out = append(out, s.renderGroup(group))
+	}
+	return out
+}
+
+// validateGroup validates a group to create or update. It must be called with the lock held.
+func (s *store) validateGroup(in *client.Group, groupID string) error {
+	if in.DisplayName == "" {
+		return invalid("invalid group", client.FieldError{Field: "display_name", Message: "is required"})
+	}
+	for _, group := range s.groups {
+		if group.DisplayName == in.DisplayName && group.ID != groupID {
+			return errorf(http.StatusConflict, "group with name %s already exists", in.DisplayName)
+		}
+	}
+	return nil
+}
+
+func (s *store) createGroup(in client.Group) (client.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.validateGroup(&in, ""); err != nil {
+		return client.Group{}, err
+	}
+	group := clone(in)
+	group.ID = uuid.NewString()
+	group.Members = nil
+	if group.GroupType == "" {
+		group.GroupType = "manual"
+	}
+	s.groups[group.ID] = &group
+	return s.renderGroup(&group), nil
+}
+
+func (s *store) updateGroup(in client.Group) (client.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findGroup(in.ID)
+	if err != nil {
+		return client.Group{}, err
+	}
+	if err := s.validateGroup(&in, in.ID); err != nil {
+		return client.Group{}, err
+	}
+	group := clone(in)
+	group.Members = nil
+	group.GroupType = existing.GroupType
+	group.DirectoryService = existing.DirectoryService
+	s.groups[group.ID] = &group
+	return s.renderGroup(&group), nil
+}
+
+func (s *store) updateGroupMemberships(groupID string, userIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findGroup(groupID); err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if _, ok := s.users[userID]; !ok {
+			return invalid("invalid group memberships", client.FieldError{Field: "users", Message: fmt.Sprintf("user %s does not exist", userID)})
+		}
+	}
+	s.groupMembers[groupID] = slices.Clone(userIDs)
+	return nil
+}
+
+func (s *store) deleteGroup(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findGroup(id); err != nil {
+		return err
+	}
+	delete(s.groups, id)
+	delete(s.groupMembers, id)
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------
+// service accounts
+// ---------------------------------------------------------------------------------------
+
+func (s *store) findServiceAccount(name string) (*client.ServiceAccount, error) {
+	serviceAccount, ok := s.serviceAccounts[name]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "service account not found")
+	}
+	return serviceAccount, nil
+}
+
+func (s *store) serviceAccount(name string) (client.ServiceAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	serviceAccount, err := s.findServiceAccount(name)
+	if err != nil {
+		return client.ServiceAccount{}, err
+	}
+	return clone(*serviceAccount), nil
+}
+
+func (s *store) createServiceAccount(in client.ServiceAccount) (client.ServiceAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if in.Name == "" {
+		return client.ServiceAccount{}, invalid("invalid service account", client.FieldError{Field: "name", Message: "is required"})
+	}
+	if _, ok := s.serviceAccounts[in.Name]; ok {
+		return client.ServiceAccount{}, errorf(http.StatusConflict, "service account with name %s already exists", in.Name)
+	}
+	now := client.FlexibleTime{Time: time.Now().Truncate(time.Second)}
+	serviceAccount := clone(in)
+	serviceAccount.ID = uuid.NewString()
+	serviceAccount.CreatedAt = now
+	serviceAccount.UpdatedAt = now
+	s.serviceAccounts[serviceAccount.Name] = &serviceAccount
+	return clone(serviceAccount), nil
+}
+
+func (s *store) updateServiceAccount(name string, in client.ServiceAccount) (client.ServiceAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.findServiceAccount(name)
+	if err != nil {
+		return client.ServiceAccount{}, err
+	}
+	serviceAccount := clone(in)
+	serviceAccount.Name = existing.Name // service accounts can't be renamed
+	serviceAccount.ID = existing.ID
+	serviceAccount.CreatedAt = existing.CreatedAt
+	serviceAccount.UpdatedAt = client.FlexibleTime{Time: time.Now().Truncate(time.Second)}
+	serviceAccount.LastSeenAt = existing.LastSeenAt
+	s.serviceAccounts[name] = &serviceAccount
+	return clone(serviceAccount), nil
+}
+
+// deleteServiceAccount deletes the service account with the given name and its tokens, and
+// returns the values of the deleted tokens.
+func (s *store) deleteServiceAccount(name string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findServiceAccount(name); err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for _, token := range s.serviceAccountTokens[name] {
+		deleted = append(deleted, token.Token)
+	}
+	delete(s.serviceAccounts, name)
+	delete(s.serviceAccountTokens, name)
+	return deleted, nil
+}
+
+func (s *store) serviceAccountTokensOf(name string) (client.ServiceAccountTokens, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findServiceAccount(name); err != nil {
+		return client.ServiceAccountTokens{}, err
+	}
+	out := client.ServiceAccountTokens{List: []client.ServiceAccountToken{}}
+	for _, token := range s.serviceAccountTokens[name] {
+		listed := clone(*token)
+		listed.Token = "" // tokens are only returned when created
+		out.List = append(out.List, listed)
+	}
+	return out, nil
+}
+
+func (s *store) createServiceAccountToken(name string, in client.ServiceAccountToken, token string) (client.ServiceAccountToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findServiceAccount(name); err != nil {
+		return client.ServiceAccountToken{}, err
+	}
+	if in.Name == "" {
+		return client.ServiceAccountToken{}, invalid("invalid service account token", client.FieldError{Field: "name", Message: "is required"})
+	}
+	created := clone(in)
+	created.ID = uuid.NewString()
+	created.Token = token
+	created.CreatedAt = client.FlexibleTime{Time: time.Now().Truncate(time.Second)}
+	s.serviceAccountTokens[name] = append(s.serviceAccountTokens[name], &created)
+	return clone(created), nil
+}
+
+// deleteServiceAccountToken deletes the given service account token, and returns its value.
+func (s *store) deleteServiceAccountToken(name, tokenID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findServiceAccount(name); err != nil {
+		return "", err
+	}
+	tokens := s.serviceAccountTokens[name]
+	i := slices.IndexFunc(tokens, func(t *client.ServiceAccountToken) bool { return t.ID == tokenID })
+	if i < 0 {
+		return "", errorf(http.StatusNotFound, "service account token not found")
+	}
+	value := tokens[i].Token
+	s.serviceAccountTokens[name] = slices.Delete(tokens, i, i+1)
+	return value, nil
+}
+
+// webIdentityServiceAccount returns the service account a web identity token is exchanged
+// for, after validating the exchange.
+func (s *store) webIdentityServiceAccount(in client.WebIdentityTokenExchangeInput) (client.ServiceAccount, error) {
+	var details []client.FieldError
+	if in.OrganizationSubdomain == "" {
+		details = append(details, client.FieldError{Field: "organization_subdomain", Message: "is required"})
+	}
+	if in.ServiceAccountName == "" {
+		details = append(details, client.FieldError{Field: "service_account_name", Message: "is required"})
+	}
+	if in.WebIdentityToken == "" {
+		details = append(details, client.FieldError{Field: "web_identity_token", Message: "is required"})
+	}
+	if len(details) > 0 {
+		return client.ServiceAccount{}, invalid("invalid web identity token exchange", details...)
+	}
+	if in.OrganizationSubdomain != s.orgSubdomain {
+		return client.ServiceAccount{}, invalid("organization not found")
+	}
+	serviceAccount, err := s.serviceAccount(in.ServiceAccountName)
+	if err != nil {
+		return client.ServiceAccount{}, invalid("service account not found")
+	}
+	return serviceAccount, nil
+}
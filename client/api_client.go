@@ -3,30 +3,58 @@ package client
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/lib/redact"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // APIClient is the client for the Border0 API.
 type APIClient struct {
 	http          HTTPRequester
+	httpClient    *http.Client      // underlying http client, shared across token swaps
+	ownsClient    bool              // whether httpClient was built by New, rather than given
+	transport     http.RoundTripper // optional, used when httpClient is not provided
+	middlewares   []Middleware      // wrap every request sent by the http requester
 	timeout       time.Duration
-	authToken     string
+	authToken     string                // static token, used when no token source is provided
+	tokenSource   TokenSource           // optional, takes precedence over the static token
+	profileTokens TokenSource           // token source of the profile, used when no token or token source is set
+	legacyToken   bool                  // whether the profile's token source is its plaintext token, which the stored token includes
+	tokens        *swappableTokenSource // token source used by the http requester
 	baseURL       string
 	portalBaseURL string
 	retryWaitMin  time.Duration // minimum time to wait
 	retryWaitMax  time.Duration // maximum time to wait
 	retryMax      int           // maximum number of retries
 	backoff       Backoff
+	retryPolicy   RetryPolicy    // defaults to a DefaultRetryPolicy with the settings above
+	observers     []CallObserver // notified about every api call and its attempts
+	logger        *slog.Logger   // always wrapped with a redacting handler
+	limiter       *rateLimiter   // optional, shared by all goroutines using the client
+	consistent    bool           // whether read-after-write consistency is enabled
+	consistency   *consistencyTracker
+	cache         *readCache                        // optional, caches reads of resources
+	wrapHTTP      func(HTTPRequester) HTTPRequester // optional, wraps the http requester
+	profile       string                            // optional, profile of the shared config file
+	configFile    string                            // path of the shared config file, set along with the profile
+	profileErr    error                             // set when the profile can't be loaded, fails all api calls
+	envProfile    bool                              // whether the profile is the one of the BORDER0_PROFILE env var
+	explicitToken bool                              // whether a token or token source was set with an option
+	explicitURL   bool                              // whether the base url was set with an option
+	storedToken   bool                              // whether to use the token stored by Authenticate when no token is set
+	reauth        bool                              // whether to re-authenticate when the stored token has expired
+	reauthOpts    []auth.Option                     // options of re-authentication, and of the token storage
 }
 
 // Requester is the interface for the Border0 API client.
 type Requester interface {
 	TokenClaims() (jwt.MapClaims, error)
+	Claims(ctx context.Context) (*Claims, error)
 	AuthenticationService
 	SocketService
 	ConnectorService
@@ -35,6 +63,7 @@ type Requester interface {
 	GroupService
 	ServiceAccountService
 	WebIdentityService
+	ServerInfoService
 }
 
 const (
@@ -68,6 +97,8 @@ func New(options ...Option) *APIClient {
 		retryWaitMax:  defaultRetryWaitMax,
 		retryMax:      defaultRetryMax,
 		backoff:       ExponentialBackoff,
+		logger:        slog.Default(),
+		storedToken:   true,
 	}
 	if api.baseURL == "" {
 		api.baseURL = defaultBaseURL
@@ -75,96 +106,169 @@ func New(options ...Option) *APIClient {
 	if api.portalBaseURL == "" {
 		api.portalBaseURL = defaultPortalBaseURL
 	}
+	if profile := os.Getenv("BORDER0_PROFILE"); profile != "" {
+		WithProfile(profile)(api)
+		api.envProfile = true
+	}
 	for _, option := range options {
 		option(api)
 	}
+	if api.profileErr != nil && api.envProfile && api.explicitToken && api.explicitURL {
+		// none of the settings of the profile of the env var would be used anyway
+		api.profileErr = nil
+	}
+	api.logger = slog.New(redact.NewHandler(api.logger.Handler()))
+	if api.httpClient == nil {
+		api.httpClient = &http.Client{
+			Timeout:   api.timeout,
+			Transport: api.transport,
+		}
+		api.ownsClient = true
+	}
+	if api.retryPolicy == nil {
+		api.retryPolicy = &DefaultRetryPolicy{
+			RetryMax:     api.retryMax,
+			RetryWaitMin: api.retryWaitMin,
+			RetryWaitMax: api.retryWaitMax,
+			Backoff:      api.backoff,
+		}
+	}
+	if api.consistent {
+		api.consistency = newConsistencyTracker(api.ServerInfo, api.logger)
+	}
+	if api.tokenSource == nil && api.authToken == "" && !(api.legacyToken && api.storedToken) {
+		// the plaintext token of the profile is used as is when stored tokens are disabled,
+		// it's read (and re-authenticated when expired) as the stored token otherwise
+		api.tokenSource = api.profileTokens
+	}
+	if api.tokenSource == nil && api.authToken == "" && api.storedToken {
+		api.tokenSource = storedTokenSource(api)
+	}
+	if api.tokenSource == nil {
+		api.tokenSource = StaticTokenSource(api.authToken)
+	}
+	api.tokens = newSwappableTokenSource(api.tokenSource)
 	api.http = &HTTPClient{
-		client: &http.Client{
-			Timeout: api.timeout,
-		},
-		token: api.authToken,
+		client:      api.httpClient,
+		ownsClient:  api.ownsClient,
+		tokens:      api.tokens,
+		middlewares: api.middlewares,
+	}
+	if api.wrapHTTP != nil {
+		api.http = api.wrapHTTP(api.http)
 	}
 	return api
 }
 
-// TokenClaims returns the claims of the JWT token.
+// TokenClaims returns the claims of the JWT token, see Claims for the typed claims. Like Claims,
+// it never authenticates interactively.
 func (api *APIClient) TokenClaims() (jwt.MapClaims, error) {
-	parsedJWT, _ := jwt.Parse(api.authToken, nil)
-	if parsedJWT == nil || parsedJWT.Claims == nil {
-		return nil, fmt.Errorf("failed to parse token")
+	claims, err := api.Claims(nonInteractive(context.Background()))
+	if err != nil {
+		return nil, err
 	}
-
-	return parsedJWT.Claims.(jwt.MapClaims), nil
+	return claims.Raw, nil
 }
 
-func (api *APIClient) request(ctx context.Context, method, path string, input, output any) (code int, err error) {
+func (api *APIClient) request(ctx context.Context, method, path string, input, output any) (int, error) {
+	if api.profileErr != nil {
+		return 0, api.profileErr
+	}
+
+	if api.consistency != nil {
+		if isReadMethod(method) {
+			if err := api.consistency.wait(ctx, path); err != nil {
+				return 0, err
+			}
+		} else {
+			// a failed write may have been applied all the same, so it's recorded regardless
+			defer api.consistency.wrote(path)
+		}
+	}
+
+	if api.cache != nil {
+		if method == http.MethodGet && api.cache.cacheable(ctx, path) {
+			return api.cache.get(ctx, path, output, func(ctx context.Context, output any) (int, error) {
+				return api.call(ctx, method, path, input, output)
+			})
+		}
+		if !isReadMethod(method) {
+			// same as above, a failed write may have been applied all the same
+			defer api.cache.invalidate(path)
+		}
+	}
+
+	return api.call(ctx, method, path, input, output)
+}
 
-	shouldRetry := false
-	retryMax := api.retryMax
-	waitMin := api.retryWaitMin
-	waitMax := api.retryWaitMax
-	retryCount := 0
+// call sends a request to the api server, retrying it as decided by the retry policy.
+func (api *APIClient) call(ctx context.Context, method, path string, input, output any) (code int, err error) {
+	ctx, trackers := api.observeCall(ctx, method, path)
+	defer func() { trackers.CallFinished(ctx, code, err) }()
 
-	for ; ; retryCount++ {
+	var attempts []Attempt
+	for {
 		if err := ctx.Err(); err != nil {
 			if errors.Is(err, context.Canceled) {
 				return 0, err
 			}
 		}
 
-		shouldRetry = false
-		code, err = api.http.Request(ctx, method, api.baseURL+path, input, output)
-		if err != nil {
-			// Retry on 404 to handle cross-region replication latency.
-			if code == http.StatusNotFound {
-				shouldRetry = true
-				retryMax = notFoundRetryMax
-				waitMin = notFoundRetryWaitMin
-				waitMax = notFoundRetryWaitMax
-			}
-			// Retry on 429 to handle rate limits being exceeded temporarily.
-			if code == http.StatusTooManyRequests {
-				shouldRetry = true
-				retryMax = tooManyRequestsRetryMax
-				// Check if error contains Retry-After header
-				var apiErr Error
-				if errors.As(err, &apiErr) && apiErr.RetryAfter != nil {
-					// Use server-specified retry delay, but cap it at our maximum
-					serverWait := *apiErr.RetryAfter
-					if serverWait < tooManyRequestsRetryWaitMin {
-						// Use our minimum if server suggests a shorter wait, which could be zero
-						waitMin = tooManyRequestsRetryWaitMin
-						waitMax = tooManyRequestsRetryWaitMin
-					} else {
-						// Respect server's Retry-After value, even if it's longer than our maximum
-						waitMin = serverWait
-						waitMax = serverWait
-					}
-				} else {
-					// Fallback to default 429 retry settings if no Retry-After header
-					waitMin = tooManyRequestsRetryWaitMin
-					waitMax = tooManyRequestsRetryWaitMax
-				}
-			}
-			// Retry on 5xx to handle temporary api issues.
-			if code >= http.StatusInternalServerError {
-				shouldRetry = true
-				retryMax = api.retryMax
-				waitMin = api.retryWaitMin
-				waitMax = api.retryWaitMax
+		if api.limiter != nil {
+			if err := api.limiter.wait(ctx, method); err != nil {
+				return 0, err
 			}
 		}
+		start := time.Now()
+		code, err = api.http.Request(ctx, method, api.baseURL+path, input, output)
+		attempt := Attempt{
+			Number:     len(attempts) + 1,
+			StatusCode: code,
+			Err:        err,
+			Latency:    time.Since(start),
+			RetryAfter: retryAfterFrom(err),
+		}
 
-		if !shouldRetry {
-			break
+		// request was successful, return the result.
+		if err == nil {
+			trackers.AttemptFinished(ctx, attempt)
+			return code, nil
 		}
 
-		remain := retryMax - retryCount
-		if remain <= 0 {
-			break
+		wait, retry := api.retryPolicy.Retry(ctx, RetryRequest{
+			Method:     method,
+			Path:       path,
+			StatusCode: code,
+			Err:        err,
+			Attempt:    attempt.Number,
+		})
+		// never sleep past the deadline, the call would fail anyway
+		if retry && exceedsDeadline(ctx, wait) {
+			retry = false
+		}
+		if !retry {
+			trackers.AttemptFinished(ctx, attempt)
+			attempts = append(attempts, attempt)
+			return code, &RequestError{Method: method, Path: path, Attempts: attempts, Err: err}
 		}
 
-		wait := api.backoff(waitMin, waitMax, retryCount)
+		if code == http.StatusTooManyRequests && api.limiter != nil {
+			// the rate limit applies to everyone using the client, so pause everyone
+			api.limiter.pause(wait)
+		}
+		attempt.Wait = wait
+		trackers.AttemptFinished(ctx, attempt)
+		attempts = append(attempts, attempt)
+		api.logger.DebugContext(
+			ctx,
+			"retrying Border0 API request",
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Int("attempt", attempt.Number),
+			slog.Int("status_code", code),
+			slog.Duration("wait", wait),
+			slog.Any("error", err),
+		)
 		timer := time.NewTimer(wait)
 		select {
 		case <-ctx.Done():
@@ -174,18 +278,4 @@ func (api *APIClient) request(ctx context.Context, method, path string, input, o
 		case <-timer.C:
 		}
 	}
-
-	// request was successful or we should not retry, return the result.
-	if err == nil && !shouldRetry {
-		return code, nil
-	}
-
-	return code, fmt.Errorf("failed after %d %s: %w", retryCount+1, attemptOrAttempts(retryCount+1), err)
-}
-
-func attemptOrAttempts(attempt int) string {
-	if attempt == 1 {
-		return "attempt"
-	}
-	return "attempts"
 }
@@ -2,15 +2,22 @@ package auth
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/borderzero/border0-go/lib/osutil"
 )
 
 // Config represents authentication configuration.
 type Config struct {
-	tokenStorageFilePath string // file to write token to
-	tokenWritingEnabled  bool   // whether the border0 client should attempt to write tokens to the token storage file path
-	browserEnabled       bool   // whether the border0 client should attempt opening the default browser for completing device authorization flow.
+	tokenStorageFilePath string     // file to write token to
+	tokenStore           TokenStore // where to write tokens to, instead of the token storage file path
+	tokenWritingEnabled  bool       // whether the border0 client should attempt to write tokens to the token storage file path
+	browserEnabled       bool       // whether the border0 client should attempt opening the default browser for completing device authorization flow.
+	prompt               PromptFunc // optional, shows users how to complete the device authorization flow
+	pollPolicy           PollPolicy // how to poll for the outcome of the device authorization flow
+
+	profileConfigFilePath string // shared config file with the profile to write tokens to
+	profileName           string // profile to write tokens to, instead of the token storage file path
 
 	legacyAuth bool   // whether to use programmatic authentication
 	email      string // DEPRECATED: programmatic authentication email
@@ -20,6 +27,25 @@ type Config struct {
 // GetTokenStorageFilePath is the getter for the token storage file path.
 func (c *Config) GetTokenStorageFilePath() string { return c.tokenStorageFilePath }
 
+// GetTokenStore returns the token store to write tokens to: the one set with WithTokenStore,
+// or a FileTokenStore for the token storage file path. It's nil when tokens are written to
+// a profile instead.
+func (c *Config) GetTokenStore() TokenStore {
+	if c.tokenStore != nil {
+		return c.tokenStore
+	}
+	if c.tokenStorageFilePath == "" {
+		return nil
+	}
+	return FileTokenStore(c.tokenStorageFilePath)
+}
+
+// GetProfile is the getter for the shared config file path and the name of the profile
+// to write tokens to. The name is empty when tokens are not written to a profile.
+func (c *Config) GetProfile() (configFilePath, name string) {
+	return c.profileConfigFilePath, c.profileName
+}
+
 // ShouldWriteTokensToDisk is the getter for the token writing disabled boolean.
 func (c *Config) ShouldWriteTokensToDisk() bool { return c.tokenWritingEnabled }
 
@@ -29,6 +55,19 @@ func (c *Config) ShouldTryOpeningBrowser() bool { return c.browserEnabled }
 // ShouldUseLegacyAuthentication is the getter for the legacy (programmatic) authentication boolean.
 func (c *Config) ShouldUseLegacyAuthentication() bool { return c.legacyAuth }
 
+// GetPrompt is the getter for the prompt of the device authorization flow, set with WithPrompt.
+// It defaults to TerminalPrompt(os.Stderr), so that users see the login url whatever the logger
+// of the client.
+func (c *Config) GetPrompt() PromptFunc {
+	if c.prompt == nil {
+		return TerminalPrompt(os.Stderr)
+	}
+	return c.prompt
+}
+
+// GetPollPolicy is the getter for the poll policy of the device authorization flow.
+func (c *Config) GetPollPolicy() PollPolicy { return c.pollPolicy }
+
 // GetEmail is the getter for the email.
 func (c *Config) GetEmail() string { return c.email }
 
@@ -44,13 +83,15 @@ func GetConfig(opts ...Option) (*Config, error) {
 		tokenStorageFilePath: "", // will only try populating if not set after applying opts
 		tokenWritingEnabled:  true,
 		browserEnabled:       true,
+		pollPolicy:           DefaultPollPolicy(),
 		legacyAuth:           false,
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	if c.tokenStorageFilePath == "" {
+	// tokens are written to the profile instead, unless a token storage file path or a token store is set
+	if c.tokenStorageFilePath == "" && c.tokenStore == nil && c.profileName == "" {
 		hd, err := osutil.GetUserHomeDir()
 		if err != nil {
 			return nil, fmt.Errorf("no token storage filepath provided and failed to get user home directory: %v", err)
@@ -66,6 +107,22 @@ func WithTokenStorageFilePath(filePath string) Option {
 	return func(c *Config) { c.tokenStorageFilePath = filePath }
 }
 
+// WithTokenStore is the authentication option to write tokens to the given token store (e.g.
+// an EncryptedFileTokenStore) instead of the token storage file path. It takes precedence
+// over WithTokenStorageFilePath and WithProfile.
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Config) { c.tokenStore = store }
+}
+
+// WithProfile is the authentication option to store tokens for the profile with the given
+// name of the shared config file at the given path, instead of in the token storage file. The
+// tokens are kept out of the config file, in a file next to it which the profile refers to,
+// encrypted when the DefaultTokenKeyEnvVar env var holds a key. Tokens are written to the token
+// storage file path (or token store) all the same when it's set explicitly.
+func WithProfile(configFilePath, name string) Option {
+	return func(c *Config) { c.profileConfigFilePath, c.profileName = configFilePath, name }
+}
+
 // WithTokenWritingDisabled is the authentication option to toggle whether tokens
 // acquired through the device authorization flow should be written to disk.
 func WithTokenWriting(enabled bool) Option {
@@ -78,6 +135,32 @@ func WithOpenBrowser(enabled bool) Option {
 	return func(c *Config) { c.browserEnabled = enabled }
 }
 
+// WithPrompt is the authentication option to show users how to complete the device
+// authorization flow with the given function (e.g. QRCodePrompt, or a function sending the
+// login url in a chat message), instead of writing the login url to stderr.
+func WithPrompt(prompt PromptFunc) Option {
+	return func(c *Config) { c.prompt = prompt }
+}
+
+// WithPollPolicy is the authentication option to set how the client polls for the outcome of
+// the device authorization flow. Unset fields of the policy keep their default values.
+func WithPollPolicy(policy PollPolicy) Option {
+	return func(c *Config) {
+		if policy.InitialInterval > 0 {
+			c.pollPolicy.InitialInterval = policy.InitialInterval
+		}
+		if policy.MaxInterval > 0 {
+			c.pollPolicy.MaxInterval = policy.MaxInterval
+		}
+		if policy.Multiplier > 0 {
+			c.pollPolicy.Multiplier = policy.Multiplier
+		}
+		if policy.Timeout != 0 {
+			c.pollPolicy.Timeout = policy.Timeout
+		}
+	}
+}
+
 // WithLegacyCredentials is the authentication option to set legacy credentials.
 func WithLegacyCredentials(email, password string) Option {
 	return func(c *Config) { c.legacyAuth, c.email, c.password = true, email, password }
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/borderzero/border0-go/lib/qrcode"
+)
+
+// DeviceAuthorization is what users need to complete the device authorization flow.
+type DeviceAuthorization struct {
+	URL  string // url of the login page to navigate to
+	Code string // identifier of the device, shown on the login page for users to check it
+}
+
+// PromptFunc shows users how to complete the device authorization flow, e.g. by printing the
+// login url, or by sending it in a chat message. Returning an error aborts the flow.
+type PromptFunc func(ctx context.Context, authorization DeviceAuthorization) error
+
+// PollPolicy is how the client polls for the outcome of the device authorization flow, while
+// users complete it. Polling stops when the context of the flow is done, or after Timeout.
+type PollPolicy struct {
+	InitialInterval time.Duration // time to wait before polling again the first time
+	MaxInterval     time.Duration // maximum time to wait between polls
+	Multiplier      float64       // factor the time to wait grows by after each poll
+	Timeout         time.Duration // time users have to complete the flow, negative for no limit
+}
+
+// DefaultPollPolicy returns the poll policy of the device authorization flow by default.
+func DefaultPollPolicy() PollPolicy {
+	return PollPolicy{
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      1.3,
+		Timeout:         3 * time.Minute,
+	}
+}
+
+// TerminalPrompt returns a PromptFunc which writes the login url and the device code to the
+// given writer, e.g. os.Stderr.
+func TerminalPrompt(w io.Writer) PromptFunc {
+	return func(_ context.Context, authorization DeviceAuthorization) error {
+		if _, err := fmt.Fprintf(w, "Please navigate to the URL below in order to complete the login process:\n\n  %s\n", authorization.URL); err != nil {
+			return err
+		}
+		if authorization.Code != "" {
+			if _, err := fmt.Fprintf(w, "\nand check that the device code shown is %s\n", authorization.Code); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// QRCodePrompt returns a PromptFunc which writes the login url as a QR code, along with the
+// login url and the device code, to the given writer. It's meant for headless machines, the
+// login page can then be opened on a phone.
+func QRCodePrompt(w io.Writer) PromptFunc {
+	return func(ctx context.Context, authorization DeviceAuthorization) error {
+		code, err := qrcode.Encode(authorization.URL, qrcode.LevelM)
+		if err != nil {
+			return fmt.Errorf("failed to encode login url into a QR code: %v", err)
+		}
+		if _, err = fmt.Fprintf(w, "Scan the QR code below to complete the login process:\n\n%s\n", code.Terminal()); err != nil {
+			return err
+		}
+		return TerminalPrompt(w)(ctx, authorization)
+	}
+}
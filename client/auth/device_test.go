@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Prompts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	authorization := DeviceAuthorization{URL: "https://portal.border0.com/login?device_identifier=device-123", Code: "device-123"}
+
+	var terminal bytes.Buffer
+	require.NoError(t, TerminalPrompt(&terminal)(ctx, authorization))
+	assert.Contains(t, terminal.String(), authorization.URL)
+	assert.Contains(t, terminal.String(), "device code shown is device-123")
+
+	var qr bytes.Buffer
+	require.NoError(t, QRCodePrompt(&qr)(ctx, authorization))
+	assert.Contains(t, qr.String(), "█▀")
+	assert.Contains(t, qr.String(), terminal.String(), "the url is written along with the QR code")
+}
+
+func Test_WithPrompt(t *testing.T) {
+	t.Parallel()
+
+	config, err := GetConfig(WithTokenStorageFilePath("/tmp/token"))
+	require.NoError(t, err)
+	assert.NotNil(t, config.GetPrompt(), "the login url is written to stderr by default")
+
+	var prompted bool
+	config, err = GetConfig(WithTokenStorageFilePath("/tmp/token"), WithPrompt(func(context.Context, DeviceAuthorization) error {
+		prompted = true
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NoError(t, config.GetPrompt()(context.Background(), DeviceAuthorization{}))
+	assert.True(t, prompted)
+}
+
+func Test_WithPollPolicy(t *testing.T) {
+	t.Parallel()
+
+	config, err := GetConfig(WithTokenStorageFilePath("/tmp/token"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPollPolicy(), config.GetPollPolicy())
+
+	config, err = GetConfig(WithTokenStorageFilePath("/tmp/token"), WithPollPolicy(PollPolicy{MaxInterval: 10 * time.Second, Timeout: -1}))
+	require.NoError(t, err)
+	assert.Equal(t, PollPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      1.3,
+		Timeout:         -1,
+	}, config.GetPollPolicy(), "unset fields keep their default values")
+}
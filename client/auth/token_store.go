@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/borderzero/border0-go/lib/nacl"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// DefaultTokenKeyEnvVar is the env var holding the base64-encoded key of encrypted token
+// stores, see KeyFromEnv.
+const DefaultTokenKeyEnvVar = "BORDER0_TOKEN_KEY"
+
+// TokenStore is where tokens acquired by authenticating are written to, and where the client
+// reads its stored token from. See WithTokenStore.
+type TokenStore interface {
+	// Load returns the stored token, empty if no token is stored.
+	Load() (string, error)
+	// Save stores the given token, replacing the stored token if any.
+	Save(token string) error
+}
+
+// ensure the token stores implement TokenStore at compile-time.
+var (
+	_ TokenStore = (*fileTokenStore)(nil)
+	_ TokenStore = (*encryptedFileTokenStore)(nil)
+	_ TokenStore = (*MemoryTokenStore)(nil)
+)
+
+// fileTokenStore stores tokens in plaintext in a file.
+type fileTokenStore struct {
+	path string
+}
+
+// FileTokenStore returns a TokenStore which stores tokens in plaintext in the file at the
+// given path, only readable and writable by the current user. It's the default token store.
+func FileTokenStore(path string) TokenStore { return &fileTokenStore{path: path} }
+
+// Load returns the token in the file, empty if the file does not exist.
+func (s *fileTokenStore) Load() (string, error) {
+	b, err := readTokenFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Save writes the token to the file.
+func (s *fileTokenStore) Save(token string) error {
+	return writeTokenFile(s.path, []byte(token))
+}
+
+// KeySource supplies the key of encrypted token stores.
+type KeySource func() (*nacl.PrivateKey, error)
+
+// KeyFromEnv returns a KeySource which reads the base64-encoded key from the env var with the
+// given name (DefaultTokenKeyEnvVar if empty). Keys are generated with nacl.GenerateKey.
+func KeyFromEnv(name string) KeySource {
+	if name == "" {
+		name = DefaultTokenKeyEnvVar
+	}
+	return func() (*nacl.PrivateKey, error) {
+		b64 := os.Getenv(name)
+		if b64 == "" {
+			return nil, fmt.Errorf("no token encryption key in env var %s", name)
+		}
+		key, err := nacl.ParsePrivateKeyB64(b64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token encryption key in env var %s: %v", name, err)
+		}
+		return key, nil
+	}
+}
+
+// KeyFromFile returns a KeySource which reads the base64-encoded key from the file at the
+// given path. The key file should be kept apart from the token file, e.g. on another volume.
+func KeyFromFile(path string) KeySource {
+	return func() (*nacl.PrivateKey, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token encryption key file: %v", err)
+		}
+		key, err := nacl.ParsePrivateKeyB64(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token encryption key in file %s: %v", path, err)
+		}
+		return key, nil
+	}
+}
+
+// encryptedFileTokenStore stores tokens encrypted in a file.
+type encryptedFileTokenStore struct {
+	path string
+	key  KeySource
+}
+
+// EncryptedFileTokenStore returns a TokenStore which stores tokens in the file at the given
+// path, encrypted (and authenticated) with the key from the given key source. The key is
+// read every time a token is loaded or saved, so it can be rotated by re-authenticating.
+func EncryptedFileTokenStore(path string, key KeySource) TokenStore {
+	return &encryptedFileTokenStore{path: path, key: key}
+}
+
+// Load decrypts the token in the file, empty if the file does not exist.
+func (s *encryptedFileTokenStore) Load() (string, error) {
+	b, err := readTokenFile(s.path)
+	if err != nil || len(b) == 0 {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(sealed) < nacl.NonceLength {
+		return "", fmt.Errorf("Border0 token file %s is not an encrypted token file", s.path)
+	}
+	key, err := s.key()
+	if err != nil {
+		return "", err
+	}
+	nonce := [nacl.NonceLength]byte(sealed[:nacl.NonceLength])
+	token, ok := box.Open(nil, sealed[nacl.NonceLength:], &nonce, key.Public().Raw(), key.Raw())
+	if !ok {
+		return "", fmt.Errorf("failed to decrypt Border0 token file %s, it was encrypted with another key", s.path)
+	}
+	return string(token), nil
+}
+
+// Save encrypts the token, and writes it to the file.
+func (s *encryptedFileTokenStore) Save(token string) error {
+	key, err := s.key()
+	if err != nil {
+		return err
+	}
+	nonce, err := nacl.GenerateNonce()
+	if err != nil {
+		return err
+	}
+	// the token is sealed for the key's own public key, the nonce is prepended to the ciphertext
+	sealed := box.Seal(nonce[:], []byte(token), nonce, key.Public().Raw(), key.Raw())
+	return writeTokenFile(s.path, []byte(base64.StdEncoding.EncodeToString(sealed)))
+}
+
+// MemoryTokenStore is a TokenStore which keeps the token in memory, e.g. for tests. The zero
+// value is an empty store. It is safe for concurrent use.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+// NewMemoryTokenStore returns a MemoryTokenStore holding the given token.
+func NewMemoryTokenStore(token string) *MemoryTokenStore { return &MemoryTokenStore{token: token} }
+
+// Load returns the token in the store.
+func (s *MemoryTokenStore) Load() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// Save replaces the token in the store.
+func (s *MemoryTokenStore) Save(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+// readTokenFile returns the content of the token file at the given path, empty if it does not exist.
+func readTokenFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Border0 token file: %w", err)
+	}
+	return b, nil
+}
+
+// writeTokenFile writes the token file at the given path, creating its directories if needed.
+func writeTokenFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to ensure directories for Border0 token file: %v", err)
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("failed to write Border0 token: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/borderzero/border0-go/lib/nacl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TokenStores(t *testing.T) {
+	t.Parallel()
+
+	key, err := nacl.GenerateKey()
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(key.String()+"\n"), 0600))
+
+	tests := []struct {
+		name  string
+		store func(path string) TokenStore
+	}{
+		{
+			name:  "file",
+			store: FileTokenStore,
+		},
+		{
+			name:  "encrypted file",
+			store: func(path string) TokenStore { return EncryptedFileTokenStore(path, KeyFromFile(keyFile)) },
+		},
+		{
+			name:  "memory",
+			store: func(string) TokenStore { return &MemoryTokenStore{} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := test.store(filepath.Join(t.TempDir(), ".border0", "token"))
+			token, err := store.Load()
+			require.NoError(t, err)
+			assert.Empty(t, token, "no token is stored yet")
+
+			require.NoError(t, store.Save("first"))
+			require.NoError(t, store.Save("second"))
+			token, err = store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, "second", token)
+		})
+	}
+}
+
+func Test_EncryptedFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	key, err := nacl.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := nacl.GenerateKey()
+	require.NoError(t, err)
+	keyEnvVar := "TEST_BORDER0_TOKEN_KEY_" + filepath.Base(dir)
+	require.NoError(t, os.Setenv(keyEnvVar, key.String()))
+	defer os.Unsetenv(keyEnvVar)
+	otherKeyFile := filepath.Join(dir, "other-key")
+	require.NoError(t, os.WriteFile(otherKeyFile, []byte(otherKey.String()), 0600))
+
+	path := filepath.Join(dir, "token")
+	store := EncryptedFileTokenStore(path, KeyFromEnv(keyEnvVar))
+	require.NoError(t, store.Save("secret-token"))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "secret-token", "the token is not written in plaintext")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = EncryptedFileTokenStore(path, KeyFromFile(otherKeyFile)).Load()
+	assert.EqualError(t, err, "failed to decrypt Border0 token file "+path+", it was encrypted with another key")
+	_, err = EncryptedFileTokenStore(path, KeyFromEnv(keyEnvVar+"_MISSING")).Load()
+	assert.EqualError(t, err, "no token encryption key in env var "+keyEnvVar+"_MISSING")
+	_, err = EncryptedFileTokenStore(path, KeyFromFile(filepath.Join(dir, "missing"))).Load()
+	assert.ErrorContains(t, err, "failed to read token encryption key file")
+
+	plaintext := filepath.Join(dir, "plaintext")
+	require.NoError(t, FileTokenStore(plaintext).Save("secret-token"))
+	_, err = EncryptedFileTokenStore(plaintext, KeyFromEnv(keyEnvVar)).Load()
+	assert.EqualError(t, err, "Border0 token file "+plaintext+" is not an encrypted token file")
+}
+
+func Test_GetConfig_tokenStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryTokenStore("")
+	config, err := GetConfig(WithProfile("", "prod"), WithTokenStore(store))
+	require.NoError(t, err)
+	assert.Same(t, store, config.GetTokenStore())
+	assert.Empty(t, config.GetTokenStorageFilePath())
+
+	config, err = GetConfig(WithTokenStorageFilePath("/tmp/token"))
+	require.NoError(t, err)
+	assert.Equal(t, FileTokenStore("/tmp/token"), config.GetTokenStore())
+
+	config, err = GetConfig(WithProfile("", "prod"))
+	require.NoError(t, err)
+	assert.Nil(t, config.GetTokenStore(), "tokens are written to the profile")
+}
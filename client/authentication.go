@@ -2,16 +2,14 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
-	"time"
 
 	"github.com/borderzero/border0-go/client/auth"
 	"github.com/cenkalti/backoff/v4"
@@ -22,41 +20,116 @@ import (
 
 // AuthenticationService is an interface for API client methods that interact with Border0 API to manage authentication.
 type AuthenticationService interface {
-	// TODO: IsAuthenticated(ctx context.Context) (bool, error)
+	IsAuthenticated(ctx context.Context, opts ...IsAuthenticatedOption) (bool, error)
 	Authenticate(ctx context.Context, opts ...auth.Option) error
 }
 
-// Authenticate authenticates the client.
+type isAuthenticatedConfig struct {
+	validate bool
+}
+
+// IsAuthenticatedOption is an option of IsAuthenticated.
+type IsAuthenticatedOption func(*isAuthenticatedConfig)
+
+// WithAPIValidation is the IsAuthenticatedOption to validate the token against the Border0 API,
+// on top of checking its expiry locally.
+func WithAPIValidation(validate bool) IsAuthenticatedOption {
+	return func(c *isAuthenticatedConfig) { c.validate = validate }
+}
+
+// IsAuthenticated reports whether the client has a token which has not expired. Token sources
+// which authenticate interactively (the device authorization flow, and re-authentication of
+// expired stored tokens) are not run to check it. With WithAPIValidation, the token is also
+// sent to the Border0 API, which must not reject it.
+func (api *APIClient) IsAuthenticated(ctx context.Context, opts ...IsAuthenticatedOption) (bool, error) {
+	config := &isAuthenticatedConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if api.profileErr != nil {
+		return false, api.profileErr
+	}
+
+	ctx = nonInteractive(ctx)
+	token, err := api.tokens.Token(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get Border0 token: %w", err)
+	}
+	if token == "" || tokenExpired(token) {
+		return false, nil
+	}
+	if !config.validate {
+		return true, nil
+	}
+
+	// any authenticated endpoint would do, the token is valid unless the api rejects it with a 401
+	_, err = api.call(ctx, http.MethodGet, "/sockets?page=1&page_size=1", nil, new(paginatedResponse[Socket]))
+	switch {
+	case err == nil, IsForbidden(err):
+		return true, nil
+	case IsUnauthorized(err):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to validate Border0 token: %w", err)
+	}
+}
+
+// Authenticate authenticates the client. The token obtained is used for all subsequent
+// api calls, it is safe to authenticate while the client is in use. Reads cached for the
+// previous token (see WithReadCache) are discarded.
 func (api *APIClient) Authenticate(ctx context.Context, opts ...auth.Option) error {
-	config, err := auth.GetConfig(opts...)
+	config, err := api.authConfig(opts...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize authentication configuration: %v", err)
 	}
 
-	token, err := doAuthFlow(ctx, api, config)
+	token, err := api.authenticate(unauthenticated(ctx), config)
 	if err != nil {
 		return err
 	}
 
-	if config.ShouldWriteTokensToDisk() {
-		tokenStorageFilePath := config.GetTokenStorageFilePath()
-		if err = os.MkdirAll(filepath.Dir(tokenStorageFilePath), 0750); err != nil {
-			return fmt.Errorf("failed to ensure directories for Border0 token file: %v", err)
-		}
-		if err = os.WriteFile(tokenStorageFilePath, []byte(token), 0600); err != nil {
-			return fmt.Errorf("failed to write Border0 token: %v", err)
-		}
+	api.tokens.set(StaticTokenSource(token))
+	if api.cache != nil {
+		// the token may be of another identity or organization
+		api.cache.clear()
 	}
+	return nil
+}
 
-	// set the token in both the api client and the http client
-	api.http.Close()
-	api.authToken, api.http = token, &HTTPClient{
-		client: &http.Client{
-			Timeout: api.timeout,
-		},
-		token: token,
+// authConfig returns the authentication configuration with the given options. When the
+// client was set up with a profile, tokens are written into that profile by default.
+func (api *APIClient) authConfig(opts ...auth.Option) (*auth.Config, error) {
+	if api.profile != "" {
+		opts = append([]auth.Option{auth.WithProfile(api.configFile, api.profile)}, opts...)
 	}
-	return nil
+	return auth.GetConfig(opts...)
+}
+
+// authenticate runs the authentication flow and stores the token obtained in the token
+// store, or the profile, of the given config (unless disabled in the given config).
+func (api *APIClient) authenticate(ctx context.Context, config *auth.Config) (string, error) {
+	token, err := doAuthFlow(ctx, api, config)
+	if err != nil {
+		return "", err
+	}
+
+	if !config.ShouldWriteTokensToDisk() {
+		return token, nil
+	}
+	if store := config.GetTokenStore(); store != nil {
+		if err = store.Save(token); err != nil {
+			return "", err
+		}
+		api.logger.DebugContext(ctx, "stored Border0 token")
+		return token, nil
+	}
+	configFilePath, profile := config.GetProfile()
+	if err = saveProfileToken(configFilePath, profile, token); err != nil {
+		return "", fmt.Errorf("failed to write Border0 token into profile [%s]: %v", profile, err)
+	}
+	api.logger.DebugContext(ctx, "wrote Border0 token into profile", slog.String("profile", profile), slog.String("path", configFilePath))
+
+	return token, nil
 }
 
 type legacyLoginResponse struct {
@@ -116,7 +189,7 @@ func doAuthFlow(ctx context.Context, api *APIClient, config *auth.Config) (strin
 	if err != nil {
 		return "", fmt.Errorf("failed to initiate Border0 device authorization flow: %v", err)
 	}
-	token, err := handleDeviceAuthorization(api, deviceAuthToken, config.ShouldTryOpeningBrowser())
+	token, err := handleDeviceAuthorization(ctx, api, deviceAuthToken, config)
 	if err != nil {
 		return "", fmt.Errorf("failed to authenticate you against Border0: %v", err)
 	}
@@ -141,30 +214,36 @@ func createDeviceAuthorization(ctx context.Context, api *APIClient) (string, err
 	return out.Token, nil
 }
 
-func getDeviceAuthorizationStatus(api *APIClient, deviceAuthToken string) (*deviceAuthorizationStatus, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/device_authorizations", api.baseURL), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create new http request object: %s", err)
+// openBrowser opens the given url in the system's browser, errors are ignored.
+func openBrowser(url string) {
+	// check if we're on darwin (MacOS) and if we're running as sudo, if so, make sure we open the browser as the user
+	// this prevents folks from not having access to credentials , sessions, etc
+	sudoUsername := os.Getenv("SUDO_USER")
+	sudoAttempt := false
+	if runtime.GOOS == "darwin" && sudoUsername != "" {
+		err := exec.Command("sudo", "-u", sudoUsername, "open", url).Run()
+		if err == nil {
+			// If for some reason this failed, we'll try again to standard way
+			sudoAttempt = true
+		}
 	}
-
-	req.Header.Add("x-access-token", deviceAuthToken)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if !sudoAttempt {
+		_ = open.Run(url)
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, errors.New("unauthorized")
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get device_authorization (%d)", resp.StatusCode)
-	}
+// errDeviceAuthorizationTimedOut is the cause of the device authorization flow timing out.
+var errDeviceAuthorizationTimedOut = errors.New("timed out waiting for the device authorization")
 
+// getDeviceAuthorizationStatus polls for the outcome of the device authorization flow. Polls are
+// sent with the http requester directly, through the middleware chain, but without the retry
+// policy (nor consistency waits, caching or rate limiting): failed polls are retried by the poll
+// policy instead, so a single poll doesn't outlast the poll interval.
+func getDeviceAuthorizationStatus(ctx context.Context, api *APIClient, deviceAuthToken string) (*deviceAuthorizationStatus, error) {
 	var status deviceAuthorizationStatus
-	if err = json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		return nil, errors.New("failed to decode device auth response")
+	ctx = withAccessToken(unauthenticated(ctx), deviceAuthToken)
+	if _, err := api.http.Request(ctx, http.MethodGet, api.baseURL+"/device_authorizations", nil, &status); err != nil {
+		return nil, fmt.Errorf("failed to get device authorization: %w", err)
 	}
 
 	if status.Token == "" || status.State == "not_authorized" {
@@ -174,7 +253,7 @@ func getDeviceAuthorizationStatus(api *APIClient, deviceAuthToken string) (*devi
 	return &status, nil
 }
 
-func handleDeviceAuthorization(api *APIClient, deviceAuthToken string, tryOpenBrowser bool) (string, error) {
+func handleDeviceAuthorization(ctx context.Context, api *APIClient, deviceAuthToken string, config *auth.Config) (string, error) {
 	deviceAuthJWT, _ := jwt.Parse(deviceAuthToken, nil)
 	if deviceAuthJWT == nil {
 		return "", fmt.Errorf("failed to decode Border0 device authorization token")
@@ -183,7 +262,7 @@ func handleDeviceAuthorization(api *APIClient, deviceAuthToken string, tryOpenBr
 	deviceIdentifier := fmt.Sprint(claims["identifier"])
 
 	// Try opening the system's browser automatically. The error is ignored because the desired behavior of the
-	// handler is the same regardless of whether opening the browser fails or succeeds -- we still print the URL.
+	// handler is the same regardless of whether opening the browser fails or succeeds -- we still show the URL.
 	// This is desirable because in the event opening the browser succeeds, the customer may still accidentally
 	// close the new tab / browser session, or may want to authenticate in a different browser / session. In the
 	// event that opening the browser fails, the customer may still complete authenticating by navigating to the
@@ -191,26 +270,16 @@ func handleDeviceAuthorization(api *APIClient, deviceAuthToken string, tryOpenBr
 
 	url := fmt.Sprintf("%s/login?device_identifier=%v", api.portalBaseURL, url.QueryEscape(deviceIdentifier))
 
-	fmt.Printf("Please navigate to the URL below in order to complete the login process:\n%s\n", url)
-
-	if tryOpenBrowser {
-		// check if we're on darwin (MacOS) and if we're running as sudo, if so, make sure we open the browser as the user
-		// this prevents folks from not having access to credentials , sessions, etc
-		sudoUsername := os.Getenv("SUDO_USER")
-		sudoAttempt := false
-		if runtime.GOOS == "darwin" && sudoUsername != "" {
-			err := exec.Command("sudo", "-u", sudoUsername, "open", url).Run()
-			if err == nil {
-				// If for some reason this failed, we'll try again to standard way
-				sudoAttempt = true
-			}
-		}
-		if !sudoAttempt {
-			_ = open.Run(url)
-		}
+	prompt := config.GetPrompt()
+	if err := prompt(ctx, auth.DeviceAuthorization{URL: url, Code: deviceIdentifier}); err != nil {
+		return "", fmt.Errorf("failed to prompt for the device authorization: %w", err)
+	}
+
+	if config.ShouldTryOpeningBrowser() {
+		openBrowser(url)
 	}
 
-	receivedToken, err := pollForToken(api, deviceAuthToken)
+	receivedToken, err := pollForToken(ctx, api, deviceAuthToken, config.GetPollPolicy())
 	if err != nil {
 		return "", err
 	}
@@ -218,18 +287,25 @@ func handleDeviceAuthorization(api *APIClient, deviceAuthToken string, tryOpenBr
 	return receivedToken, nil
 }
 
-// pollForToken will poll until the device is authorized.
-func pollForToken(api *APIClient, deviceAuthorizationToken string) (string, error) {
+// pollForToken will poll until the device is authorized, the given context is done,
+// or the poll policy times out.
+func pollForToken(ctx context.Context, api *APIClient, deviceAuthorizationToken string, policy auth.PollPolicy) (string, error) {
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, policy.Timeout, errDeviceAuthorizationTimedOut)
+		defer cancel()
+	}
+
 	exponentialBackoff := backoff.NewExponentialBackOff()
-	exponentialBackoff.InitialInterval = 1 * time.Second
-	exponentialBackoff.MaxInterval = 5 * time.Second
-	exponentialBackoff.Multiplier = 1.3
-	exponentialBackoff.MaxElapsedTime = 3 * time.Minute
+	exponentialBackoff.InitialInterval = policy.InitialInterval
+	exponentialBackoff.MaxInterval = policy.MaxInterval
+	exponentialBackoff.Multiplier = policy.Multiplier
+	exponentialBackoff.MaxElapsedTime = 0 // bounded by the context instead
 
 	var token string
 
 	retryFn := func() error {
-		tk, err := getDeviceAuthorizationStatus(api, deviceAuthorizationToken)
+		tk, err := getDeviceAuthorizationStatus(ctx, api, deviceAuthorizationToken)
 		if err != nil {
 			return err
 		}
@@ -237,16 +313,16 @@ func pollForToken(api *APIClient, deviceAuthorizationToken string) (string, erro
 		return err
 	}
 
-	err := backoff.Retry(retryFn, exponentialBackoff)
+	err := backoff.Retry(retryFn, backoff.WithContext(exponentialBackoff, ctx))
 	if err != nil {
-		if errors.Is(err, errors.New("unauthorized")) {
-			fmt.Printf("We couldn't log you in, your session is expired or you are not authorized to perform this action: %v\n", err)
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
 		}
-		fmt.Printf("We couldn't log you in, make sure that you are properly logged in using the link above: %v\n", err)
+		api.logger.ErrorContext(ctx, "We couldn't log you in, make sure that you are properly logged in using the link above", slog.Any("error", err))
 		return "", err
 	}
 
-	fmt.Println("Login successful")
+	api.logger.InfoContext(ctx, "Login successful")
 
 	return token, nil
 }
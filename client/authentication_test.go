@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/client/mocks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_APIClient_IsAuthenticated(t *testing.T) {
+	t.Parallel()
+
+	valid := testTokenExpiringIn(t, time.Hour)
+	expired := testTokenExpiringIn(t, -time.Hour)
+	validationPath := defaultBaseURL + "/sockets?page=1&page_size=1"
+
+	tests := []struct {
+		name              string
+		token             string
+		opts              []IsAuthenticatedOption
+		mockRequester     func(context.Context, *mocks.ClientHTTPRequester)
+		wantAuthenticated bool
+		wantErr           string
+	}{
+		{
+			name:              "no token",
+			token:             "",
+			wantAuthenticated: false,
+		},
+		{
+			name:              "expired token",
+			token:             expired,
+			opts:              []IsAuthenticatedOption{WithAPIValidation(true)},
+			wantAuthenticated: false,
+		},
+		{
+			name:              "valid token",
+			token:             valid,
+			wantAuthenticated: true,
+		},
+		{
+			name:              "tokens without expiry don't expire",
+			token:             "opaque-token",
+			wantAuthenticated: true,
+		},
+		{
+			name:  "token accepted by the api",
+			token: valid,
+			opts:  []IsAuthenticatedOption{WithAPIValidation(true)},
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(mock.Anything, http.MethodGet, validationPath, nil, new(paginatedResponse[Socket])).
+					Return(http.StatusOK, nil)
+			},
+			wantAuthenticated: true,
+		},
+		{
+			name:  "token rejected by the api",
+			token: valid,
+			opts:  []IsAuthenticatedOption{WithAPIValidation(true)},
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(mock.Anything, http.MethodGet, validationPath, nil, new(paginatedResponse[Socket])).
+					Return(http.StatusUnauthorized, Error{Code: http.StatusUnauthorized, Message: "unauthorized"})
+			},
+			wantAuthenticated: false,
+		},
+		{
+			name:  "token without permission to list sockets",
+			token: valid,
+			opts:  []IsAuthenticatedOption{WithAPIValidation(true)},
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(mock.Anything, http.MethodGet, validationPath, nil, new(paginatedResponse[Socket])).
+					Return(http.StatusForbidden, Error{Code: http.StatusForbidden, Message: "forbidden"})
+			},
+			wantAuthenticated: true,
+		},
+		{
+			name:  "failed to validate token",
+			token: valid,
+			opts:  []IsAuthenticatedOption{WithAPIValidation(true)},
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(mock.Anything, http.MethodGet, validationPath, nil, new(paginatedResponse[Socket])).
+					Return(0, errors.New("connection refused"))
+			},
+			wantAuthenticated: false,
+			wantErr:           "failed to validate Border0 token: failed after 1 attempt: connection refused",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			requester := new(mocks.ClientHTTPRequester)
+			if test.mockRequester != nil {
+				test.mockRequester(ctx, requester)
+			}
+
+			api := New(
+				WithAuthToken(test.token),
+				WithStoredToken(false),
+				WithRetryMax(0),
+			)
+			api.http = requester
+
+			authenticated, err := api.IsAuthenticated(ctx, test.opts...)
+
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+			assert.Equal(t, test.wantAuthenticated, authenticated)
+			requester.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_APIClient_Authenticate_deviceFlow(t *testing.T) {
+	t.Parallel()
+
+	deviceToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"identifier": "device-123"}).SignedString([]byte("test-key"))
+	require.NoError(t, err)
+	fastPolls := auth.PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		pendingPolls int32 // number of polls before the device is authorized, -1 for never
+		pendingCode  int   // status code of the polls before the device is authorized, 200 if 0
+		promptErr    error
+		policy       auth.PollPolicy
+		cancelAfter  time.Duration
+		wantToken    string
+		wantErr      string
+	}{
+		{
+			name:         "authorized after a few polls",
+			pendingPolls: 2,
+			policy:       fastPolls,
+			wantToken:    "user-token",
+		},
+		{
+			name:         "failed polls are not retried by the retry policy",
+			pendingPolls: 2,
+			pendingCode:  http.StatusServiceUnavailable,
+			policy:       fastPolls,
+			wantToken:    "user-token",
+		},
+		{
+			name:         "prompt fails",
+			pendingPolls: 0,
+			promptErr:    errors.New("slack is down"),
+			policy:       fastPolls,
+			wantErr:      "failed to authenticate you against Border0: failed to prompt for the device authorization: slack is down",
+		},
+		{
+			name:         "poll policy times out",
+			pendingPolls: -1,
+			policy:       auth.PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond, Timeout: 50 * time.Millisecond},
+			wantErr:      "failed to authenticate you against Border0: timed out waiting for the device authorization",
+		},
+		{
+			name:         "context canceled",
+			pendingPolls: -1,
+			policy:       auth.PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond, Timeout: -1},
+			cancelAfter:  50 * time.Millisecond,
+			wantErr:      "failed to authenticate you against Border0: context canceled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var polls atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/device_authorizations", r.URL.Path)
+				assert.Equal(t, "injected", r.Header.Get("X-Test-Header"), "the configured transport is used")
+				if r.Method == http.MethodPost {
+					w.Write([]byte(`{"token":"` + deviceToken + `"}`))
+					return
+				}
+				assert.Equal(t, deviceToken, r.Header.Get("x-access-token"))
+				if n := polls.Add(1); test.pendingPolls < 0 || n <= test.pendingPolls {
+					if test.pendingCode != 0 {
+						w.WriteHeader(test.pendingCode)
+					}
+					w.Write([]byte(`{"state":"not_authorized"}`))
+					return
+				}
+				w.Write([]byte(`{"token":"user-token","state":"authorized"}`))
+			}))
+			defer ts.Close()
+
+			ctx := context.Background()
+			if test.cancelAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				defer cancel()
+				time.AfterFunc(test.cancelAfter, cancel)
+			}
+
+			var prompts []auth.DeviceAuthorization
+			prompt := func(_ context.Context, authorization auth.DeviceAuthorization) error {
+				prompts = append(prompts, authorization)
+				return test.promptErr
+			}
+			store := auth.NewMemoryTokenStore("")
+			var requests atomic.Int32
+			api := New(
+				WithBaseURL(ts.URL),
+				WithTransport(&headerInjectingTransport{header: "X-Test-Header", value: "injected"}),
+				WithMiddleware(func(next RequestFunc) RequestFunc {
+					return func(ctx context.Context, method, path string, input, output any) (int, error) {
+						requests.Add(1)
+						return next(ctx, method, path, input, output)
+					}
+				}),
+				WithStoredToken(false),
+			)
+			api.portalBaseURL = "https://portal.example.com"
+
+			err := api.Authenticate(ctx,
+				auth.WithOpenBrowser(false),
+				auth.WithTokenStore(store),
+				auth.WithPrompt(prompt),
+				auth.WithPollPolicy(test.policy),
+			)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []auth.DeviceAuthorization{{URL: "https://portal.example.com/login?device_identifier=device-123", Code: "device-123"}}, prompts)
+			assert.Equal(t, test.pendingPolls+1, polls.Load())
+			assert.Equal(t, polls.Load()+1, requests.Load(), "polls go through the middleware chain")
+			stored, err := store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, test.wantToken, stored)
+			token, err := api.tokens.Token(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantToken, token)
+		})
+	}
+}
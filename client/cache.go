@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// readCache caches the responses of reads of resources (sockets, policies, connectors, etc.)
+// made by an APIClient. Entries expire after the TTL of their type of resource, and when
+// the api server returns an ETag, expired entries are revalidated with a conditional
+// request instead of being fetched again. Identical concurrent reads are coalesced into a
+// single request, and writes through the client invalidate the entries of the resources
+// they write to.
+type readCache struct {
+	ttl  time.Duration            // default TTL
+	ttls map[string]time.Duration // TTLs by type of resource, see resourceKeys
+
+	group singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry // by path
+	generation uint64                 // incremented by every invalidation
+}
+
+type cacheEntry struct {
+	body    json.RawMessage
+	etag    string
+	expires time.Time
+	keys    []string // types of resources the entry refers to
+}
+
+// fetchFunc fetches a resource, decoding the response body into the given output.
+type fetchFunc func(ctx context.Context, output any) (int, error)
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{
+		ttl:     ttl,
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// cacheable reports whether reads of the given path can be served from the cache. Only
+// reads of resources are cached, and never on behalf of unauthenticated requests.
+func (c *readCache) cacheable(ctx context.Context, path string) bool {
+	return !isUnauthenticated(ctx) && len(resourceKeys(path)) > 0
+}
+
+// get decodes the cached response of the given path into the output, fetching it with the
+// given function if it's not cached or the cached response has expired.
+func (c *readCache) get(ctx context.Context, path string, output any, fetch fetchFunc) (int, error) {
+	c.mu.Lock()
+	entry := c.entries[path]
+	generation := c.generation
+	c.mu.Unlock()
+
+	res := fetchResult{code: http.StatusOK}
+	if entry == nil || !time.Now().Before(entry.expires) {
+		// the request is shared by all the callers waiting on it, so it must not be
+		// canceled when the caller who happened to send it goes away. Callers don't
+		// wait on requests sent before an invalidation, whose responses may be stale.
+		key := fmt.Sprintf("%d %s", generation, path)
+		ch := c.group.DoChan(key, func() (any, error) {
+			return c.fetch(context.WithoutCancel(ctx), path, fetch)
+		})
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case shared := <-ch:
+			res = shared.Val.(fetchResult)
+			if shared.Err != nil {
+				return res.code, shared.Err
+			}
+		}
+	} else {
+		res.body = entry.body
+	}
+
+	if len(res.body) > 0 && output != nil {
+		if err := json.Unmarshal(res.body, output); err != nil {
+			return res.code, fmt.Errorf("failed to decode response from JSON: %w", err)
+		}
+	}
+	return res.code, nil
+}
+
+// fetchResult is the result of fetching a resource, shared by all the callers waiting on it.
+type fetchResult struct {
+	code int
+	body json.RawMessage
+}
+
+// fetch fetches the given path, revalidating the cached response if there is one, and
+// caches the result.
+func (c *readCache) fetch(ctx context.Context, path string, fetch fetchFunc) (fetchResult, error) {
+	keys := resourceKeys(path)
+
+	c.mu.Lock()
+	entry := c.entries[path]
+	generation := c.generation
+	c.mu.Unlock()
+
+	cond := new(conditionalRequest)
+	if entry != nil {
+		cond.ifNoneMatch = entry.etag
+	}
+	var body json.RawMessage
+	code, err := fetch(withConditionalRequest(ctx, cond), &body)
+	if err != nil {
+		return fetchResult{code: code}, err
+	}
+	etag := cond.etag
+	if code == http.StatusNotModified && entry != nil {
+		// the cached response is still valid, callers get it as if it was just fetched
+		code, body = http.StatusOK, entry.body
+		if etag == "" {
+			etag = entry.etag
+		}
+	}
+	res := fetchResult{code: code, body: body}
+
+	ttl := c.ttlFor(keys)
+	if ttl <= 0 && etag == "" {
+		return res, nil // nothing worth caching
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// don't cache responses that might predate a write made while they were being fetched
+	if c.generation != generation {
+		return res, nil
+	}
+	c.entries[path] = &cacheEntry{
+		body:    body,
+		etag:    etag,
+		expires: time.Now().Add(ttl),
+		keys:    keys,
+	}
+	return res, nil
+}
+
+// invalidate removes the cached responses of the resources of the given path.
+func (c *readCache) invalidate(path string) {
+	keys := resourceKeys(path)
+	if len(keys) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for p, entry := range c.entries {
+		if slices.ContainsFunc(entry.keys, func(key string) bool { return slices.Contains(keys, key) }) {
+			delete(c.entries, p)
+		}
+	}
+}
+
+// clear removes all the cached responses, e.g. when the client switches to another identity,
+// whose reads must not be served the responses cached for the previous one.
+func (c *readCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+// ttlFor returns the TTL of responses referring to the given types of resources, which is
+// the shortest of their TTLs.
+func (c *readCache) ttlFor(keys []string) time.Duration {
+	ttl, found := time.Duration(0), false
+	for _, key := range keys {
+		keyTTL, ok := c.ttls[key]
+		if !ok {
+			keyTTL = c.ttl
+		}
+		if !found || keyTTL < ttl {
+			ttl, found = keyTTL, true
+		}
+	}
+	if !found {
+		return c.ttl
+	}
+	return ttl
+}
+
+// conditionalRequest carries the ETag of a cached response to the http requester, so that
+// it can send a conditional request, and the ETag of the response back.
+type conditionalRequest struct {
+	ifNoneMatch string // sent in the If-None-Match header, if not empty
+	etag        string // set to the ETag header of the response
+}
+
+type conditionalRequestKey struct{}
+
+func withConditionalRequest(ctx context.Context, cond *conditionalRequest) context.Context {
+	return context.WithValue(ctx, conditionalRequestKey{}, cond)
+}
+
+func conditionalRequestFrom(ctx context.Context) *conditionalRequest {
+	cond, _ := ctx.Value(conditionalRequestKey{}).(*conditionalRequest)
+	return cond
+}
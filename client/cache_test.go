@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheTestServer struct {
+	*httptest.Server
+	gets        atomic.Int32
+	revalidated atomic.Int32
+	release     chan struct{} // if not nil, GETs block until it's closed
+}
+
+func newCacheTestServer(t *testing.T) *cacheTestServer {
+	s := new(cacheTestServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		s.gets.Add(1)
+		if s.release != nil {
+			<-s.release
+		}
+		w.Header().Set(headerETag, `"v1"`)
+		if r.Header.Get(headerIfNoneMatch) == `"v1"` {
+			s.revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"path": r.URL.Path})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func Test_readCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	type response struct {
+		Path string `json:"path"`
+	}
+
+	t.Run("fresh responses are served from the cache", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newCacheTestServer(t)
+		api := New(WithBaseURL(srv.URL), WithAuthToken("token"), WithReadCacheTTL("socket", time.Hour))
+
+		for i := 0; i < 3; i++ {
+			out := new(response)
+			code, err := api.request(ctx, http.MethodGet, "/socket/abc", nil, out)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "/socket/abc", out.Path)
+		}
+		assert.Equal(t, int32(1), srv.gets.Load())
+	})
+
+	t.Run("expired responses are revalidated with their etag", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newCacheTestServer(t)
+		api := New(WithBaseURL(srv.URL), WithAuthToken("token"), WithReadCache(0))
+
+		for i := 0; i < 3; i++ {
+			out := new(response)
+			code, err := api.request(ctx, http.MethodGet, "/policies", nil, out)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "/policies", out.Path)
+		}
+		assert.Equal(t, int32(3), srv.gets.Load())
+		assert.Equal(t, int32(2), srv.revalidated.Load())
+	})
+
+	t.Run("writes invalidate cached responses of the same type of resource", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newCacheTestServer(t)
+		api := New(WithBaseURL(srv.URL), WithAuthToken("token"), WithReadCache(time.Hour))
+
+		_, err := api.request(ctx, http.MethodGet, "/socket/abc", nil, new(response))
+		require.NoError(t, err)
+		_, err = api.request(ctx, http.MethodGet, "/connectors", nil, new(response))
+		require.NoError(t, err)
+
+		_, err = api.request(ctx, http.MethodPut, "/policy/def/socket", map[string]string{}, nil)
+		require.NoError(t, err)
+
+		_, err = api.request(ctx, http.MethodGet, "/socket/abc", nil, new(response))
+		require.NoError(t, err)
+		_, err = api.request(ctx, http.MethodGet, "/connectors", nil, new(response))
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(3), srv.gets.Load())
+	})
+
+	t.Run("identical concurrent reads are coalesced", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newCacheTestServer(t)
+		srv.release = make(chan struct{})
+		api := New(WithBaseURL(srv.URL), WithAuthToken("token"), WithReadCache(0))
+
+		var wg sync.WaitGroup
+		outs := make([]response, 10)
+		for i := range outs {
+			wg.Add(1)
+			go func(out *response) {
+				defer wg.Done()
+				_, err := api.request(ctx, http.MethodGet, "/socket/abc", nil, out)
+				assert.NoError(t, err)
+			}(&outs[i])
+		}
+		assert.Eventually(t, func() bool { return srv.gets.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond) // let the other readers queue up behind the first one
+		close(srv.release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), srv.gets.Load())
+		for _, out := range outs {
+			assert.Equal(t, "/socket/abc", out.Path)
+		}
+	})
+
+	t.Run("reads of non resources are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		srv := newCacheTestServer(t)
+		api := New(WithBaseURL(srv.URL), WithAuthToken("token"), WithReadCache(time.Hour))
+
+		for i := 0; i < 2; i++ {
+			_, err := api.request(ctx, http.MethodGet, "/serverinfo", nil, new(response))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), srv.gets.Load())
+		assert.Zero(t, srv.revalidated.Load())
+	})
+}
+
+func Test_readCache_ttlFor(t *testing.T) {
+	t.Parallel()
+
+	cache := newReadCache(time.Minute)
+	cache.ttls["socket"] = time.Hour
+	cache.ttls["policy"] = time.Second
+
+	assert.Equal(t, time.Hour, cache.ttlFor([]string{"socket"}))
+	assert.Equal(t, time.Second, cache.ttlFor([]string{"socket", "policy"}))
+	assert.Equal(t, time.Minute, cache.ttlFor([]string{"connector"}))
+	assert.Equal(t, time.Minute, cache.ttlFor(nil))
+}
+
+func Test_readCache_clearedOnAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	deviceToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"identifier": "device-123"}).SignedString([]byte("test-key"))
+	require.NoError(t, err)
+
+	var gets atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/device_authorizations" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"token":"` + deviceToken + `"}`))
+		case r.URL.Path == "/device_authorizations":
+			_, _ = w.Write([]byte(`{"token":"other-org-token","state":"authorized"}`))
+		default:
+			gets.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")})
+		}
+	}))
+	defer ts.Close()
+
+	api := New(WithBaseURL(ts.URL), WithAuthToken("org-token"), WithReadCache(time.Hour))
+	type response struct {
+		Token string `json:"token"`
+	}
+
+	out := new(response)
+	_, err = api.request(ctx, http.MethodGet, "/socket/abc", nil, out)
+	require.NoError(t, err)
+	assert.Equal(t, "org-token", out.Token)
+
+	err = api.Authenticate(ctx,
+		auth.WithOpenBrowser(false),
+		auth.WithTokenStore(auth.NewMemoryTokenStore("")),
+		auth.WithPrompt(func(context.Context, auth.DeviceAuthorization) error { return nil }),
+		auth.WithPollPolicy(auth.PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	out = new(response)
+	_, err = api.request(ctx, http.MethodGet, "/socket/abc", nil, out)
+	require.NoError(t, err)
+	assert.Equal(t, "other-org-token", out.Token, "reads cached for the previous token are not served")
+	assert.Equal(t, int32(2), gets.Load())
+}
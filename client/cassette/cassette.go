@@ -0,0 +1,297 @@
+// Package cassette records interactions with the Border0 API to a file (a cassette), and
+// replays them, so that tests can exercise real API responses deterministically, without
+// network access.
+//
+// Interactions are recorded by wrapping the HTTPRequester of a client.APIClient:
+//
+//	recorder := cassette.NewRecorder("testdata/sockets.json")
+//	api := client.New(client.WithHTTPRequesterWrapper(recorder.Wrap))
+//
+// and replayed the same way, without sending any requests:
+//
+//	replayer, err := cassette.NewReplayer("testdata/sockets.json")
+//	api := client.New(client.WithHTTPRequesterWrapper(replayer.Wrap))
+//
+// Requests are recorded above the HTTP layer, so headers (like the Authorization header
+// with the bearer token) are never recorded. Secrets in request and response bodies, and
+// in error messages, are redacted before they are written to the cassette.
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/lib/redact"
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request sent to the Border0 API, and its outcome.
+type Interaction struct {
+	Method     string          `json:"method"`
+	Path       string          `json:"path"` // path and query of the request url
+	Request    json.RawMessage `json:"request,omitempty"`
+	StatusCode int             `json:"status_code"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      *Error          `json:"error,omitempty"`
+}
+
+// Error is an error returned by a request. Errors returned by the Border0 API have a code,
+// other errors (e.g. network errors) only have a message.
+type Error struct {
+	Code       int                 `json:"code,omitempty"`
+	Message    string              `json:"message"`
+	Fallback   string              `json:"fallback,omitempty"`
+	RetryAfter time.Duration       `json:"retry_after,omitempty"`
+	Method     string              `json:"method,omitempty"`
+	URLPath    string              `json:"url_path,omitempty"`
+	RequestID  string              `json:"request_id,omitempty"`
+	Details    []client.FieldError `json:"details,omitempty"`
+}
+
+// errorFrom returns the recorded form of the given error, with secrets redacted.
+func errorFrom(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var apiErr client.Error
+	if !errors.As(err, &apiErr) {
+		return &Error{Message: redact.String(err.Error())}
+	}
+	out := &Error{
+		Code:      apiErr.Code,
+		Message:   redact.String(apiErr.Message),
+		Fallback:  redact.String(apiErr.Fallback),
+		Method:    apiErr.Method,
+		URLPath:   apiErr.URLPath,
+		RequestID: apiErr.RequestID,
+		Details:   apiErr.Details,
+	}
+	if apiErr.RetryAfter != nil {
+		out.RetryAfter = *apiErr.RetryAfter
+	}
+	return out
+}
+
+// err returns the error the recorded error stands for.
+func (e *Error) err() error {
+	if e == nil {
+		return nil
+	}
+	if e.Code == 0 {
+		return errors.New(e.Message)
+	}
+	apiErr := client.Error{
+		Code:      e.Code,
+		Message:   e.Message,
+		Fallback:  e.Fallback,
+		Method:    e.Method,
+		URLPath:   e.URLPath,
+		RequestID: e.RequestID,
+		Details:   e.Details,
+	}
+	if e.RetryAfter > 0 {
+		retryAfter := e.RetryAfter
+		apiErr.RetryAfter = &retryAfter
+	}
+	return apiErr
+}
+
+// requestPath returns the path and query of the given request url, so that interactions
+// don't depend on the base url of the Border0 API.
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redact.String(rawURL)
+	}
+	return redact.String(u.RequestURI())
+}
+
+// body returns the JSON representation of the given request or response body, with
+// secrets redacted.
+func body(v any) (json.RawMessage, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	b, err := json.Marshal(redact.JSON(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode body into JSON: %w", err)
+	}
+	return b, nil
+}
+
+// Recorder records the interactions of the HTTPRequesters it wraps to a cassette file.
+type Recorder struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a recorder which writes the interactions it records to the cassette
+// file at the given path, overwriting it if it exists.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Wrap returns an HTTPRequester which sends requests with the given one, and records them.
+// It's meant to be used with client.WithHTTPRequesterWrapper.
+func (r *Recorder) Wrap(inner client.HTTPRequester) client.HTTPRequester {
+	return &recording{recorder: r, inner: inner}
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// record adds an interaction to the cassette, and writes the cassette file.
+func (r *Recorder) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0750); err != nil {
+		return fmt.Errorf("failed to ensure directories for cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(b, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+type recording struct {
+	recorder *Recorder
+	inner    client.HTTPRequester
+}
+
+// Request sends the request with the wrapped HTTPRequester, and records it.
+func (r *recording) Request(ctx context.Context, method, path string, input, output any) (int, error) {
+	code, err := r.inner.Request(ctx, method, path, input, output)
+
+	interaction := Interaction{
+		Method:     method,
+		Path:       requestPath(path),
+		StatusCode: code,
+		Error:      errorFrom(err),
+	}
+	var encodeErr error
+	if interaction.Request, encodeErr = body(input); encodeErr != nil {
+		return code, fmt.Errorf("failed to record request: %w", encodeErr)
+	}
+	if err == nil {
+		if interaction.Response, encodeErr = body(output); encodeErr != nil {
+			return code, fmt.Errorf("failed to record response: %w", encodeErr)
+		}
+	}
+	if recordErr := r.recorder.record(interaction); recordErr != nil {
+		return code, recordErr
+	}
+	return code, err
+}
+
+// Close closes the wrapped HTTPRequester.
+func (r *recording) Close() { r.inner.Close() }
+
+// Replayer replays the interactions of a cassette file, without sending any requests.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// NewReplayer returns a replayer of the interactions of the cassette file at the given path.
+func NewReplayer(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(b, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette: %w", err)
+	}
+	return &Replayer{
+		interactions: cassette.Interactions,
+		replayed:     make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// Wrap returns an HTTPRequester which replays the interactions of the cassette, instead of
+// sending requests with the given one. It's meant to be used with client.WithHTTPRequesterWrapper.
+func (r *Replayer) Wrap(client.HTTPRequester) client.HTTPRequester {
+	return r
+}
+
+// Request replays the first interaction not replayed yet with the same method, path and
+// request body. Requests without such interaction fail.
+func (r *Replayer) Request(_ context.Context, method, path string, input, output any) (int, error) {
+	request, err := body(input)
+	if err != nil {
+		return 0, err
+	}
+	path = requestPath(path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || interaction.Method != method || interaction.Path != path || !sameJSON(interaction.Request, request) {
+			continue
+		}
+		r.replayed[i] = true
+		if interaction.Error == nil && output != nil && len(interaction.Response) > 0 {
+			if err := json.Unmarshal(interaction.Response, output); err != nil {
+				return interaction.StatusCode, fmt.Errorf("failed to decode response from JSON: %w", err)
+			}
+		}
+		return interaction.StatusCode, interaction.Error.err()
+	}
+	return 0, fmt.Errorf("cassette has no interaction for request %s %s", method, path)
+}
+
+// Close does nothing, there is nothing to close.
+func (r *Replayer) Close() {}
+
+// Unused returns the interactions of the cassette which were not replayed.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.replayed[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// sameJSON reports whether the given JSON documents are equal, regardless of formatting.
+func sameJSON(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
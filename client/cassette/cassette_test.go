@@ -0,0 +1,173 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/border0test"
+	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/lib/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noBackoff(_, _ time.Duration, _ int) time.Duration { return 0 }
+
+func Test_Cassette(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	// record
+	srv := border0test.NewServer()
+	recorder := NewRecorder(path)
+	api := srv.Client(client.WithHTTPRequesterWrapper(recorder.Wrap))
+
+	connector, err := api.CreateConnector(ctx, &client.Connector{Name: "connector"})
+	require.NoError(t, err)
+	token, err := api.CreateConnectorToken(ctx, &client.ConnectorToken{ConnectorID: connector.ConnectorID, Name: "token"})
+	require.NoError(t, err)
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	recordedSockets, err := api.Sockets(ctx)
+	require.NoError(t, err)
+	_, recordedErr := api.Socket(ctx, "missing")
+	require.True(t, client.NotFound(recordedErr))
+	srv.Close()
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(recorded), token.Token, "secrets are redacted")
+	assert.NotContains(t, string(recorded), srv.Token(), "secrets are redacted")
+	assert.Contains(t, string(recorded), redact.Redacted)
+	assert.NotContains(t, string(recorded), srv.URL, "the base url is not recorded")
+
+	// replay, with a different base url, without a server
+	replayer, err := NewReplayer(path)
+	require.NoError(t, err)
+	api = client.New(
+		client.WithBaseURL("http://localhost:1/api/v1"),
+		client.WithBackoff(noBackoff),
+		client.WithHTTPRequesterWrapper(replayer.Wrap),
+	)
+
+	replayedConnector, err := api.CreateConnector(ctx, &client.Connector{Name: "connector"})
+	require.NoError(t, err)
+	assert.Equal(t, connector, replayedConnector)
+	replayedToken, err := api.CreateConnectorToken(ctx, &client.ConnectorToken{ConnectorID: connector.ConnectorID, Name: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, replayedToken.ID)
+	assert.Equal(t, redact.Redacted, replayedToken.Token)
+	_, err = api.CreateSocket(ctx, &client.Socket{Name: "web", SocketType: "http"})
+	require.NoError(t, err)
+	replayedSockets, err := api.Sockets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, recordedSockets, replayedSockets)
+	_, replayedErr := api.Socket(ctx, "missing")
+	assert.True(t, client.NotFound(replayedErr))
+	assert.Equal(t, recordedErr.Error(), replayedErr.Error())
+
+	assert.Empty(t, replayer.Unused())
+	_, err = api.Sockets(ctx)
+	assert.ErrorContains(t, err, "cassette has no interaction for request GET /api/v1/sockets?page=1&page_size=100")
+}
+
+func Test_Replayer_Request(t *testing.T) {
+	t.Parallel()
+
+	retryAfter := 3 * time.Second
+	tests := []struct {
+		name         string
+		interactions []Interaction
+		method       string
+		path         string
+		input        any
+		wantCode     int
+		wantOutput   map[string]any
+		wantErr      error
+		wantUnused   int
+	}{
+		{
+			name: "replays the first unused matching interaction",
+			interactions: []Interaction{
+				{Method: http.MethodGet, Path: "/api/v1/socket/web", StatusCode: http.StatusOK, Response: []byte(`{"name":"first"}`)},
+				{Method: http.MethodGet, Path: "/api/v1/socket/web", StatusCode: http.StatusOK, Response: []byte(`{"name":"second"}`)},
+			},
+			method:     http.MethodGet,
+			path:       "https://api.border0.com/api/v1/socket/web",
+			wantCode:   http.StatusOK,
+			wantOutput: map[string]any{"name": "first"},
+			wantUnused: 1,
+		},
+		{
+			name: "request bodies must match, with secrets redacted",
+			interactions: []Interaction{
+				{Method: http.MethodPost, Path: "/api/v1/socket", Request: []byte(`{"name":"other"}`), StatusCode: http.StatusOK},
+				{Method: http.MethodPost, Path: "/api/v1/socket", Request: []byte(`{"name":"web","token":"[REDACTED]"}`), StatusCode: http.StatusCreated},
+			},
+			method:     http.MethodPost,
+			path:       "https://api.border0.com/api/v1/socket",
+			input:      map[string]any{"token": "secret", "name": "web"},
+			wantCode:   http.StatusCreated,
+			wantOutput: map[string]any{},
+			wantUnused: 1,
+		},
+		{
+			name: "api errors are replayed",
+			interactions: []Interaction{
+				{Method: http.MethodGet, Path: "/api/v1/sockets", StatusCode: http.StatusTooManyRequests, Error: &Error{
+					Code:       http.StatusTooManyRequests,
+					Message:    "slow down",
+					RetryAfter: retryAfter,
+				}},
+			},
+			method:     http.MethodGet,
+			path:       "https://api.border0.com/api/v1/sockets",
+			wantCode:   http.StatusTooManyRequests,
+			wantOutput: map[string]any{},
+			wantErr:    client.Error{Code: http.StatusTooManyRequests, Message: "slow down", RetryAfter: &retryAfter},
+		},
+		{
+			name: "other errors are replayed",
+			interactions: []Interaction{
+				{Method: http.MethodGet, Path: "/api/v1/sockets", Error: &Error{Message: "connection refused"}},
+			},
+			method:     http.MethodGet,
+			path:       "https://api.border0.com/api/v1/sockets",
+			wantOutput: map[string]any{},
+			wantErr:    errors.New("connection refused"),
+		},
+		{
+			name: "unexpected requests fail",
+			interactions: []Interaction{
+				{Method: http.MethodGet, Path: "/api/v1/sockets", StatusCode: http.StatusOK},
+			},
+			method:     http.MethodDelete,
+			path:       "https://api.border0.com/api/v1/sockets",
+			wantOutput: map[string]any{},
+			wantErr:    errors.New("cassette has no interaction for request DELETE /api/v1/sockets"),
+			wantUnused: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			replayer := &Replayer{interactions: test.interactions, replayed: make([]bool, len(test.interactions))}
+			output := map[string]any{}
+			code, err := replayer.Request(context.Background(), test.method, test.path, test.input, &output)
+
+			assert.Equal(t, test.wantCode, code)
+			assert.Equal(t, test.wantErr, err)
+			assert.Equal(t, test.wantOutput, output)
+			assert.Len(t, replayer.Unused(), test.wantUnused)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenType is the type of principal a Border0 token was issued to.
+type TokenType string
+
+// Types of Border0 tokens.
+const (
+	TokenTypeUser           TokenType = "user"
+	TokenTypeServiceAccount TokenType = "service_account"
+	TokenTypeConnector      TokenType = "connector"
+)
+
+// Roles of users and service accounts in a Border0 organization.
+const (
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleReadOnly = "read_only"
+	RoleClient   = "client"
+)
+
+// Resource is a kind of resource of a Border0 organization, see Claims.CanManage.
+type Resource string
+
+// Kinds of resources of a Border0 organization.
+const (
+	ResourceSockets         Resource = "sockets"
+	ResourcePolicies        Resource = "policies"
+	ResourceConnectors      Resource = "connectors"
+	ResourceUsers           Resource = "users"
+	ResourceGroups          Resource = "groups"
+	ResourceServiceAccounts Resource = "service_accounts"
+)
+
+// deniedResources are the kinds of resources each role is known not to be allowed to create,
+// update and delete. Neither tokens nor the api tell what a role allows, so the table is only
+// advisory, meant to fail fast on obvious mistakes: roles and resources missing from it are
+// allowed, and the api has the final say.
+var deniedResources = map[string][]Resource{
+	RoleReadOnly: {ResourceSockets, ResourcePolicies, ResourceConnectors, ResourceUsers, ResourceGroups, ResourceServiceAccounts},
+	RoleClient:   {ResourceSockets, ResourcePolicies, ResourceConnectors, ResourceUsers, ResourceGroups, ResourceServiceAccounts},
+}
+
+// Claims are the claims of a Border0 token, see ParseClaims.
+//
+// Only the user id (the user_id claim) and the standard claims (iat and exp) are known to be in
+// Border0 tokens. The other fields are best-effort: they are read from the claims they are
+// expected in (user_email, service_account_id, service_account_name, connector_id, org_id,
+// org_subdomain and role), which are not part of a published API contract, and are empty if
+// tokens don't have them. The type of the token is derived from them, so it's best-effort too.
+// Raw has the claims of the token as they are.
+type Claims struct {
+	Type               TokenType // best-effort, see above
+	UserID             string    // set for user tokens
+	UserEmail          string    // best-effort, set for user tokens
+	ServiceAccountID   string    // best-effort, set for service account tokens
+	ServiceAccountName string    // best-effort, set for service account tokens
+	ConnectorID        string    // best-effort, set for connector tokens
+	OrgID              string    // best-effort
+	OrgSubdomain       string    // best-effort
+	Role               string    // best-effort, empty if the token does not tell
+	IssuedAt           time.Time // zero if the token does not tell
+	ExpiresAt          time.Time // zero if the token does not expire
+
+	Raw jwt.MapClaims // all the claims of the token, including the ones above
+}
+
+// ParseClaims returns the claims of the given Border0 token. The signature of the token is not
+// verified, the claims are only meant to give callers a heads-up, the api decides.
+func ParseClaims(token string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, raw); err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	claim := func(key string) string {
+		s, _ := raw[key].(string)
+		return s
+	}
+	claims := &Claims{
+		Type:               TokenTypeUser,
+		UserID:             claim("user_id"),
+		UserEmail:          claim("user_email"),
+		ServiceAccountID:   claim("service_account_id"),
+		ServiceAccountName: claim("service_account_name"),
+		ConnectorID:        claim("connector_id"),
+		OrgID:              claim("org_id"),
+		OrgSubdomain:       claim("org_subdomain"),
+		Role:               claim("role"),
+		Raw:                raw,
+	}
+	switch {
+	case claims.ConnectorID != "":
+		claims.Type = TokenTypeConnector
+	case claims.ServiceAccountID != "" || claims.ServiceAccountName != "":
+		claims.Type = TokenTypeServiceAccount
+	}
+	if iat, err := raw.GetIssuedAt(); err == nil && iat != nil {
+		claims.IssuedAt = iat.Time
+	}
+	if exp, err := raw.GetExpirationTime(); err == nil && exp != nil {
+		claims.ExpiresAt = exp.Time
+	}
+	return claims, nil
+}
+
+// Claims returns the typed claims of the client's token, see ParseClaims. Token sources which
+// authenticate interactively (the device authorization flow, and re-authentication of expired
+// stored tokens) are not run to get it, so it fails when the client has no token yet.
+func (api *APIClient) Claims(ctx context.Context) (*Claims, error) {
+	token, err := api.tokens.Token(nonInteractive(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return ParseClaims(token)
+}
+
+// ExpiresIn returns the time left before the token expires, negative if it has expired. It's
+// the maximum duration for tokens which don't expire.
+func (c *Claims) ExpiresIn() time.Duration {
+	if c.ExpiresAt.IsZero() {
+		return math.MaxInt64
+	}
+	return time.Until(c.ExpiresAt)
+}
+
+// Expired reports whether the token has expired.
+func (c *Claims) Expired() bool { return c.ExpiresIn() <= 0 }
+
+// CanManage reports whether the token is allowed to create, update and delete the given kind
+// of resources, based on its role. It's advisory, the api has the final say: only the read only
+// and client roles are known not to manage resources, and connector tokens can't manage any.
+// Tokens without a role, or with any other role, are given the benefit of the doubt. Like the
+// role and the type of the token, it's best-effort (see Claims).
+func (c *Claims) CanManage(resource Resource) bool {
+	if c.Type == TokenTypeConnector {
+		return false
+	}
+	return !slices.Contains(deniedResources[c.Role], resource)
+}
+
+// RequireManage returns an error explaining why the token can't manage the given kind of
+// resources, nil if it can (see CanManage). It's meant for tools to fail fast, before calling
+// a mutating endpoint of the api.
+func (c *Claims) RequireManage(resource Resource) error {
+	if c.Expired() {
+		return fmt.Errorf("the Border0 token of %s expired at %s", c.principal(), c.ExpiresAt.Format(time.RFC3339))
+	}
+	if c.CanManage(resource) {
+		return nil
+	}
+	if c.Type == TokenTypeConnector {
+		return fmt.Errorf("the Border0 token of %s can't manage %s, use a user or service account token instead", c.principal(), humanize(resource))
+	}
+	return fmt.Errorf("the Border0 token of %s has the role [%s], which can't manage %s", c.principal(), c.Role, humanize(resource))
+}
+
+// principal describes who the token was issued to, for error messages.
+func (c *Claims) principal() string {
+	switch c.Type {
+	case TokenTypeConnector:
+		return fmt.Sprintf("connector [%s]", c.ConnectorID)
+	case TokenTypeServiceAccount:
+		return fmt.Sprintf("service account [%s]", cmp.Or(c.ServiceAccountName, c.ServiceAccountID))
+	default:
+		return fmt.Sprintf("user [%s]", cmp.Or(c.UserEmail, c.UserID))
+	}
+}
+
+// humanize returns the kind of resources in plain words.
+func humanize(resource Resource) string { return strings.ReplaceAll(string(resource), "_", " ") }
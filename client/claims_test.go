@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenWithClaims returns a token with the given claims.
+func testTokenWithClaims(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-key"))
+	require.NoError(t, err)
+	return token
+}
+
+func Test_ParseClaims(t *testing.T) {
+	t.Parallel()
+
+	issuedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantClaims Claims
+	}{
+		{
+			name: "user token",
+			claims: jwt.MapClaims{
+				"user_id":       "user-id",
+				"user_email":    "alice@example.com",
+				"org_id":        "org-id",
+				"org_subdomain": "acme",
+				"role":          "admin",
+				"iat":           issuedAt.Unix(),
+				"exp":           expiresAt.Unix(),
+			},
+			wantClaims: Claims{
+				Type:         TokenTypeUser,
+				UserID:       "user-id",
+				UserEmail:    "alice@example.com",
+				OrgID:        "org-id",
+				OrgSubdomain: "acme",
+				Role:         RoleAdmin,
+				IssuedAt:     issuedAt,
+				ExpiresAt:    expiresAt,
+			},
+		},
+		{
+			name:   "service account token",
+			claims: jwt.MapClaims{"service_account_id": "sa-id", "service_account_name": "ci", "role": "member"},
+			wantClaims: Claims{
+				Type:               TokenTypeServiceAccount,
+				ServiceAccountID:   "sa-id",
+				ServiceAccountName: "ci",
+				Role:               RoleMember,
+			},
+		},
+		{
+			name:   "connector token",
+			claims: jwt.MapClaims{"connector_id": "connector-id", "org_id": "org-id"},
+			wantClaims: Claims{
+				Type:        TokenTypeConnector,
+				ConnectorID: "connector-id",
+				OrgID:       "org-id",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims, err := ParseClaims(testTokenWithClaims(t, test.claims))
+			require.NoError(t, err)
+			assert.NotEmpty(t, claims.Raw)
+			claims.Raw = nil
+			assert.Equal(t, test.wantClaims, *claims)
+		})
+	}
+
+	_, err := ParseClaims("bad.jwt.token")
+	assert.ErrorContains(t, err, "failed to parse token")
+}
+
+func Test_APIClient_Claims(t *testing.T) {
+	t.Parallel()
+
+	api := New(WithAuthToken(testTokenWithClaims(t, jwt.MapClaims{"user_id": "user-id"})))
+	claims, err := api.Claims(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "user-id", claims.UserID)
+}
+
+func Test_APIClient_Claims_nonInteractive(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	api := New(WithBaseURL(ts.URL), WithDeviceFlow(auth.WithTokenWriting(false), auth.WithOpenBrowser(false)))
+	_, err := api.Claims(context.Background())
+	assert.ErrorContains(t, err, "failed to parse token")
+	_, err = api.TokenClaims()
+	assert.ErrorContains(t, err, "failed to parse token")
+	assert.Zero(t, requests.Load(), "the device authorization flow is not started")
+}
+
+func Test_Claims_ExpiresIn(t *testing.T) {
+	t.Parallel()
+
+	claims := &Claims{ExpiresAt: time.Now().Add(time.Hour)}
+	assert.InDelta(t, time.Hour, claims.ExpiresIn(), float64(time.Second))
+	assert.False(t, claims.Expired())
+
+	claims = &Claims{ExpiresAt: time.Now().Add(-time.Minute)}
+	assert.Negative(t, claims.ExpiresIn())
+	assert.True(t, claims.Expired())
+
+	claims = &Claims{}
+	assert.Equal(t, time.Duration(math.MaxInt64), claims.ExpiresIn(), "tokens without expiry don't expire")
+	assert.False(t, claims.Expired())
+}
+
+func Test_Claims_RequireManage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		claims   Claims
+		resource Resource
+		wantErr  string
+	}{
+		{
+			name:     "admins manage everything",
+			claims:   Claims{Type: TokenTypeUser, Role: RoleAdmin},
+			resource: ResourceServiceAccounts,
+		},
+		{
+			name:     "members are not restricted by the client",
+			claims:   Claims{Type: TokenTypeServiceAccount, ServiceAccountName: "ci", Role: RoleMember},
+			resource: ResourceServiceAccounts,
+		},
+		{
+			name:     "read only users manage nothing",
+			claims:   Claims{Type: TokenTypeUser, UserEmail: "bob@example.com", Role: RoleReadOnly},
+			resource: ResourcePolicies,
+			wantErr:  "the Border0 token of user [bob@example.com] has the role [read_only], which can't manage policies",
+		},
+		{
+			name:     "clients manage nothing",
+			claims:   Claims{Type: TokenTypeServiceAccount, ServiceAccountID: "service-account-id", Role: RoleClient},
+			resource: ResourceSockets,
+			wantErr:  "the Border0 token of service account [service-account-id] has the role [client], which can't manage sockets",
+		},
+		{
+			name:     "connectors manage nothing",
+			claims:   Claims{Type: TokenTypeConnector, ConnectorID: "connector-id"},
+			resource: ResourceSockets,
+			wantErr:  "the Border0 token of connector [connector-id] can't manage sockets, use a user or service account token instead",
+		},
+		{
+			name:     "unknown roles are given the benefit of the doubt",
+			claims:   Claims{Type: TokenTypeUser, Role: "owner"},
+			resource: ResourceGroups,
+		},
+		{
+			name:     "tokens without a role are given the benefit of the doubt",
+			claims:   Claims{Type: TokenTypeUser, UserEmail: "bob@example.com"},
+			resource: ResourceUsers,
+		},
+		{
+			name:     "unknown resources are given the benefit of the doubt",
+			claims:   Claims{Type: TokenTypeUser, Role: RoleReadOnly},
+			resource: Resource("tunnels"),
+		},
+		{
+			name:     "expired tokens",
+			claims:   Claims{Type: TokenTypeUser, UserID: "user-id", Role: RoleAdmin, ExpiresAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+			resource: ResourceSockets,
+			wantErr:  "the Border0 token of user [user-id] expired at 2020-01-02T03:04:05Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.claims.RequireManage(test.resource)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				assert.True(t, test.claims.CanManage(test.resource))
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+		})
+	}
+}
@@ -11,10 +11,12 @@ import (
 type ConnectorService interface {
 	Connector(ctx context.Context, id string) (out *Connector, err error)
 	Connectors(ctx context.Context) (out *Connectors, err error)
+	ConnectorsPaginator(ctx context.Context, pageSize int) *Paginator[Connector]
 	CreateConnector(ctx context.Context, in *Connector) (out *Connector, err error)
 	UpdateConnector(ctx context.Context, in *Connector) (out *Connector, err error)
 	DeleteConnector(ctx context.Context, id string) (err error)
 	ConnectorTokens(ctx context.Context, connectorID string) (out *ConnectorTokens, err error)
+	ConnectorTokensPaginator(ctx context.Context, connectorID string, pageSize int) *Paginator[ConnectorToken]
 	ConnectorToken(ctx context.Context, connectorID string, tokenID string) (out *ConnectorToken, err error)
 	CreateConnectorToken(ctx context.Context, in *ConnectorToken) (out *ConnectorToken, err error)
 	DeleteConnectorToken(ctx context.Context, connectorID, tokenID string) (err error)
@@ -43,6 +45,19 @@ func (api *APIClient) Connectors(ctx context.Context) (out *Connectors, err erro
 	return out, nil
 }
 
+// ConnectorsPaginator returns a paginator to iterate pages of connectors. The Border0 API
+// returns all connectors at once, so they are all fetched with the first page.
+func (api *APIClient) ConnectorsPaginator(ctx context.Context, pageSize int) *Paginator[Connector] {
+	list := func(ctx context.Context, api *APIClient) ([]Connector, error) {
+		connectors, err := api.Connectors(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return connectors.List, nil
+	}
+	return newListPaginator(api, list, pageSize)
+}
+
 // CreateConnector creates a new connector in your Border0 organization. Connector name must be unique within your organization,
 // otherwise API will return an error. Connector name must contain only lowercase letters, numbers and dashes.
 func (api *APIClient) CreateConnector(ctx context.Context, in *Connector) (out *Connector, err error) {
@@ -86,6 +101,19 @@ func (api *APIClient) ConnectorTokens(ctx context.Context, connectorID string) (
 	return out, nil
 }
 
+// ConnectorTokensPaginator returns a paginator to iterate pages of tokens for a connector. The
+// Border0 API returns all tokens of a connector at once, so they are all fetched with the first page.
+func (api *APIClient) ConnectorTokensPaginator(ctx context.Context, connectorID string, pageSize int) *Paginator[ConnectorToken] {
+	list := func(ctx context.Context, api *APIClient) ([]ConnectorToken, error) {
+		tokens, err := api.ConnectorTokens(ctx, connectorID)
+		if err != nil {
+			return nil, err
+		}
+		return tokens.List, nil
+	}
+	return newListPaginator(api, list, pageSize)
+}
+
 // ConnectorToken fetches a connector's token by connector UUID and token UUID.
 func (api *APIClient) ConnectorToken(ctx context.Context, connectorID string, tokenID string) (out *ConnectorToken, err error) {
 	out = new(ConnectorToken)
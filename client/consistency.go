@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consistencyTracker implements read-after-write consistency. It remembers when each type
+// of resource (sockets, policies, connectors, etc.) was last written to, and delays reads
+// of that type of resource until the delay advertised by the api server (in the data
+// consistency section of its server info) has passed since the last write. Resources are
+// tracked by type rather than by id, so that listing resources also observes the writes, and
+// so that reading a resource by name observes the writes made by id (e.g. EnsureSocket updates
+// sockets by id), and the other way around.
+type consistencyTracker struct {
+	serverInfo func(context.Context) (*ServerInfo, error)
+	logger     *slog.Logger
+
+	infoMu  sync.Mutex
+	fetched bool
+	delay   time.Duration
+
+	mu         sync.Mutex
+	lastWrites map[string]time.Time
+}
+
+func newConsistencyTracker(serverInfo func(context.Context) (*ServerInfo, error), logger *slog.Logger) *consistencyTracker {
+	return &consistencyTracker{
+		serverInfo: serverInfo,
+		logger:     logger,
+		lastWrites: make(map[string]time.Time),
+	}
+}
+
+// wrote records that a write to the resources of the given path has completed.
+func (c *consistencyTracker) wrote(path string) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range resourceKeys(path) {
+		c.lastWrites[key] = now
+	}
+}
+
+// wait blocks until the resources of the given path can be read consistently, or until
+// the context is done.
+func (c *consistencyTracker) wait(ctx context.Context, path string) error {
+	var lastWrite time.Time
+	c.mu.Lock()
+	for _, key := range resourceKeys(path) {
+		if t := c.lastWrites[key]; t.After(lastWrite) {
+			lastWrite = t
+		}
+	}
+	c.mu.Unlock()
+	if lastWrite.IsZero() {
+		return nil
+	}
+	return sleep(ctx, time.Until(lastWrite.Add(c.readAfterWriteDelay(ctx))))
+}
+
+// readAfterWriteDelay returns the delay advertised by the api server, which is fetched
+// only once. Failing to fetch it is not fatal: reads are not delayed, and fetching it is
+// attempted again on the next read.
+func (c *consistencyTracker) readAfterWriteDelay(ctx context.Context) time.Duration {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.fetched {
+		return c.delay
+	}
+	info, err := c.serverInfo(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to get Border0 API server info, reads will not be delayed", slog.Any("error", err))
+		return 0
+	}
+	if info.DataConsistency != nil {
+		c.delay = time.Duration(info.DataConsistency.RxAfterTxDelayMS) * time.Millisecond
+	}
+	c.fetched = true
+	return c.delay
+}
+
+// resourceKinds maps the path segments identifying resources to the type of the resource,
+// so that e.g. "/sockets" and "/socket/{id}" refer to the same type of resource.
+var resourceKinds = map[string]string{
+	"socket":           "socket",
+	"sockets":          "socket",
+	"connector":        "connector",
+	"connectors":       "connector",
+	"policy":           "policy",
+	"policies":         "policy",
+	"user":             "user",
+	"users":            "user",
+	"group":            "group",
+	"groups":           "group",
+	"service_account":  "service_account",
+	"service_accounts": "service_account",
+}
+
+// resourceKeys returns the types of the resources referred to by the given path, in the
+// order they appear in it. e.g. "/policy/{id}/socket" refers to both policies and sockets.
+func resourceKeys(path string) []string {
+	path, _, _ = strings.Cut(path, "?")
+	var keys []string
+	for _, segment := range strings.Split(path, "/") {
+		kind, ok := resourceKinds[segment]
+		if ok && !slices.Contains(keys, kind) {
+			keys = append(keys, kind)
+		}
+	}
+	return keys
+}
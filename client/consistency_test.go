@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resourceKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "/sockets", want: []string{"socket"}},
+		{path: "/socket/abc?activeOnly=true", want: []string{"socket"}},
+		{path: "/socket/abc/policy", want: []string{"socket", "policy"}},
+		{path: "/policy/abc/socket", want: []string{"policy", "socket"}},
+		{path: "/policies/find?name=abc", want: []string{"policy"}},
+		{path: "/connector/abc/token/def", want: []string{"connector"}},
+		{path: "/organizations/iam/service_accounts/abc/tokens", want: []string{"service_account"}},
+		{path: "/organizations/iam/groups/memberships", want: []string{"group"}},
+		{path: "/serverinfo", want: nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, resourceKeys(test.path))
+		})
+	}
+}
+
+func Test_consistencyTracker(t *testing.T) {
+	t.Parallel()
+
+	delay := 100 * time.Millisecond
+	newTracker := func(fetches *atomic.Int32, err error) *consistencyTracker {
+		return newConsistencyTracker(func(context.Context) (*ServerInfo, error) {
+			fetches.Add(1)
+			if err != nil {
+				return nil, err
+			}
+			return &ServerInfo{DataConsistency: &DataConsistency{RxAfterTxDelayMS: delay.Milliseconds()}}, nil
+		}, slog.Default())
+	}
+
+	t.Run("reads without previous writes are not delayed", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, nil)
+
+		start := time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/sockets"))
+		assert.Less(t, time.Since(start), delay)
+		assert.Zero(t, fetches.Load(), "server info should not be fetched until needed")
+	})
+
+	t.Run("reads after writes are delayed and server info is fetched once", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, nil)
+
+		tracker.wrote("/socket/abc")
+		start := time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/sockets"))
+		assert.GreaterOrEqual(t, time.Since(start), delay-10*time.Millisecond)
+
+		// other types of resources are not delayed
+		start = time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/policies"))
+		assert.Less(t, time.Since(start), delay)
+
+		tracker.wrote("/policy/abc/socket")
+		assert.NoError(t, tracker.wait(context.Background(), "/policies"))
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("writes by id delay reads by name", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, nil)
+
+		tracker.wrote("/socket/test-id")
+		start := time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/socket/test-name"))
+		assert.GreaterOrEqual(t, time.Since(start), delay-10*time.Millisecond)
+	})
+
+	t.Run("writes without an id delay reads of all the resources of their type", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, nil)
+
+		tracker.wrote("/socket")
+		start := time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/socket/b"))
+		assert.GreaterOrEqual(t, time.Since(start), delay-10*time.Millisecond)
+	})
+
+	t.Run("reads are not delayed when server info is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, errors.New("unavailable"))
+
+		tracker.wrote("/socket")
+		start := time.Now()
+		assert.NoError(t, tracker.wait(context.Background(), "/socket/abc"))
+		assert.NoError(t, tracker.wait(context.Background(), "/socket/abc"))
+		assert.Less(t, time.Since(start), delay)
+		assert.Equal(t, int32(2), fetches.Load(), "server info should be fetched again after a failure")
+	})
+
+	t.Run("delayed reads stop when the context is done", func(t *testing.T) {
+		t.Parallel()
+
+		var fetches atomic.Int32
+		tracker := newTracker(&fetches, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		tracker.wrote("/socket")
+		assert.ErrorIs(t, tracker.wait(ctx, "/socket/abc"), context.Canceled)
+	})
+}
+
+func Test_APIClient_WithReadAfterWriteConsistency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	delay := 100 * time.Millisecond
+
+	requester := new(mocks.ClientHTTPRequester)
+	requester.EXPECT().
+		Request(ctx, http.MethodPost, defaultBaseURL+"/socket", nil, nil).
+		Return(http.StatusOK, nil).
+		Once()
+	requester.EXPECT().
+		Request(ctx, http.MethodGet, defaultBaseURL+"/serverinfo", nil, mock.Anything).
+		Run(func(_ context.Context, _, _ string, _, output any) {
+			output.(*ServerInfo).DataConsistency = &DataConsistency{RxAfterTxDelayMS: delay.Milliseconds()}
+		}).
+		Return(http.StatusOK, nil).
+		Once()
+	requester.EXPECT().
+		Request(ctx, http.MethodGet, defaultBaseURL+"/socket/abc", nil, nil).
+		Return(http.StatusOK, nil).
+		Twice()
+
+	api := New(WithReadAfterWriteConsistency())
+	api.http = requester
+
+	_, err := api.request(ctx, http.MethodPost, "/socket", nil, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = api.request(ctx, http.MethodGet, "/socket/abc", nil, nil)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), delay-10*time.Millisecond)
+
+	// once the delay has passed, reads are no longer delayed
+	start = time.Now()
+	_, err = api.request(ctx, http.MethodGet, "/socket/abc", nil, nil)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), delay)
+
+	requester.AssertExpectations(t)
+}
+
+func Test_APIClient_WithReadAfterWriteConsistency_readByNameAfterWriteByID(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	delay := 100 * time.Millisecond
+
+	requester := new(mocks.ClientHTTPRequester)
+	requester.EXPECT().
+		Request(ctx, http.MethodPut, defaultBaseURL+"/socket/test-id", mock.Anything, mock.Anything).
+		Return(http.StatusOK, nil).
+		Once()
+	requester.EXPECT().
+		Request(ctx, http.MethodGet, defaultBaseURL+"/serverinfo", nil, mock.Anything).
+		Run(func(_ context.Context, _, _ string, _, output any) {
+			output.(*ServerInfo).DataConsistency = &DataConsistency{RxAfterTxDelayMS: delay.Milliseconds()}
+		}).
+		Return(http.StatusOK, nil).
+		Once()
+	requester.EXPECT().
+		Request(ctx, http.MethodGet, defaultBaseURL+"/socket/test-name?activeOnly=true", nil, mock.Anything).
+		Return(http.StatusOK, nil).
+		Once()
+
+	api := New(WithReadAfterWriteConsistency())
+	api.http = requester
+
+	_, err := api.UpdateSocket(ctx, "test-id", &Socket{Name: "test-name"})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = api.Socket(ctx, "test-name")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), delay-10*time.Millisecond)
+
+	requester.AssertExpectations(t)
+}
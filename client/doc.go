@@ -8,4 +8,10 @@
 //	)
 //
 // See [Option] for more configurable options.
+//
+// Clients for several Border0 organizations can be configured with named profiles of the
+// shared config file (~/.border0/config), selected with [WithProfile] or the BORDER0_PROFILE
+// env var:
+//
+//	api := client.New(client.WithProfile("staging"))
 package client
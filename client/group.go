@@ -15,6 +15,7 @@ type GroupService interface {
 	Group(ctx context.Context, id string) (out *Group, err error)
 	Groups(ctx context.Context) (out *Groups, err error)
 	GroupsPaginator(ctx context.Context, pageSize int) *Paginator[Group]
+	ResumeGroupsPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[Group]
 	CreateGroup(ctx context.Context, in *Group) (out *Group, err error)
 	UpdateGroup(ctx context.Context, in *Group) (out *Group, err error)
 	UpdateGroupMemberships(ctx context.Context, in *Group, userIDs []string) (out *Group, err error)
@@ -50,10 +51,17 @@ func (api *APIClient) Groups(ctx context.Context) (out *Groups, err error) {
 
 // GroupsPaginator returns a paginator to iterate pages of groups.
 func (api *APIClient) GroupsPaginator(ctx context.Context, pageSize int) *Paginator[Group] {
+	return api.ResumeGroupsPaginator(ctx, Checkpoint{PageSize: pageSize})
+}
+
+// ResumeGroupsPaginator returns a paginator to iterate pages of groups, which resumes from
+// the given checkpoint of a groups paginator.
+func (api *APIClient) ResumeGroupsPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[Group] {
+	pageSize := checkpoint.PageSize
 	if pageSize <= 0 {
 		pageSize = defaultPageSizeGroups
 	}
-	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []Group, nextPage int, err error) {
+	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []Group, pages pagination, err error) {
 		params := url.Values{}
 		params.Add("page", strconv.Itoa(page))
 		params.Add("page_size", strconv.Itoa(size))
@@ -61,11 +69,11 @@ func (api *APIClient) GroupsPaginator(ctx context.Context, pageSize int) *Pagina
 
 		var res paginatedResponse[Group]
 		if _, err = api.request(ctx, http.MethodGet, path, nil, &res); err != nil {
-			return nil, 0, err
+			return nil, pagination{}, err
 		}
-		return res.List, res.Pagination.NextPage, nil
+		return res.List, res.Pagination, nil
 	}
-	return newPaginator(api, fetch, pageSize)
+	return newPaginator(api, fetch, pageSize).resume(checkpoint)
 }
 
 // CreateGroup creates a new group in your Border0 organization.
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,8 +18,10 @@ import (
 // HTTPClient is a wrapper around http.Client that handles authentication,
 // request/response encoding/decoding, and error handling.
 type HTTPClient struct {
-	client *http.Client
-	token  string
+	client      *http.Client
+	ownsClient  bool // whether the http client was built by the sdk, see Close
+	tokens      TokenSource
+	middlewares []Middleware
 }
 
 // HTTPRequester is an interface for HTTPClient.
@@ -32,13 +35,49 @@ const (
 	headerAccept        = "Accept"
 	headerAuthorization = "Authorization"
 	headerContentType   = "Content-Type"
+	headerETag          = "ETag"
+	headerIfNoneMatch   = "If-None-Match"
+	headerAccessToken   = "x-access-token"
 
 	// HTTP header values
 	applicationJSON = "application/json"
 )
 
-// Request sends an HTTP request to the API server.
+// Request sends an HTTP request to the API server. The request goes through
+// the configured middleware chain before it is sent.
 func (h *HTTPClient) Request(ctx context.Context, method, path string, input, output any) (int, error) {
+	return chain(h.do, h.middlewares...)(ctx, method, path, input, output)
+}
+
+// do gets a token from the token source and sends the request with it. If the
+// API server rejects the token, and the token source can refresh it, the request
+// is retried once with a fresh token.
+func (h *HTTPClient) do(ctx context.Context, method, path string, input, output any) (int, error) {
+	if h.tokens == nil || isUnauthenticated(ctx) {
+		return h.send(ctx, "", method, path, input, output)
+	}
+
+	token, err := h.tokens.Token(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get Border0 token: %w", err)
+	}
+	code, err := h.send(ctx, token, method, path, input, output)
+	if code != http.StatusUnauthorized {
+		return code, err
+	}
+
+	refreshable, ok := h.tokens.(RefreshableTokenSource)
+	if !ok {
+		return code, err
+	}
+	fresh, refreshErr := refreshable.Refresh(ctx, token)
+	if refreshErr != nil || fresh == token {
+		return code, err
+	}
+	return h.send(ctx, fresh, method, path, input, output)
+}
+
+func (h *HTTPClient) send(ctx context.Context, token, method, path string, input, output any) (int, error) {
 	// create request
 	var buf bytes.Buffer
 	if input != nil {
@@ -52,12 +91,21 @@ func (h *HTTPClient) Request(ctx context.Context, method, path string, input, ou
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Add(headerAuthorization, fmt.Sprintf("Bearer %s", h.token))
+	if token != "" {
+		req.Header.Add(headerAuthorization, fmt.Sprintf("Bearer %s", token))
+	}
 	if input == nil {
 		req.Header.Set(headerAccept, applicationJSON)
 	} else {
 		req.Header.Set(headerContentType, applicationJSON)
 	}
+	if accessToken := accessTokenFrom(ctx); accessToken != "" {
+		req.Header.Set(headerAccessToken, accessToken)
+	}
+	cond := conditionalRequestFrom(ctx)
+	if cond != nil && cond.ifNoneMatch != "" {
+		req.Header.Set(headerIfNoneMatch, cond.ifNoneMatch)
+	}
 
 	// send request
 	resp, err := h.client.Do(req)
@@ -66,6 +114,10 @@ func (h *HTTPClient) Request(ctx context.Context, method, path string, input, ou
 	}
 	defer resp.Body.Close()
 
+	if cond != nil {
+		cond.etag = resp.Header.Get(headerETag)
+	}
+
 	// handle successful response (2xx)
 	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
 		if output != nil {
@@ -85,17 +137,37 @@ func (h *HTTPClient) Request(ctx context.Context, method, path string, input, ou
 	return resp.StatusCode, nil
 }
 
-// Close closes idle connections in the underlying HTTP client.
+// Close closes idle connections in the underlying HTTP client. Clients given with
+// WithHTTPClient are owned by the caller, and may be shared, so they're left alone.
 func (h *HTTPClient) Close() {
-	h.client.CloseIdleConnections()
+	if h.ownsClient {
+		h.client.CloseIdleConnections()
+	}
 }
 
+// requestIDHeaders are the response headers which may hold the id the API server assigned
+// to a request, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "X-Amzn-Requestid"}
+
 // APIErrorFrom creates an Error from an HTTP response.
 func APIErrorFrom(resp *http.Response) Error {
 	apiErr := Error{
 		Code: resp.StatusCode,
 	}
 
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		if resp.Request.URL != nil {
+			apiErr.URLPath = resp.Request.URL.Path
+		}
+	}
+	for _, header := range requestIDHeaders {
+		if requestID := resp.Header.Get(header); requestID != "" {
+			apiErr.RequestID = requestID
+			break
+		}
+	}
+
 	// Parse Retry-After header if present for rate limiting responses
 	if resp.StatusCode == http.StatusTooManyRequests {
 		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
@@ -108,11 +180,19 @@ func APIErrorFrom(resp *http.Response) Error {
 	var buf bytes.Buffer
 	tee := io.TeeReader(resp.Body, &buf)
 
-	if err := json.NewDecoder(tee).Decode(&apiErr); err != nil {
+	var body errorBody
+	if err := json.NewDecoder(tee).Decode(&body); err != nil {
 		peeked, _ := bufio.NewReader(&buf).Peek(1024)
 		if len(peeked) > 0 {
 			apiErr.Message = string(peeked)
 		}
+	} else {
+		if body.Code != 0 {
+			apiErr.Code = body.Code
+		}
+		apiErr.Message = body.Message
+		apiErr.Fallback = body.Fallback
+		apiErr.Details = append(fieldErrorsFrom(body.Details), fieldErrorsFrom(body.Errors)...)
 	}
 
 	if apiErr.Message == "" {
@@ -126,6 +206,36 @@ func APIErrorFrom(resp *http.Response) Error {
 	return apiErr
 }
 
+// errorBody is the body of an error response from the API server.
+type errorBody struct {
+	Code     int             `json:"status_code"`
+	Message  string          `json:"error_message"`
+	Fallback string          `json:"message"`
+	Details  json.RawMessage `json:"details"`
+	Errors   json.RawMessage `json:"errors"`
+}
+
+// fieldErrorsFrom parses field-level validation errors, which the API server returns either
+// as a list of objects with a field and a message, or as an object of messages by field.
+func fieldErrorsFrom(raw json.RawMessage) []FieldError {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []FieldError
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var byField map[string]string
+	if err := json.Unmarshal(raw, &byField); err == nil {
+		for field, message := range byField {
+			list = append(list, FieldError{Field: field, Message: message})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Field < list[j].Field })
+		return list
+	}
+	return nil
+}
+
 // parseRetryAfter parses the Retry-After header value (always in seconds) and returns a time.Duration.
 func parseRetryAfter(retryAfter string) (time.Duration, error) {
 	if retryAfter == "" {
@@ -147,30 +257,77 @@ type Error struct {
 	Message    string         `json:"error_message"`
 	Fallback   string         `json:"message"`
 	RetryAfter *time.Duration `json:"-"` // For internal use, not part of the API response
+	Method     string         `json:"-"` // HTTP method of the request that failed
+	URLPath    string         `json:"-"` // URL path of the request that failed, including the path of the base URL
+	RequestID  string         `json:"-"` // id the API server assigned to the request, if any
+	Details    []FieldError   `json:"-"` // field-level validation errors, if any
+}
+
+// FieldError is a validation error of a field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // Error returns string representation of an Error.
 func (e Error) Error() string {
-	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d: %s", e.Code, e.Message)
+	for i, detail := range e.Details {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s: %s", detail.Field, detail.Message)
+	}
+	if e.Method != "" {
+		fmt.Fprintf(&sb, " (%s %s", e.Method, e.URLPath)
+		if e.RequestID != "" {
+			fmt.Fprintf(&sb, ", request id %s", e.RequestID)
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
 }
 
 func NotFound(err error) bool {
-	if err == nil {
-		return false
-	}
-	var apiErr Error
-	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+	_, ok := hasCode(err, http.StatusNotFound)
+	return ok
 }
 
 func BadRequest(err error) (Error, bool) {
-	if err == nil {
-		return Error{}, false
-	}
+	return hasCode(err, http.StatusBadRequest)
+}
+
+// IsUnauthorized returns true if the error is a 401 error returned by the API server.
+func IsUnauthorized(err error) bool {
+	_, ok := hasCode(err, http.StatusUnauthorized)
+	return ok
+}
+
+// IsForbidden returns true if the error is a 403 error returned by the API server.
+func IsForbidden(err error) bool {
+	_, ok := hasCode(err, http.StatusForbidden)
+	return ok
+}
+
+// IsConflict returns true if the error is a 409 error returned by the API server.
+func IsConflict(err error) bool {
+	_, ok := hasCode(err, http.StatusConflict)
+	return ok
+}
+
+// IsRateLimited returns true if the error is a 429 error returned by the API server.
+func IsRateLimited(err error) bool {
+	_, ok := hasCode(err, http.StatusTooManyRequests)
+	return ok
+}
+
+// hasCode returns the Error in the chain of the given error, if it has the given status code.
+func hasCode(err error, code int) (Error, bool) {
 	var apiErr Error
-	if !errors.As(err, &apiErr) {
-		return Error{}, false
-	}
-	if apiErr.Code != http.StatusBadRequest {
+	if err == nil || !errors.As(err, &apiErr) || apiErr.Code != code {
 		return Error{}, false
 	}
 	return apiErr, true
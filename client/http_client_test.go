@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -116,7 +117,7 @@ func Test_HTTPClient_Request(t *testing.T) {
 			defer ts.Close()
 
 			requester := new(HTTPClient)
-			requester.token = testToken
+			requester.tokens = StaticTokenSource(testToken)
 			requester.client = ts.Client()
 			defer requester.Close()
 
@@ -148,10 +149,13 @@ func Test_HTTPClient_Request(t *testing.T) {
 func Test_APIErrorFrom(t *testing.T) {
 	t.Parallel()
 
+	request := &http.Request{Method: http.MethodPost, URL: &url.URL{Scheme: "https", Host: "api.border0.com", Path: "/api/v1/socket"}}
+
 	tests := []struct {
-		name      string
-		givenResp *http.Response
-		wantErr   error
+		name       string
+		givenResp  *http.Response
+		wantErr    error
+		wantAPIErr *Error // checked along with the error message, if set
 	}{
 		{
 			name: "cannot decode error response so just return whatever responded",
@@ -185,6 +189,72 @@ func Test_APIErrorFrom(t *testing.T) {
 			},
 			wantErr: errors.New("400: bad request"),
 		},
+		{
+			name: "method and path of the request",
+			givenResp: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Request:    request,
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"socket not found"}`)),
+			},
+			wantErr:    errors.New("404: socket not found (POST /api/v1/socket)"),
+			wantAPIErr: &Error{Code: http.StatusNotFound, Message: "socket not found", Method: http.MethodPost, URLPath: "/api/v1/socket"},
+		},
+		{
+			name: "request id from X-Request-Id header",
+			givenResp: &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Request:    request,
+				Header:     http.Header{"X-Request-Id": {"req-1"}, "X-Amzn-Requestid": {"amzn-1"}},
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"oops"}`)),
+			},
+			wantErr:    errors.New("500: oops (POST /api/v1/socket, request id req-1)"),
+			wantAPIErr: &Error{Code: http.StatusInternalServerError, Message: "oops", Method: http.MethodPost, URLPath: "/api/v1/socket", RequestID: "req-1"},
+		},
+		{
+			name: "request id from X-Amzn-Requestid header",
+			givenResp: &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Request:    request,
+				Header:     http.Header{"X-Amzn-Requestid": {"amzn-1"}},
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"oops"}`)),
+			},
+			wantErr:    errors.New("500: oops (POST /api/v1/socket, request id amzn-1)"),
+			wantAPIErr: &Error{Code: http.StatusInternalServerError, Message: "oops", Method: http.MethodPost, URLPath: "/api/v1/socket", RequestID: "amzn-1"},
+		},
+		{
+			name: "list of field errors in details",
+			givenResp: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"invalid socket","details":[{"field":"socket_type","message":"is required"},{"field":"name","message":"is required"}]}`)),
+			},
+			wantErr: errors.New("400: invalid socket: socket_type: is required, name: is required"),
+			wantAPIErr: &Error{Code: http.StatusBadRequest, Message: "invalid socket", Details: []FieldError{
+				{Field: "socket_type", Message: "is required"},
+				{Field: "name", Message: "is required"},
+			}},
+		},
+		{
+			name: "field errors by field in details and errors, sorted by field",
+			givenResp: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"invalid socket","details":{"socket_type":"is required","name":"is required"},"errors":{"tags":"too many"}}`)),
+			},
+			wantErr: errors.New("400: invalid socket: name: is required, socket_type: is required, tags: too many"),
+			wantAPIErr: &Error{Code: http.StatusBadRequest, Message: "invalid socket", Details: []FieldError{
+				{Field: "name", Message: "is required"},
+				{Field: "socket_type", Message: "is required"},
+				{Field: "tags", Message: "too many"},
+			}},
+		},
+		{
+			name: "unparseable details are ignored",
+			givenResp: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(strings.NewReader(`{"error_message":"invalid socket","details":"name is required"}`)),
+			},
+			wantErr:    errors.New("400: invalid socket"),
+			wantAPIErr: &Error{Code: http.StatusBadRequest, Message: "invalid socket"},
+		},
 	}
 
 	for _, test := range tests {
@@ -194,10 +264,66 @@ func Test_APIErrorFrom(t *testing.T) {
 
 			gotErr := APIErrorFrom(test.givenResp)
 			assert.EqualError(t, gotErr, test.wantErr.Error())
+			if test.wantAPIErr != nil {
+				assert.Equal(t, *test.wantAPIErr, gotErr)
+			}
+		})
+	}
+}
+
+func Test_errorPredicates(t *testing.T) {
+	t.Parallel()
+
+	predicates := map[string]func(error) bool{
+		"NotFound":       NotFound,
+		"BadRequest":     func(err error) bool { _, ok := BadRequest(err); return ok },
+		"IsUnauthorized": IsUnauthorized,
+		"IsForbidden":    IsForbidden,
+		"IsConflict":     IsConflict,
+		"IsRateLimited":  IsRateLimited,
+	}
+
+	tests := []struct {
+		name     string
+		givenErr error
+		want     string // name of the only predicate which holds, empty for none
+	}{
+		{name: "not an error", givenErr: nil},
+		{name: "not an Error typed error", givenErr: errors.New("oops")},
+		{name: "server error", givenErr: Error{Code: http.StatusInternalServerError}},
+		{name: "bad request", givenErr: Error{Code: http.StatusBadRequest}, want: "BadRequest"},
+		{name: "unauthorized", givenErr: Error{Code: http.StatusUnauthorized}, want: "IsUnauthorized"},
+		{name: "forbidden", givenErr: Error{Code: http.StatusForbidden}, want: "IsForbidden"},
+		{name: "not found", givenErr: Error{Code: http.StatusNotFound}, want: "NotFound"},
+		{name: "conflict", givenErr: Error{Code: http.StatusConflict}, want: "IsConflict"},
+		{name: "rate limited", givenErr: Error{Code: http.StatusTooManyRequests}, want: "IsRateLimited"},
+		{name: "wrapped conflict", givenErr: fmt.Errorf("failed to create socket: %w", Error{Code: http.StatusConflict}), want: "IsConflict"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			for name, predicate := range predicates {
+				assert.Equal(t, name == test.want, predicate(test.givenErr), name)
+			}
 		})
 	}
 }
 
+func Test_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	details := []FieldError{{Field: "name", Message: "is required"}}
+	apiErr, ok := BadRequest(fmt.Errorf("failed: %w", Error{Code: http.StatusBadRequest, Message: "invalid", Details: details}))
+	assert.True(t, ok)
+	assert.Equal(t, details, apiErr.Details)
+
+	apiErr, ok = BadRequest(Error{Code: http.StatusConflict})
+	assert.False(t, ok)
+	assert.Equal(t, Error{}, apiErr)
+}
+
 func Test_NotFound(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// loopbackClientID is the OAuth2 client id of the loopback flow.
+const loopbackClientID = "border0-go"
+
+// errLoopbackTimedOut is the cause of the loopback flow timing out.
+var errLoopbackTimedOut = errors.New("timed out waiting for the login in the browser")
+
+// loopbackTokenRequest is the request body of the exchange of an authorization code for a token.
+type loopbackTokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	ClientID     string `json:"client_id"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+}
+
+// loopbackTokenResponse is the response body of the exchange of an authorization code for a token.
+type loopbackTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// loopbackCallback is the outcome of the redirect to the loopback listener.
+type loopbackCallback struct {
+	code string
+	err  error
+}
+
+// loopbackAuthFlow runs the loopback flow, the OAuth2 authorization code flow with PKCE: it
+// listens on 127.0.0.1 for the redirect of the browser from the login page of the portal, for
+// up to the given timeout, and exchanges the authorization code of the redirect for a token.
+// The state of the redirect, and the nonce of the token, must be the ones the flow was started
+// with.
+//
+// The flow is not part of doAuthFlow, nor of the auth options: the endpoints it relies on (the
+// /oauth/authorize page of the portal and the /oauth/token endpoint of the api), its client id
+// and the nonce claim of the tokens are not part of the Border0 API yet.
+func loopbackAuthFlow(ctx context.Context, api *APIClient, config *auth.Config, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errLoopbackTimedOut)
+	defer cancel()
+
+	verifier, err := randomURLSafeString()
+	if err != nil {
+		return "", err
+	}
+	state, err := randomURLSafeString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomURLSafeString()
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for the login redirect: %v", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr())
+	callbacks := make(chan loopbackCallback, 1)
+	srv := &http.Server{
+		Handler:           loopbackCallbackHandler(state, callbacks),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", loopbackClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	loginURL := fmt.Sprintf("%s/oauth/authorize?%s", api.portalBaseURL, query.Encode())
+
+	prompt := config.GetPrompt()
+	if err := prompt(ctx, auth.DeviceAuthorization{URL: loginURL}); err != nil {
+		return "", fmt.Errorf("failed to prompt for the login: %w", err)
+	}
+	if config.ShouldTryOpeningBrowser() {
+		openBrowser(loginURL)
+	}
+
+	var callback loopbackCallback
+	select {
+	case callback = <-callbacks:
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+	if callback.err != nil {
+		return "", callback.err
+	}
+
+	var out loopbackTokenResponse
+	in := &loopbackTokenRequest{
+		GrantType:    "authorization_code",
+		ClientID:     loopbackClientID,
+		Code:         callback.code,
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+	}
+	if _, err := api.request(unauthenticated(ctx), http.MethodPost, "/oauth/token", in, &out); err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code for a Border0 token: %w", err)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(out.Token, claims); err != nil {
+		return "", fmt.Errorf("failed to decode Border0 token: %v", err)
+	}
+	if claims["nonce"] != nonce {
+		return "", errors.New("the nonce of the Border0 token does not match the nonce of the login")
+	}
+
+	api.logger.InfoContext(ctx, "Login successful")
+	return out.Token, nil
+}
+
+// loopbackCallbackHandler handles the redirect of the browser from the login page, and sends
+// its outcome on the given channel. Only the first redirect with the given state is handled,
+// requests with another state (e.g. from other local processes, or stale browser tabs) are
+// rejected without affecting the flow.
+func loopbackCallbackHandler(state string, callbacks chan<- loopbackCallback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "Invalid login state, please try logging in again.", http.StatusBadRequest)
+			return
+		}
+		var callback loopbackCallback
+		switch {
+		case query.Get("error") != "":
+			http.Error(w, "Login failed, you can close this window.", http.StatusForbidden)
+			callback.err = fmt.Errorf("login failed: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			http.Error(w, "Invalid login redirect, please try logging in again.", http.StatusBadRequest)
+			callback.err = errors.New("the login redirect has no authorization code")
+		default:
+			w.Write([]byte("Login successful, you can close this window."))
+			callback.code = query.Get("code")
+		}
+		select {
+		case callbacks <- callback:
+		default: // the flow already got its outcome
+		}
+	})
+	return mux
+}
+
+// randomURLSafeString returns a random string of 43 url-safe characters, which is suitable
+// for PKCE code verifiers, OAuth2 states and nonces.
+func randomURLSafeString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
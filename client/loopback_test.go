@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorizationServer is a stand-in for the authorization server of the Border0 portal and
+// api: it issues authorization codes for the logins of the browser, and exchanges them for
+// tokens with the nonce of the login.
+type authorizationServer struct {
+	mu     sync.Mutex
+	logins map[string]url.Values // query of the login of each code issued
+	nonce  string                // nonce of the tokens issued instead of the nonce of the login, if set
+}
+
+// login handles the login page of the given url like a browser would, and returns the query
+// of the redirect to the loopback listener, which is left to the caller to send.
+func (s *authorizationServer) login(t *testing.T, loginURL string) (string, url.Values) {
+	t.Helper()
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/oauth/authorize", u.Path)
+	login := u.Query()
+	assert.Equal(t, "code", login.Get("response_type"))
+	assert.Equal(t, "S256", login.Get("code_challenge_method"))
+	assert.True(t, strings.HasPrefix(login.Get("redirect_uri"), "http://127.0.0.1:"), "the listener is on the loopback interface")
+
+	code := "code-" + login.Get("state")[:8]
+	s.mu.Lock()
+	s.logins[code] = login
+	s.mu.Unlock()
+	return login.Get("redirect_uri"), url.Values{"code": {code}, "state": {login.Get("state")}}
+}
+
+func (s *authorizationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in loopbackTokenRequest
+	if r.URL.Path != "/oauth/token" || json.NewDecoder(r.Body).Decode(&in) != nil {
+		http.Error(w, `{"status_code":400,"error_message":"bad request"}`, http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	login, ok := s.logins[in.Code]
+	delete(s.logins, in.Code)
+	s.mu.Unlock()
+	challenge := sha256.Sum256([]byte(in.CodeVerifier))
+	if !ok || login.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) || login.Get("redirect_uri") != in.RedirectURI {
+		http.Error(w, `{"status_code":400,"error_message":"invalid grant"}`, http.StatusBadRequest)
+		return
+	}
+	nonce := login.Get("nonce")
+	if s.nonce != "" {
+		nonce = s.nonce
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"nonce": nonce}).SignedString([]byte("test-key"))
+	json.NewEncoder(w).Encode(loopbackTokenResponse{Token: token})
+}
+
+func Test_loopbackAuthFlow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		nonce    string
+		redirect func(query url.Values) // changes the query of the redirect, nil for no redirect
+		forged   bool                   // whether a redirect with another state is sent before the redirect
+		wantErr  string
+	}{
+		{
+			name:     "logged in",
+			redirect: func(url.Values) {},
+		},
+		{
+			name:     "redirect with another state is ignored",
+			forged:   true,
+			redirect: func(url.Values) {},
+		},
+		{
+			name: "login denied",
+			redirect: func(query url.Values) {
+				query.Del("code")
+				query.Set("error", "access_denied")
+				query.Set("error_description", "user canceled")
+			},
+			wantErr: "login failed: access_denied user canceled",
+		},
+		{
+			name:     "authorization code rejected",
+			redirect: func(query url.Values) { query.Set("code", "stolen-code") },
+			wantErr:  "failed to exchange authorization code for a Border0 token: failed after 1 attempt: 400: invalid grant (POST /oauth/token)",
+		},
+		{
+			name:     "nonce mismatch",
+			nonce:    "replayed",
+			redirect: func(url.Values) {},
+			wantErr:  "the nonce of the Border0 token does not match the nonce of the login",
+		},
+		{
+			name:    "timed out",
+			wantErr: "timed out waiting for the login in the browser",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			authServer := &authorizationServer{logins: map[string]url.Values{}, nonce: test.nonce}
+			ts := httptest.NewServer(authServer)
+			defer ts.Close()
+
+			api := New(WithBaseURL(ts.URL), WithStoredToken(false), WithRetryMax(0))
+			api.portalBaseURL = "https://portal.example.com"
+			browser := func(_ context.Context, authorization auth.DeviceAuthorization) error {
+				assert.True(t, strings.HasPrefix(authorization.URL, "https://portal.example.com/oauth/authorize?"))
+				assert.Empty(t, authorization.Code)
+				redirectURI, query := authServer.login(t, authorization.URL)
+				if test.redirect == nil {
+					return nil
+				}
+				test.redirect(query)
+				if test.forged {
+					forged := url.Values{"code": {"forged-code"}, "state": {"forged"}}
+					resp, err := http.Get(redirectURI + "?" + forged.Encode())
+					require.NoError(t, err)
+					resp.Body.Close()
+					assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				}
+				go func() {
+					// the listener may be closed before the response is read, once the flow has its outcome
+					if resp, err := http.Get(redirectURI + "?" + query.Encode()); err == nil {
+						resp.Body.Close()
+					}
+				}()
+				return nil
+			}
+			config, err := auth.GetConfig(auth.WithOpenBrowser(false), auth.WithPrompt(browser), auth.WithTokenWriting(false))
+			require.NoError(t, err)
+
+			token, err := loopbackAuthFlow(unauthenticated(ctx), api, config, 100*time.Millisecond)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+		})
+	}
+}
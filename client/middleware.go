@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RequestFunc is the function signature of HTTPRequester.Request. It is the
+// unit that request middlewares wrap.
+type RequestFunc func(ctx context.Context, method, path string, input, output any) (int, error)
+
+// Middleware wraps a RequestFunc with additional behavior. Middlewares can
+// inspect or modify the request arguments, the returned status code and the
+// returned error, and can short-circuit the request entirely by not calling
+// next.
+type Middleware func(next RequestFunc) RequestFunc
+
+// BeforeRequestHook is called right before a request is sent to the API server.
+type BeforeRequestHook func(ctx context.Context, method, path string)
+
+// AfterRequestHook is called right after a response (or an error) is received
+// from the API server, along with the time it took to get it.
+type AfterRequestHook func(ctx context.Context, method, path string, code int, latency time.Duration, err error)
+
+// Hooks returns a Middleware that calls the given before and after hooks around
+// every request. Either hook can be nil.
+func Hooks(before BeforeRequestHook, after AfterRequestHook) Middleware {
+	return func(next RequestFunc) RequestFunc {
+		return func(ctx context.Context, method, path string, input, output any) (int, error) {
+			if before != nil {
+				before(ctx, method, path)
+			}
+			start := time.Now()
+			code, err := next(ctx, method, path, input, output)
+			if after != nil {
+				after(ctx, method, path, code, time.Since(start), err)
+			}
+			return code, err
+		}
+	}
+}
+
+// chain wraps the given RequestFunc with the given middlewares. The first
+// middleware is the outermost one, so it sees the request first and the
+// response last.
+func chain(fn RequestFunc, middlewares ...Middleware) RequestFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// idleClosingTransport counts the calls to CloseIdleConnections.
+type idleClosingTransport struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *idleClosingTransport) CloseIdleConnections() { t.closed++ }
+
+type headerInjectingTransport struct {
+	header string
+	value  string
+}
+
+func (t *headerInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, t.value)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_chain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next RequestFunc) RequestFunc {
+			return func(ctx context.Context, method, path string, input, output any) (int, error) {
+				calls = append(calls, name+":before")
+				code, err := next(ctx, method, path, input, output)
+				calls = append(calls, name+":after")
+				return code, err
+			}
+		}
+	}
+	final := func(ctx context.Context, method, path string, input, output any) (int, error) {
+		calls = append(calls, "request")
+		return http.StatusOK, nil
+	}
+
+	code, err := chain(final, record("first"), record("second"))(context.Background(), http.MethodGet, "/test", nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"first:before", "second:before", "request", "second:after", "first:after"}, calls)
+}
+
+func Test_Hooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		beforeCalled bool
+		gotMethod    string
+		gotPath      string
+		gotCode      int
+		gotLatency   time.Duration
+	)
+	mw := Hooks(
+		func(_ context.Context, method, path string) {
+			beforeCalled = true
+		},
+		func(_ context.Context, method, path string, code int, latency time.Duration, err error) {
+			gotMethod, gotPath, gotCode, gotLatency = method, path, code, latency
+		},
+	)
+	final := func(ctx context.Context, method, path string, input, output any) (int, error) {
+		time.Sleep(time.Millisecond)
+		return http.StatusCreated, nil
+	}
+
+	_, err := mw(final)(context.Background(), http.MethodPost, "/socket", nil, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, beforeCalled)
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/socket", gotPath)
+	assert.Equal(t, http.StatusCreated, gotCode)
+	assert.GreaterOrEqual(t, gotLatency, time.Millisecond)
+}
+
+func Test_APIClient_transportAndMiddlewareSurviveTokenSwap(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "injected", r.Header.Get("X-Test-Header"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var requests int
+	api := New(
+		WithBaseURL(ts.URL),
+		WithTransport(&headerInjectingTransport{header: "X-Test-Header", value: "injected"}),
+		WithMiddleware(Hooks(func(context.Context, string, string) { requests++ }, nil)),
+	)
+
+	_, err := api.request(context.Background(), http.MethodGet, "/test", nil, nil)
+	assert.NoError(t, err)
+
+	// simulate the token swap done by Authenticate
+	api.tokens.set(StaticTokenSource("new-token"))
+
+	_, err = api.request(context.Background(), http.MethodGet, "/test", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func Test_WithHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	custom := &http.Client{Timeout: time.Minute}
+	api := New(WithHTTPClient(custom), WithTimeout(time.Second))
+
+	assert.Same(t, custom, api.httpClient)
+	assert.Same(t, custom, api.http.(*HTTPClient).client)
+}
+
+func Test_HTTPClient_Close(t *testing.T) {
+	t.Parallel()
+
+	owned := &idleClosingTransport{RoundTripper: http.DefaultTransport}
+	New(WithTransport(owned)).http.Close()
+	assert.Equal(t, 1, owned.closed, "idle connections of the client built by the sdk are closed")
+
+	given := &idleClosingTransport{RoundTripper: http.DefaultTransport}
+	New(WithHTTPClient(&http.Client{Transport: given})).http.Close()
+	assert.Equal(t, 0, given.closed, "idle connections of the caller's client are left alone")
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// CallObserver is notified about every logical API call made by the APIClient. A logical
+// call is a single invocation of an API client method, which may result in multiple HTTP
+// requests (attempts) being sent when retries kick in.
+type CallObserver interface {
+	// CallStarted is called before the first attempt of a call. The returned context is
+	// used for all the attempts of the call, and the returned tracker is notified about
+	// every attempt and about the end of the call.
+	CallStarted(ctx context.Context, method, path string) (context.Context, CallTracker)
+}
+
+// CallTracker tracks a single logical API call.
+type CallTracker interface {
+	// AttemptFinished is called after every attempt of the call, including the last one.
+	AttemptFinished(ctx context.Context, attempt Attempt)
+	// CallFinished is called once the call is done, with its final status code and error.
+	CallFinished(ctx context.Context, code int, err error)
+}
+
+// Attempt describes a single attempt (HTTP request) of a logical API call.
+type Attempt struct {
+	Number     int            // attempt number, starting at 1
+	StatusCode int            // response status code, zero if no response was received
+	Err        error          // error returned by the attempt, if any
+	Latency    time.Duration  // time it took to get the response
+	RetryAfter *time.Duration // server-specified Retry-After value, if any
+	Wait       time.Duration  // time waited before the next attempt, zero if there was none
+}
+
+// callTrackers fans out notifications to multiple call trackers.
+type callTrackers []CallTracker
+
+func (api *APIClient) observeCall(ctx context.Context, method, path string) (context.Context, callTrackers) {
+	if len(api.observers) == 0 {
+		return ctx, nil
+	}
+	trackers := make(callTrackers, 0, len(api.observers))
+	for _, observer := range api.observers {
+		var tracker CallTracker
+		ctx, tracker = observer.CallStarted(ctx, method, path)
+		trackers = append(trackers, tracker)
+	}
+	return ctx, trackers
+}
+
+func (t callTrackers) AttemptFinished(ctx context.Context, attempt Attempt) {
+	for _, tracker := range t {
+		tracker.AttemptFinished(ctx, attempt)
+	}
+}
+
+func (t callTrackers) CallFinished(ctx context.Context, code int, err error) {
+	for _, tracker := range t {
+		tracker.CallFinished(ctx, code, err)
+	}
+}
+
+// retryAfterFrom returns the Retry-After value carried by an API error, if any.
+func retryAfterFrom(err error) *time.Duration {
+	var apiErr Error
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return nil
+}
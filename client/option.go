@@ -1,7 +1,12 @@
 package client
 
 import (
+	"fmt"
+	"log/slog"
+	"net/http"
 	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
 )
 
 // Option is a function that can be passed to NewAPIClient to configure it.
@@ -14,17 +19,135 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithAuthToken sets the auth token for Border0 api calls.
+// WithHTTPClient sets the underlying http client used for Border0 api calls. When set,
+// the timeout from WithTimeout and the transport from WithTransport are not applied,
+// the given client is used as is, and its idle connections are never closed by the client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(api *APIClient) {
+		api.httpClient = client
+	}
+}
+
+// WithTransport sets the http.RoundTripper of the underlying http client. This can be
+// used to route requests through a corporate proxy, to sign requests or to inject
+// headers.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(api *APIClient) {
+		api.transport = transport
+	}
+}
+
+// WithMiddleware appends middlewares to the chain that wraps every request sent to
+// the Border0 API. Middlewares are called in the order they are given, the first
+// one being the outermost.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(api *APIClient) {
+		api.middlewares = append(api.middlewares, middlewares...)
+	}
+}
+
+// WithHTTPRequesterWrapper sets a function which wraps the HTTPRequester the client sends
+// requests to the Border0 API with, e.g. to record and replay api interactions in tests.
+// The wrapper may also replace the HTTPRequester altogether.
+func WithHTTPRequesterWrapper(wrap func(HTTPRequester) HTTPRequester) Option {
+	return func(api *APIClient) {
+		api.wrapHTTP = wrap
+	}
+}
+
+// WithAuthToken sets the auth token for Border0 api calls. It takes precedence over the
+// token of a profile set before it (see WithProfile).
 func WithAuthToken(token string) Option {
 	return func(api *APIClient) {
-		api.authToken = token
+		api.authToken, api.profileTokens, api.explicitToken = token, nil, true
+	}
+}
+
+// WithTokenSource sets the source of the tokens used for Border0 api calls. The client
+// asks the token source for a token on every request, caching tokens and refreshing them
+// shortly before they expire, and forcing a refresh when the api rejects a token. A token
+// source takes precedence over a static token set with WithAuthToken or BORDER0_AUTH_TOKEN.
+func WithTokenSource(tokenSource TokenSource) Option {
+	return func(api *APIClient) {
+		api.tokenSource, api.profileTokens, api.explicitToken = tokenSource, nil, true
+	}
+}
+
+// WithStoredToken toggles whether the client uses the token stored by Authenticate when no
+// token is set (with WithAuthToken, WithTokenSource and the like, or the BORDER0_AUTH_TOKEN env
+// var). The stored token is the token in the token store set with the auth.WithTokenStore option
+// of WithReauthentication if any, the token of the client's profile (see WithProfile) if it has
+// one, the token in the token storage file (~/.border0/token by default) otherwise. It's enabled
+// by default. See WithReauthentication to renew expired stored tokens. The plaintext token of
+// profiles written by older versions is used all the same when disabled.
+func WithStoredToken(enabled bool) Option {
+	return func(api *APIClient) {
+		api.storedToken = enabled
+	}
+}
+
+// WithReauthentication makes the client run the device authorization flow, the same way
+// Authenticate does with the given options, when the stored token (see WithStoredToken) has
+// expired, and store the new token in its place. Expired stored tokens are used as they are
+// otherwise, and rejected by the api.
+func WithReauthentication(opts ...auth.Option) Option {
+	return func(api *APIClient) {
+		api.reauth, api.reauthOpts = true, opts
+	}
+}
+
+// WithWebIdentity sets the client up to obtain its tokens by exchanging web identity tokens,
+// from the given web identity token source, for tokens of the given service account. The
+// web identity token source is typically a provider from the webidentity package (e.g. for
+// GitHub Actions or Kubernetes). Tokens are exchanged again shortly before they expire.
+func WithWebIdentity(organizationSubdomain, serviceAccountName string, webIdentityToken TokenSource) Option {
+	return func(api *APIClient) {
+		api.tokenSource, api.profileTokens, api.explicitToken = WebIdentityTokenSource(api, organizationSubdomain, serviceAccountName, webIdentityToken), nil, true
+	}
+}
+
+// WithDeviceFlow sets the client up to obtain its tokens by running the device authorization
+// flow (the same way Authenticate does) whenever a new token is needed.
+func WithDeviceFlow(opts ...auth.Option) Option {
+	return func(api *APIClient) {
+		api.tokenSource, api.profileTokens, api.explicitToken = DeviceFlowTokenSource(api, opts...), nil, true
 	}
 }
 
 // WithBaseURL sets the base url for Border0 api calls.
 func WithBaseURL(url string) Option {
 	return func(api *APIClient) {
-		api.baseURL = url
+		api.baseURL, api.explicitURL = url, true
+	}
+}
+
+// WithProfile sets the client up with the settings of the named profile of the shared Border0
+// config file (see DefaultConfigFilePath and Profile), and makes Authenticate write tokens into
+// that profile. The profile's settings take precedence over the BORDER0_AUTH_TOKEN,
+// BORDER0_BASE_URL and BORDER0_PORTAL_BASE_URL env vars, and options given after WithProfile
+// take precedence over the profile's settings. If not set, the profile named by the
+// BORDER0_PROFILE env var is used, if any. If the profile can't be loaded, all api calls fail,
+// unless it's the profile of the env var and the token and base url are set with options.
+func WithProfile(name string) Option {
+	return WithProfileFromFile("", name)
+}
+
+// WithProfileFromFile is like WithProfile, with the shared Border0 config file at the given path.
+func WithProfileFromFile(path, name string) Option {
+	return func(api *APIClient) {
+		api.profile, api.configFile, api.profileErr, api.envProfile = name, path, nil, false
+		if path == "" {
+			if api.configFile, api.profileErr = DefaultConfigFilePath(); api.profileErr != nil {
+				return
+			}
+		}
+		profile, err := LoadProfile(api.configFile, name)
+		if err == nil {
+			err = profile.apply(api)
+		}
+		if err != nil {
+			api.profileErr = fmt.Errorf("failed to load Border0 profile [%s]: %w", name, err)
+		}
 	}
 }
 
@@ -49,6 +172,16 @@ func WithRetryMax(attempts int) Option {
 	}
 }
 
+// WithRetryPolicy sets the policy that decides whether failed api calls are retried, and
+// how long to wait between retries. When set, the WithRetryWaitMin, WithRetryWaitMax,
+// WithRetryMax and WithBackoff options have no effect. See DefaultRetryPolicy for the
+// policy used by default.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(api *APIClient) {
+		api.retryPolicy = policy
+	}
+}
+
 // WithBackoff sets the backoff function that's used to calculate the wait time
 // between retries of failed api calls.
 func WithBackoff(fn Backoff) Option {
@@ -56,3 +189,84 @@ func WithBackoff(fn Backoff) Option {
 		api.backoff = fn
 	}
 }
+
+// WithCallObserver adds observers that get notified about every logical api call and
+// about every attempt made on behalf of it, including retries. See the otelclient
+// package for an OpenTelemetry based observer.
+func WithCallObserver(observers ...CallObserver) Option {
+	return func(api *APIClient) {
+		api.observers = append(api.observers, observers...)
+	}
+}
+
+// WithLogger sets the logger used for all messages logged by the api client, e.g. retries
+// and the outcome of the device authorization flow. Secrets such as tokens and passwords are always
+// redacted before reaching the logger's handler. If not set, slog.Default() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(api *APIClient) {
+		api.logger = logger
+	}
+}
+
+// WithReadAfterWriteConsistency makes reads observe previous writes made with the client.
+// The client fetches the api server's data consistency settings once, remembers when each
+// type of resource (sockets, policies, connectors, etc.) was last written to, and delays
+// reads of that type of resource, whether by id, by name or as lists, until the delay
+// advertised by the server has passed.
+func WithReadAfterWriteConsistency() Option {
+	return func(api *APIClient) {
+		api.consistent = true
+	}
+}
+
+// WithReadCache enables caching of reads of resources (sockets, policies, connectors, etc.)
+// for the given TTL. Expired responses are revalidated with a conditional request when the
+// api server returned an ETag for them, identical concurrent reads are coalesced into a
+// single request, and writes through the client invalidate the cached responses of the
+// resources they write to. See WithReadCacheTTL to set the TTL of a type of resource.
+func WithReadCache(ttl time.Duration) Option {
+	return func(api *APIClient) {
+		if api.cache == nil {
+			api.cache = newReadCache(ttl)
+		}
+		api.cache.ttl = ttl
+	}
+}
+
+// WithReadCacheTTL sets the TTL of cached reads of a type of resource, one of "socket",
+// "connector", "policy", "user", "group" and "service_account", and enables caching of
+// reads if it's not already enabled, with a default TTL of zero for the other types of
+// resources, which means their responses are always revalidated.
+func WithReadCacheTTL(resource string, ttl time.Duration) Option {
+	return func(api *APIClient) {
+		if api.cache == nil {
+			api.cache = newReadCache(0)
+		}
+		api.cache.ttls[resource] = ttl
+	}
+}
+
+// WithReadRateLimit limits the rate of read (GET) requests sent to the Border0 api, across
+// all goroutines using the client. Once a rate limit is set, a 429 response received by any
+// goroutine pauses requests of all goroutines for the time the api asks to back off.
+func WithReadRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(api *APIClient) {
+		if api.limiter == nil {
+			api.limiter = newRateLimiter()
+		}
+		api.limiter.read = newTokenBucket(requestsPerSecond, burst)
+	}
+}
+
+// WithWriteRateLimit limits the rate of write (POST, PUT, PATCH and DELETE) requests sent to
+// the Border0 api, across all goroutines using the client. Once a rate limit is set, a 429
+// response received by any goroutine pauses requests of all goroutines for the time the api
+// asks to back off.
+func WithWriteRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(api *APIClient) {
+		if api.limiter == nil {
+			api.limiter = newRateLimiter()
+		}
+		api.limiter.write = newTokenBucket(requestsPerSecond, burst)
+	}
+}
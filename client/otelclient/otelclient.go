@@ -0,0 +1,176 @@
+// Package otelclient provides OpenTelemetry instrumentation for the Border0 API client.
+//
+// Every logical API call (a single invocation of an API client method) is recorded as
+// a span, and every attempt made on behalf of the call, including retries, is recorded
+// as an event of that span. Counters and histograms for calls, retries and latencies
+// are exported through the configured meter provider.
+//
+// Example:
+//
+//	api := client.New(
+//		client.WithCallObserver(otelclient.New()), // uses the global tracer and meter providers
+//	)
+package otelclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/borderzero/border0-go/client/otelclient"
+
+// attribute keys, following the OpenTelemetry semantic conventions where applicable
+const (
+	attrMethod     = attribute.Key("http.request.method")
+	attrPath       = attribute.Key("url.path")
+	attrStatusCode = attribute.Key("http.response.status_code")
+	attrAttempt    = attribute.Key("border0.attempt")
+	attrAttempts   = attribute.Key("border0.attempts")
+	attrWait       = attribute.Key("border0.retry.wait_ms")
+	attrRetryAfter = attribute.Key("border0.retry.retry_after_ms")
+	attrLatency    = attribute.Key("border0.attempt.latency_ms")
+	attrError      = attribute.Key("error.message")
+)
+
+// Option configures the OpenTelemetry observer.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider used to create spans. If not set,
+// the global tracer provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider sets the meter provider used to create instruments. If not set,
+// the global meter provider is used.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// Observer is a client.CallObserver that records traces and metrics with OpenTelemetry.
+type Observer struct {
+	tracer trace.Tracer
+
+	calls           metric.Int64Counter
+	retries         metric.Int64Counter
+	callDuration    metric.Float64Histogram
+	attemptDuration metric.Float64Histogram
+}
+
+// ensure Observer implements client.CallObserver at compile-time.
+var _ client.CallObserver = (*Observer)(nil)
+
+// New returns a new OpenTelemetry observer, to be passed to client.WithCallObserver.
+func New(opts ...Option) *Observer {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	meter := c.meterProvider.Meter(instrumentationName)
+	o := &Observer{tracer: c.tracerProvider.Tracer(instrumentationName)}
+
+	// instrument creation only fails on invalid names, which are constant here,
+	// and a no-op instrument is returned alongside the error regardless.
+	o.calls, _ = meter.Int64Counter(
+		"border0.client.calls",
+		metric.WithDescription("Number of logical Border0 API calls."),
+		metric.WithUnit("{call}"),
+	)
+	o.retries, _ = meter.Int64Counter(
+		"border0.client.retries",
+		metric.WithDescription("Number of retried Border0 API requests."),
+		metric.WithUnit("{retry}"),
+	)
+	o.callDuration, _ = meter.Float64Histogram(
+		"border0.client.call.duration",
+		metric.WithDescription("Duration of logical Border0 API calls, including retries and waits."),
+		metric.WithUnit("s"),
+	)
+	o.attemptDuration, _ = meter.Float64Histogram(
+		"border0.client.attempt.duration",
+		metric.WithDescription("Duration of individual Border0 API requests."),
+		metric.WithUnit("s"),
+	)
+	return o
+}
+
+// CallStarted starts a span for a logical API call.
+func (o *Observer) CallStarted(ctx context.Context, method, path string) (context.Context, client.CallTracker) {
+	path, _, _ = strings.Cut(path, "?") // query strings may contain names and filters
+	ctx, span := o.tracer.Start(
+		ctx,
+		fmt.Sprintf("Border0 %s", method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrMethod.String(method), attrPath.String(path)),
+	)
+	return ctx, &tracker{observer: o, span: span, method: method, start: time.Now()}
+}
+
+// tracker is the client.CallTracker for a single logical API call.
+type tracker struct {
+	observer *Observer
+	span     trace.Span
+	method   string
+	start    time.Time
+	attempts int
+}
+
+// AttemptFinished records an attempt as a span event.
+func (t *tracker) AttemptFinished(ctx context.Context, attempt client.Attempt) {
+	t.attempts = attempt.Number
+
+	attrs := []attribute.KeyValue{
+		attrAttempt.Int(attempt.Number),
+		attrStatusCode.Int(attempt.StatusCode),
+		attrLatency.Int64(attempt.Latency.Milliseconds()),
+		attrWait.Int64(attempt.Wait.Milliseconds()),
+	}
+	if attempt.RetryAfter != nil {
+		attrs = append(attrs, attrRetryAfter.Int64(attempt.RetryAfter.Milliseconds()))
+	}
+	if attempt.Err != nil {
+		attrs = append(attrs, attrError.String(attempt.Err.Error()))
+	}
+	t.span.AddEvent("attempt", trace.WithAttributes(attrs...))
+
+	set := metric.WithAttributes(attrMethod.String(t.method), attrStatusCode.Int(attempt.StatusCode))
+	t.observer.attemptDuration.Record(ctx, attempt.Latency.Seconds(), set)
+	if attempt.Number > 1 {
+		t.observer.retries.Add(ctx, 1, set)
+	}
+}
+
+// CallFinished ends the span of the call and records the call metrics.
+func (t *tracker) CallFinished(ctx context.Context, code int, err error) {
+	t.span.SetAttributes(attrStatusCode.Int(code), attrAttempts.Int(t.attempts))
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	} else if code >= http.StatusBadRequest {
+		t.span.SetStatus(codes.Error, http.StatusText(code))
+	}
+	t.span.End()
+
+	set := metric.WithAttributes(attrMethod.String(t.method), attrStatusCode.Int(code))
+	t.observer.calls.Add(ctx, 1, set)
+	t.observer.callDuration.Record(ctx, time.Since(t.start).Seconds(), set)
+}
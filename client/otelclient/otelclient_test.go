@@ -0,0 +1,134 @@
+package otelclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Observer(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name":"test-socket","socket_id":"test-id"}`))
+		}
+	}))
+	defer ts.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	api := client.New(
+		client.WithBaseURL(ts.URL),
+		client.WithBackoff(func(_, _ time.Duration, _ int) time.Duration { return time.Millisecond }),
+		client.WithCallObserver(New(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		)),
+	)
+
+	socket, err := api.Socket(context.Background(), "test-socket")
+	require.NoError(t, err)
+	assert.Equal(t, "test-id", socket.SocketID)
+
+	// traces
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "Border0 GET", span.Name())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attrPath.String("/socket/test-socket"))
+	assert.Contains(t, span.Attributes(), attrStatusCode.Int(http.StatusOK))
+	assert.Contains(t, span.Attributes(), attrAttempts.Int(3))
+
+	events := span.Events()
+	require.Len(t, events, 3)
+	assert.Contains(t, events[0].Attributes, attrStatusCode.Int(http.StatusTooManyRequests))
+	assert.Contains(t, events[0].Attributes, attrRetryAfter.Int64(2000))
+	assert.Contains(t, events[1].Attributes, attrStatusCode.Int(http.StatusInternalServerError))
+	assert.Contains(t, events[1].Attributes, attrWait.Int64(1))
+	assert.Contains(t, events[2].Attributes, attrStatusCode.Int(http.StatusOK))
+	assert.Contains(t, events[2].Attributes, attrWait.Int64(0))
+
+	// metrics
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	got := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	calls := got["border0.client.calls"].(metricdata.Sum[int64])
+	require.Len(t, calls.DataPoints, 1)
+	assert.Equal(t, int64(1), calls.DataPoints[0].Value)
+	code, _ := calls.DataPoints[0].Attributes.Value(attrStatusCode)
+	assert.Equal(t, attribute.IntValue(http.StatusOK), code)
+
+	var retries int64
+	for _, dp := range got["border0.client.retries"].(metricdata.Sum[int64]).DataPoints {
+		retries += dp.Value
+	}
+	assert.Equal(t, int64(2), retries)
+
+	callDuration := got["border0.client.call.duration"].(metricdata.Histogram[float64])
+	require.Len(t, callDuration.DataPoints, 1)
+	assert.Equal(t, uint64(1), callDuration.DataPoints[0].Count)
+
+	var attempts uint64
+	for _, dp := range got["border0.client.attempt.duration"].(metricdata.Histogram[float64]).DataPoints {
+		attempts += dp.Count
+	}
+	assert.Equal(t, uint64(3), attempts)
+}
+
+func Test_Observer_failedCall(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error_message":"bad request"}`))
+	}))
+	defer ts.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	api := client.New(
+		client.WithBaseURL(ts.URL),
+		client.WithCallObserver(New(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider()),
+		)),
+	)
+
+	_, err := api.CreateSocket(context.Background(), &client.Socket{Name: "test-socket"})
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "Border0 POST", ended[0].Name())
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	assert.Len(t, ended[0].Events(), 2) // the attempt and the recorded error
+}
@@ -3,6 +3,9 @@ package client
 import (
 	"context"
 	"iter"
+	"maps"
+	"net/url"
+	"sync"
 )
 
 const defaultPageSize = 100
@@ -26,16 +29,29 @@ type pagination struct {
 }
 
 // fetchPageFunc defines a function that fetches a page of items.
-// It should return the items, the next page number (0 if no more), and an error.
-type fetchPageFunc[T any] func(ctx context.Context, api *APIClient, page, size int) ([]T, int, error)
+// It should return the items, the pagination metadata of the page (with the next
+// page number, 0 if no more, and the total number of pages, 0 if unknown), and an error.
+type fetchPageFunc[T any] func(ctx context.Context, api *APIClient, page, size int) ([]T, pagination, error)
 
 // Paginator provides sequential access to paginated API resources.
 type Paginator[T any] struct {
-	api      *APIClient
-	pageSize int
-	nextPage int
-	done     bool
-	fetch    fetchPageFunc[T]
+	api         *APIClient
+	pageSize    int
+	nextPage    int
+	totalPages  int
+	done        bool
+	fetch       fetchPageFunc[T]
+	concurrency int
+	filters     url.Values
+}
+
+// Checkpoint is the progress of a paginator. It can be serialized (e.g. into JSON) and
+// persisted, to resume paginating from where it was left off later, e.g. after a restart.
+type Checkpoint struct {
+	NextPage int        `json:"next_page"`
+	PageSize int        `json:"page_size"`
+	Filters  url.Values `json:"filters,omitempty"`
+	Done     bool       `json:"done,omitempty"`
 }
 
 // PageResult represents the result of fetching a single
@@ -53,12 +69,94 @@ func newPaginator[T any](api *APIClient, fetch fetchPageFunc[T], pageSize int) *
 		pageSize = defaultPageSize
 	}
 	return &Paginator[T]{
-		api:      api,
-		pageSize: pageSize,
-		nextPage: 1,
-		done:     false,
-		fetch:    fetch,
+		api:         api,
+		pageSize:    pageSize,
+		nextPage:    1,
+		done:        false,
+		fetch:       fetch,
+		concurrency: 1,
+	}
+}
+
+// newListPaginator creates a Paginator over the items of an endpoint which the Border0 API
+// does not paginate. All the items are fetched with the first page, and paged through locally.
+func newListPaginator[T any](api *APIClient, list func(ctx context.Context, api *APIClient) ([]T, error), pageSize int) *Paginator[T] {
+	var items []T
+	fetch := func(ctx context.Context, api *APIClient, page, size int) ([]T, pagination, error) {
+		if page == 1 {
+			all, err := list(ctx, api)
+			if err != nil {
+				return nil, pagination{}, err
+			}
+			items = all
+		}
+		start := min((page-1)*size, len(items))
+		end := min(start+size, len(items))
+		pages := pagination{CurrentPage: page}
+		if end < len(items) {
+			pages.NextPage = page + 1
+		}
+		return items[start:end], pages, nil
 	}
+	return newPaginator(api, fetch, pageSize)
+}
+
+// NewPaginator returns a paginator which fetches pages with the given function. The function
+// must return the items of the requested page, and the number of the next page (0 if no more).
+// It's meant for implementations of the client interfaces other than APIClient, like fakes.
+// The total number of pages is unknown to such paginators, so they always fetch pages one at
+// a time.
+func NewPaginator[T any](pageSize int, fetch func(ctx context.Context, page, size int) ([]T, int, error)) *Paginator[T] {
+	return newPaginator(nil, func(ctx context.Context, _ *APIClient, page, size int) ([]T, pagination, error) {
+		items, next, err := fetch(ctx, page, size)
+		return items, pagination{CurrentPage: page, NextPage: next}, err
+	}, pageSize)
+}
+
+// ResumePaginator returns a paginator which resumes from the given checkpoint, and fetches
+// pages with the given function, like NewPaginator. The filters of the checkpoint are kept
+// as they are, it's up to the function to apply them.
+func ResumePaginator[T any](checkpoint Checkpoint, fetch func(ctx context.Context, page, size int) ([]T, int, error)) *Paginator[T] {
+	return NewPaginator(checkpoint.PageSize, fetch).resume(checkpoint)
+}
+
+// resume sets the progress of the paginator to the given checkpoint, except for the page
+// size, which is set when the paginator is created.
+func (p *Paginator[T]) resume(checkpoint Checkpoint) *Paginator[T] {
+	p.nextPage = max(checkpoint.NextPage, 1)
+	p.totalPages = 0
+	p.done = checkpoint.Done
+	p.filters = checkpoint.Filters
+	return p
+}
+
+// Checkpoint returns the progress of the paginator: the next page to fetch, the page size,
+// and the filters of the paginated resources. Paginators are resumed from checkpoints with
+// the Resume...Paginator methods of the client (e.g. ResumeSocketsPaginator).
+func (p *Paginator[T]) Checkpoint() Checkpoint {
+	return Checkpoint{
+		NextPage: p.nextPage,
+		PageSize: p.pageSize,
+		Filters:  maps.Clone(p.filters),
+		Done:     p.done,
+	}
+}
+
+// Reset rewinds the paginator to the first page, so that all the pages are fetched again.
+func (p *Paginator[T]) Reset() {
+	p.nextPage = 1
+	p.totalPages = 0
+	p.done = false
+}
+
+// WithConcurrency makes the iterators of the paginator (Iter and Items) fetch up to the given
+// number of pages concurrently. Once the first page tells the total number of pages, the
+// remaining pages are prefetched in the background, and still yielded in order. Outstanding
+// fetches are canceled on the first error, or when the caller stops iterating. Next always
+// fetches one page at a time. Values lower than 2 disable prefetching, which is the default.
+func (p *Paginator[T]) WithConcurrency(concurrency int) *Paginator[T] {
+	p.concurrency = max(concurrency, 1)
+	return p
 }
 
 // HasNext reports whether more pages are available.
@@ -71,14 +169,17 @@ func (p *Paginator[T]) Next(ctx context.Context) ([]T, error) {
 		var empty []T
 		return empty, nil
 	}
-	items, next, err := p.fetch(ctx, p.api, p.nextPage, p.pageSize)
+	items, pages, err := p.fetch(ctx, p.api, p.nextPage, p.pageSize)
 	if err != nil {
 		return nil, err
 	}
-	if next <= 0 {
+	if pages.TotalPages > 0 {
+		p.totalPages = pages.TotalPages
+	}
+	if pages.NextPage <= 0 {
 		p.done = true
 	} else {
-		p.nextPage = next
+		p.nextPage = pages.NextPage
 	}
 	return items, nil
 }
@@ -88,9 +189,7 @@ func (p *Paginator[T]) Next(ctx context.Context) ([]T, error) {
 // error. If an error is encountered, the iterator is finished.
 func (p *Paginator[T]) Iter(ctx context.Context) iter.Seq[PageResult[T]] {
 	return func(yield func(PageResult[T]) bool) {
-		for p.HasNext() {
-			items, err := p.Next(ctx)
-
+		for items, err := range p.pages(ctx) {
 			// if there's an error, yield the error and stop
 			if err != nil {
 				yield(PageResult[T]{Items: nil, Err: err})
@@ -109,3 +208,146 @@ func (p *Paginator[T]) Iter(ctx context.Context) iter.Seq[PageResult[T]] {
 		}
 	}
 }
+
+// Items returns an iterator over the items of all the remaining pages, fetching pages as
+// needed. If fetching a page fails, the error is yielded along with the zero value of T,
+// and the iterator is finished.
+func (p *Paginator[T]) Items(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for items, err := range p.pages(ctx) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pages returns an iterator over the remaining pages, and the errors fetching them. Pages
+// are fetched one at a time, unless the paginator is concurrent and knows the total number
+// of pages, in which case they are prefetched.
+func (p *Paginator[T]) pages(ctx context.Context) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		for p.HasNext() {
+			if p.concurrency > 1 && p.nextPage <= p.totalPages {
+				p.prefetch(ctx, yield)
+				return
+			}
+			items, err := p.Next(ctx)
+			if !yield(items, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// pageFetch is the outcome of fetching a page in the background.
+type pageFetch[T any] struct {
+	items []T
+	err   error
+}
+
+// prefetch fetches the remaining pages, up to the total number of pages, with up to
+// p.concurrency fetches in flight or waiting to be yielded, and yields them in order.
+// Outstanding fetches are canceled, and waited for, before it returns.
+func (p *Paginator[T]) prefetch(ctx context.Context, yield func([]T, error) bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	first, last := p.nextPage, p.totalPages
+	fetches := make([]chan pageFetch[T], last-first+1)
+	for i := range fetches {
+		fetches[i] = make(chan pageFetch[T], 1)
+	}
+	slots := make(chan struct{}, p.concurrency)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range fetches {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(page int, out chan<- pageFetch[T]) {
+				defer wg.Done()
+				items, _, err := p.fetch(ctx, p.api, page, p.pageSize)
+				out <- pageFetch[T]{items: items, err: err}
+			}(first+i, fetches[i])
+		}
+	}()
+
+	for i, fetched := range fetches {
+		var result pageFetch[T]
+		select {
+		case result = <-fetched:
+		case <-ctx.Done():
+			yield(nil, ctx.Err())
+			return
+		}
+		<-slots
+
+		if result.err != nil {
+			yield(nil, result.err)
+			return
+		}
+		if page := first + i; page < last {
+			p.nextPage = page + 1
+		} else {
+			p.done = true
+		}
+		if !yield(result.items, nil) {
+			return
+		}
+	}
+}
+
+// Collect returns all the items of the given iterator, or the first error it yields.
+func Collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
+
+// First returns the first item of the given iterator, and whether there was one, or the
+// first error it yields. It stops the iterator right after, so no more pages are fetched.
+func First[T any](items iter.Seq2[T, error]) (T, bool, error) {
+	for item, err := range items {
+		if err != nil {
+			var zero T
+			return zero, false, err
+		}
+		return item, true, nil
+	}
+	var zero T
+	return zero, false, nil
+}
+
+// Filter returns an iterator over the items of the given iterator for which keep returns
+// true. Errors are always passed through.
+func Filter[T any](items iter.Seq2[T, error], keep func(T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item, err := range items {
+			if err != nil || keep(item) {
+				if !yield(item, err) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// numbersPaginator returns a paginator over the numbers 1 to count, which fails with the
+// given error when fetching the page failAt. It records the pages it fetches.
+func numbersPaginator(count, pageSize, failAt int, err error, fetched *[]int) *Paginator[int] {
+	return NewPaginator(pageSize, fetchNumbers(count, failAt, err, fetched))
+}
+
+// fetchNumbers returns a function fetching pages of the numbers paginator.
+func fetchNumbers(count, failAt int, err error, fetched *[]int) func(context.Context, int, int) ([]int, int, error) {
+	return func(_ context.Context, page, size int) ([]int, int, error) {
+		*fetched = append(*fetched, page)
+		if page == failAt {
+			return nil, 0, err
+		}
+		var items []int
+		for n := (page-1)*size + 1; n <= min(page*size, count); n++ {
+			items = append(items, n)
+		}
+		next := 0
+		if page*size < count {
+			next = page + 1
+		}
+		return items, next, nil
+	}
+}
+
+func Test_Paginator_Items(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed to fetch page")
+
+	tests := []struct {
+		name        string
+		count       int
+		failAt      int
+		stopAt      int
+		wantItems   []int
+		wantErr     error
+		wantFetched []int
+	}{
+		{
+			name:        "all items of all pages",
+			count:       5,
+			wantItems:   []int{1, 2, 3, 4, 5},
+			wantFetched: []int{1, 2, 3},
+		},
+		{
+			name:        "no items",
+			count:       0,
+			wantFetched: []int{1},
+		},
+		{
+			name:        "stops on error",
+			count:       5,
+			failAt:      2,
+			wantItems:   []int{1, 2},
+			wantErr:     errFailed,
+			wantFetched: []int{1, 2},
+		},
+		{
+			name:        "no more pages are fetched when the caller stops",
+			count:       5,
+			stopAt:      3,
+			wantItems:   []int{1, 2, 3},
+			wantFetched: []int{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			var fetched, items []int
+			var gotErr error
+			for item, err := range numbersPaginator(test.count, 2, test.failAt, errFailed, &fetched).Items(ctx) {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				items = append(items, item)
+				if item == test.stopAt {
+					break
+				}
+			}
+
+			assert.Equal(t, test.wantItems, items)
+			assert.Equal(t, test.wantErr, gotErr)
+			assert.Equal(t, test.wantFetched, fetched)
+		})
+	}
+}
+
+func Test_Collect(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var fetched []int
+	items, err := Collect(numbersPaginator(5, 2, 0, nil, &fetched).Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+
+	errFailed := errors.New("failed to fetch page")
+	items, err = Collect(numbersPaginator(5, 2, 3, errFailed, &fetched).Items(ctx))
+	assert.Equal(t, errFailed, err)
+	assert.Nil(t, items)
+}
+
+func Test_First(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var fetched []int
+	item, ok, err := First(numbersPaginator(5, 2, 0, nil, &fetched).Items(ctx))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, item)
+	assert.Equal(t, []int{1}, fetched, "only the first page is fetched")
+
+	_, ok, err = First(numbersPaginator(0, 2, 0, nil, &fetched).Items(ctx))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	errFailed := errors.New("failed to fetch page")
+	_, ok, err = First(numbersPaginator(5, 2, 1, errFailed, &fetched).Items(ctx))
+	assert.Equal(t, errFailed, err)
+	assert.False(t, ok)
+}
+
+func Test_Filter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	even := func(n int) bool { return n%2 == 0 }
+
+	var fetched []int
+	items, err := Collect(Filter(numbersPaginator(7, 3, 0, nil, &fetched).Items(ctx), even))
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 6}, items)
+
+	fetched = nil
+	item, ok, err := First(Filter(numbersPaginator(7, 3, 0, nil, &fetched).Items(ctx), func(n int) bool { return n > 4 }))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 5, item)
+	assert.Equal(t, []int{1, 2}, fetched)
+
+	errFailed := errors.New("failed to fetch page")
+	_, err = Collect(Filter(numbersPaginator(7, 3, 2, errFailed, &fetched).Items(ctx), even))
+	assert.Equal(t, errFailed, err, "errors are passed through")
+}
+
+func Test_newListPaginator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	calls := 0
+	list := func(context.Context, *APIClient) ([]string, error) {
+		calls++
+		return []string{"a", "b", "c", "d", "e"}, nil
+	}
+
+	var pages [][]string
+	for result := range newListPaginator(nil, list, 2).Iter(ctx) {
+		require.NoError(t, result.Err)
+		pages = append(pages, result.Items)
+	}
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages)
+	assert.Equal(t, 1, calls, "all items are fetched with the first page")
+
+	items, err := Collect(newListPaginator(nil, list, 0).Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, items)
+
+	errFailed := errors.New("failed to list")
+	_, err = newListPaginator(nil, func(context.Context, *APIClient) ([]string, error) { return nil, errFailed }, 2).Next(ctx)
+	assert.Equal(t, errFailed, err)
+}
+
+// pagesFetcher fetches pages of numbers for concurrent paginators, with the total number
+// of pages, taking longer for earlier pages so that they complete out of order. It fails
+// with errFailed when fetching the page failAt, and records the pages it fetches and the
+// maximum number of fetches in flight.
+type pagesFetcher struct {
+	count, failAt int
+	errFailed     error
+
+	inFlight, maxInFlight atomic.Int32
+	mu                    sync.Mutex
+	fetched               []int
+}
+
+func (f *pagesFetcher) fetch(ctx context.Context, _ *APIClient, page, size int) ([]int, pagination, error) {
+	inFlight := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		maxInFlight := f.maxInFlight.Load()
+		if inFlight <= maxInFlight || f.maxInFlight.CompareAndSwap(maxInFlight, inFlight) {
+			break
+		}
+	}
+	f.mu.Lock()
+	f.fetched = append(f.fetched, page)
+	f.mu.Unlock()
+
+	if page == f.failAt {
+		return nil, pagination{}, f.errFailed
+	}
+	select {
+	case <-time.After(time.Duration(10-page%10) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, pagination{}, ctx.Err()
+	}
+
+	totalPages := (f.count + size - 1) / size
+	pages := pagination{CurrentPage: page, TotalPages: totalPages}
+	if page < totalPages {
+		pages.NextPage = page + 1
+	}
+	var items []int
+	for n := (page-1)*size + 1; n <= min(page*size, f.count); n++ {
+		items = append(items, n)
+	}
+	return items, pages, nil
+}
+
+func Test_Paginator_WithConcurrency(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed to fetch page")
+
+	tests := []struct {
+		name            string
+		count           int
+		concurrency     int
+		failAt          int
+		stopAt          int
+		wantItems       int
+		wantErr         error
+		wantMaxInFlight int32
+	}{
+		{
+			name:            "pages are yielded in order",
+			count:           100,
+			concurrency:     4,
+			wantItems:       100,
+			wantMaxInFlight: 4,
+		},
+		{
+			name:            "pages are fetched one at a time without concurrency",
+			count:           30,
+			concurrency:     1,
+			wantItems:       30,
+			wantMaxInFlight: 1,
+		},
+		{
+			name:            "a single page",
+			count:           5,
+			concurrency:     4,
+			wantItems:       5,
+			wantMaxInFlight: 1,
+		},
+		{
+			name:            "stops on the first error",
+			count:           100,
+			concurrency:     4,
+			failAt:          4,
+			wantItems:       15,
+			wantErr:         errFailed,
+			wantMaxInFlight: 4,
+		},
+		{
+			name:            "stops when the caller stops",
+			count:           100,
+			concurrency:     4,
+			stopAt:          22,
+			wantItems:       22,
+			wantMaxInFlight: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			fetcher := &pagesFetcher{count: test.count, failAt: test.failAt, errFailed: errFailed}
+			paginator := newPaginator(nil, fetcher.fetch, 5).WithConcurrency(test.concurrency)
+
+			var items []int
+			var gotErr error
+			for item, err := range paginator.Items(ctx) {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				items = append(items, item)
+				if item == test.stopAt {
+					break
+				}
+			}
+
+			require.Len(t, items, test.wantItems)
+			for i, item := range items {
+				assert.Equal(t, i+1, item, "items are yielded in order")
+			}
+			assert.Equal(t, test.wantErr, gotErr)
+			assert.LessOrEqual(t, fetcher.maxInFlight.Load(), test.wantMaxInFlight)
+			assert.Zero(t, fetcher.inFlight.Load(), "no fetch is outstanding once the iteration is over")
+			assert.LessOrEqual(t, len(fetcher.fetched), len(items)/5+test.concurrency+1, "no more pages are fetched than needed")
+			if test.stopAt > 0 {
+				assert.Equal(t, test.stopAt/5+2, paginator.nextPage, "the paginator resumes after the last page yielded")
+				assert.True(t, paginator.HasNext())
+			}
+		})
+	}
+}
+
+func Test_Paginator_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var fetched []int
+	paginator := numbersPaginator(7, 3, 0, nil, &fetched)
+	assert.Equal(t, Checkpoint{NextPage: 1, PageSize: 3}, paginator.Checkpoint())
+
+	items, err := paginator.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+	checkpoint := paginator.Checkpoint()
+	assert.Equal(t, Checkpoint{NextPage: 2, PageSize: 3}, checkpoint)
+
+	fetched = nil
+	resumed, err := Collect(ResumePaginator(checkpoint, fetchNumbers(7, 0, nil, &fetched)).Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 6, 7}, resumed)
+	assert.Equal(t, []int{2, 3}, fetched, "pages before the checkpoint are not fetched again")
+
+	rest, err := Collect(paginator.Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 6, 7}, rest)
+	assert.Equal(t, Checkpoint{NextPage: 3, PageSize: 3, Done: true}, paginator.Checkpoint())
+	assert.False(t, ResumePaginator(paginator.Checkpoint(), fetchNumbers(7, 0, nil, &fetched)).HasNext())
+
+	paginator.Reset()
+	assert.Equal(t, Checkpoint{NextPage: 1, PageSize: 3}, paginator.Checkpoint())
+	all, err := Collect(paginator.Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, all)
+}
@@ -25,6 +25,7 @@ const (
 type PolicyService interface {
 	Policy(ctx context.Context, id string) (out *Policy, err error)
 	Policies(ctx context.Context) (out []Policy, err error)
+	PoliciesPaginator(ctx context.Context, pageSize int) *Paginator[Policy]
 	PoliciesByNames(ctx context.Context, names ...string) (out []Policy, err error)
 	CreatePolicy(ctx context.Context, in *Policy) (out *Policy, err error)
 	UpdatePolicy(ctx context.Context, id string, in *Policy) (out *Policy, err error)
@@ -57,6 +58,15 @@ func (api *APIClient) Policies(ctx context.Context) (out []Policy, err error) {
 	return out, nil
 }
 
+// PoliciesPaginator returns a paginator to iterate pages of policies. The Border0 API returns
+// all policies at once, so they are all fetched with the first page.
+func (api *APIClient) PoliciesPaginator(ctx context.Context, pageSize int) *Paginator[Policy] {
+	list := func(ctx context.Context, api *APIClient) ([]Policy, error) {
+		return api.Policies(ctx)
+	}
+	return newListPaginator(api, list, pageSize)
+}
+
 // PoliciesByNames finds policies in your Border0 organization by policy names. If any of the policies does not exist,
 // an error will be returned. When only one policy name is provided, this method will use the /policies/find endpoint,
 // otherwise it will fetch all policies and filter them by name.
@@ -11,6 +11,7 @@ import (
 	"github.com/borderzero/border0-go/lib/types/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 var testPolicyData = PolicyData{
@@ -258,6 +259,37 @@ func Test_APIClient_Policies(t *testing.T) {
 	}
 }
 
+func Test_APIClient_PoliciesPaginator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	testPolicies := []Policy{
+		{Name: "test-name-1", Version: "v1", PolicyData: testPolicyData},
+		{Name: "test-name-2", Version: "v1", PolicyData: testPolicyData},
+		{Name: "test-name-3", Version: "v2", PolicyData: testPolicyDataV2},
+	}
+
+	requester := new(mocks.ClientHTTPRequester)
+	requester.On("Request", ctx, http.MethodGet, defaultBaseURL+"/policies", nil, new([]Policy)).
+		Return(http.StatusOK, nil).
+		Run(func(args mock.Arguments) {
+			output := args.Get(4).(*[]Policy)
+			*output = testPolicies
+		}).
+		Once()
+
+	api := New(WithRetryMax(0))
+	api.http = requester
+
+	var pages [][]Policy
+	for result := range api.PoliciesPaginator(ctx, 2).Iter(ctx) {
+		require.NoError(t, result.Err)
+		pages = append(pages, result.Items)
+	}
+	assert.Equal(t, [][]Policy{testPolicies[:2], testPolicies[2:]}, pages)
+	requester.AssertExpectations(t)
+}
+
 func Test_APIClient_PoliciesByNames(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/lib/osutil"
+)
+
+// ConfigFile is the content of the shared Border0 config file, which holds named profiles,
+// e.g. one per Border0 organization:
+//
+//	{
+//	  "profiles": {
+//	    "prod": { "token_file": "/etc/border0/prod-token" },
+//	    "staging": { "base_url": "https://api.staging.border0.com/api/v1", "retry_max": 2 }
+//	  }
+//	}
+type ConfigFile struct {
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile is a named set of client settings in the shared Border0 config file. Settings
+// which are not set are left as they are when the profile is applied to a client.
+type Profile struct {
+	StoredToken   *ProfileStoredToken `json:"stored_token,omitempty"`    // where the stored token is, written by Authenticate, see WithStoredToken
+	Token         string              `json:"token,omitempty"`           // plaintext stored token of older versions, used when StoredToken is not set
+	TokenFile     string              `json:"token_file,omitempty"`      // file to read tokens from, takes precedence over Token
+	WebIdentity   *ProfileWebIdentity `json:"web_identity,omitempty"`    // takes precedence over TokenFile and Token
+	BaseURL       string              `json:"base_url,omitempty"`        // base url of the Border0 API
+	PortalBaseURL string              `json:"portal_base_url,omitempty"` // base url of the Border0 (Admin) Portal
+	RetryMax      *int                `json:"retry_max,omitempty"`       // maximum number of retries
+	RetryWaitMin  string              `json:"retry_wait_min,omitempty"`  // e.g. "1s"
+	RetryWaitMax  string              `json:"retry_wait_max,omitempty"`  // e.g. "30s"
+}
+
+// ProfileWebIdentity sets a profile up to obtain its tokens by exchanging the web identity
+// token in the given file (e.g. a Kubernetes service account token) for tokens of a service
+// account. See WithWebIdentity.
+type ProfileWebIdentity struct {
+	OrganizationSubdomain string `json:"organization_subdomain"`
+	ServiceAccountName    string `json:"service_account_name"`
+	TokenFile             string `json:"token_file"`
+}
+
+// ProfileStoredToken refers to the token of a profile stored by Authenticate, which is kept
+// out of the shared config file.
+type ProfileStoredToken struct {
+	File      string `json:"file"`                // file the token is stored in
+	Encrypted bool   `json:"encrypted,omitempty"` // whether the file is encrypted, see auth.EncryptedFileTokenStore
+}
+
+// store returns the token store of the stored token. Encrypted tokens are decrypted with
+// the key of the BORDER0_TOKEN_KEY env var, see auth.KeyFromEnv.
+func (t *ProfileStoredToken) store() auth.TokenStore {
+	if t.Encrypted {
+		return auth.EncryptedFileTokenStore(t.File, auth.KeyFromEnv(""))
+	}
+	return auth.FileTokenStore(t.File)
+}
+
+// DefaultConfigFilePath returns the path of the shared Border0 config file, which is the
+// value of the BORDER0_CONFIG_FILE env var if set, ~/.border0/config otherwise.
+func DefaultConfigFilePath() (string, error) {
+	if path := os.Getenv("BORDER0_CONFIG_FILE"); path != "" {
+		return path, nil
+	}
+	hd, err := osutil.GetUserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(hd, ".border0", "config"), nil
+}
+
+// LoadConfigFile reads the shared Border0 config file at the given path.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Border0 config file: %w", err)
+	}
+	var config ConfigFile
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode Border0 config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// LoadProfile returns the profile with the given name from the shared Border0 config file at
+// the given path, or at the default path (see DefaultConfigFilePath) if the path is empty.
+func LoadProfile(path, name string) (*Profile, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultConfigFilePath(); err != nil {
+			return nil, err
+		}
+	}
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile [%s] not found in Border0 config file %s", name, path)
+	}
+	return &profile, nil
+}
+
+// apply sets the settings of the profile on the given client.
+func (p *Profile) apply(api *APIClient) error {
+	var retryWaitMin, retryWaitMax time.Duration
+	var err error
+	if p.RetryWaitMin != "" {
+		if retryWaitMin, err = time.ParseDuration(p.RetryWaitMin); err != nil {
+			return fmt.Errorf("invalid retry_wait_min: %w", err)
+		}
+	}
+	if p.RetryWaitMax != "" {
+		if retryWaitMax, err = time.ParseDuration(p.RetryWaitMax); err != nil {
+			return fmt.Errorf("invalid retry_wait_max: %w", err)
+		}
+	}
+	if p.WebIdentity != nil && (p.WebIdentity.OrganizationSubdomain == "" || p.WebIdentity.ServiceAccountName == "" || p.WebIdentity.TokenFile == "") {
+		return errors.New("web_identity requires organization_subdomain, service_account_name and token_file")
+	}
+
+	// the profile's token source is kept apart, so that tokens set with options after the
+	// profile take precedence over it, see New
+	api.profileTokens, api.legacyToken = nil, false
+	switch {
+	case p.WebIdentity != nil:
+		api.profileTokens = WebIdentityTokenSource(api, p.WebIdentity.OrganizationSubdomain, p.WebIdentity.ServiceAccountName, FileTokenSource(p.WebIdentity.TokenFile))
+	case p.TokenFile != "":
+		api.profileTokens = FileTokenSource(p.TokenFile)
+	case p.Token != "" && p.StoredToken == nil:
+		api.profileTokens, api.legacyToken = StaticTokenSource(p.Token), true
+	}
+	if p.StoredToken != nil || api.profileTokens != nil {
+		// tokens set before the profile give way to the profile's token, the stored token of
+		// the client (see storedTokenSource) or the profile's token source
+		api.authToken, api.tokenSource = "", nil
+	}
+	if p.BaseURL != "" {
+		api.baseURL = p.BaseURL
+	}
+	if p.PortalBaseURL != "" {
+		api.portalBaseURL = p.PortalBaseURL
+	}
+	if p.RetryMax != nil {
+		api.retryMax = *p.RetryMax
+	}
+	if retryWaitMin > 0 {
+		api.retryWaitMin = retryWaitMin
+	}
+	if retryWaitMax > 0 {
+		api.retryWaitMax = retryWaitMax
+	}
+	return nil
+}
+
+// saveProfileToken stores the given token of the profile with the given name of the shared
+// Border0 config file at the given path, and refers to it from the profile. The token is kept
+// out of the config file, in a file of the tokens directory next to it, which is encrypted
+// when the BORDER0_TOKEN_KEY env var holds a key (see auth.EncryptedFileTokenStore). The file
+// and the profile are created if they don't exist, everything else in the file is kept as it
+// is, but for the plaintext token of older versions, which is removed.
+func saveProfileToken(path, name, token string) (err error) {
+	if path == "" {
+		if path, err = DefaultConfigFilePath(); err != nil {
+			return err
+		}
+	}
+	if name == "" || name != filepath.Base(name) {
+		return fmt.Errorf("invalid profile name [%s]", name)
+	}
+	stored := &ProfileStoredToken{
+		File:      filepath.Join(filepath.Dir(path), "tokens", name),
+		Encrypted: os.Getenv(auth.DefaultTokenKeyEnvVar) != "",
+	}
+	if err = stored.store().Save(token); err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+
+	config := map[string]json.RawMessage{}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read Border0 config file: %w", err)
+	default:
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("failed to decode Border0 config file %s: %w", path, err)
+		}
+	}
+
+	profiles := map[string]map[string]json.RawMessage{}
+	if raw, ok := config["profiles"]; ok {
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return fmt.Errorf("failed to decode profiles of Border0 config file %s: %w", path, err)
+		}
+	}
+	if profiles[name] == nil {
+		profiles[name] = map[string]json.RawMessage{}
+	}
+	delete(profiles[name], "token")
+	if profiles[name]["stored_token"], err = json.Marshal(stored); err != nil {
+		return fmt.Errorf("failed to encode stored token: %w", err)
+	}
+	if config["profiles"], err = json.Marshal(profiles); err != nil {
+		return fmt.Errorf("failed to encode profiles: %w", err)
+	}
+	if b, err = json.MarshalIndent(config, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode Border0 config file: %w", err)
+	}
+
+	// write to a temporary file first, so that the config file is never left half written
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to ensure directories for Border0 config file: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, append(b, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write Border0 config file: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write Border0 config file: %w", err)
+	}
+	return nil
+}
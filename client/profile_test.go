@@ -0,0 +1,276 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/borderzero/border0-go/lib/nacl"
+	"github.com/borderzero/border0-go/lib/types/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfigFile = `{
+  "profiles": {
+    "prod": {
+      "token": "prod-token",
+      "retry_max": 2,
+      "retry_wait_min": "100ms",
+      "retry_wait_max": "2s"
+    },
+    "staging": {
+      "token_file": "%s",
+      "base_url": "https://api.staging.border0.com/api/v1",
+      "portal_base_url": "https://portal.staging.border0.com"
+    },
+    "invalid": {
+      "retry_wait_min": "soon"
+    },
+    "incomplete": {
+      "web_identity": {"organization_subdomain": "acme"}
+    }
+  },
+  "unknown": true
+}`
+
+func writeTestConfigFile(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "staging-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("staging-token\n"), 0600))
+	path := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testConfigFile, tokenFile)), 0600))
+	return path
+}
+
+func Test_LoadProfile(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestConfigFile(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		profile     string
+		wantProfile *Profile
+		wantErr     string
+	}{
+		{
+			name:    "profile found",
+			path:    path,
+			profile: "prod",
+			wantProfile: &Profile{
+				Token:        "prod-token",
+				RetryMax:     pointer.To(2),
+				RetryWaitMin: "100ms",
+				RetryWaitMax: "2s",
+			},
+		},
+		{
+			name:    "profile not found",
+			path:    path,
+			profile: "dev",
+			wantErr: "profile [dev] not found in Border0 config file " + path,
+		},
+		{
+			name:    "config file not found",
+			path:    filepath.Join(t.TempDir(), "config"),
+			profile: "prod",
+			wantErr: "failed to read Border0 config file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			profile, err := LoadProfile(test.path, test.profile)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantProfile, profile)
+		})
+	}
+}
+
+func Test_WithProfileFromFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := writeTestConfigFile(t)
+
+	prod := New(WithBaseURL("https://example.com"), WithProfileFromFile(path, "prod"), WithRetryMax(3))
+	require.NoError(t, prod.profileErr)
+	token, err := prod.tokens.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", token)
+	assert.Equal(t, "https://example.com", prod.baseURL, "settings not in the profile are kept")
+	retryPolicy := prod.retryPolicy.(*DefaultRetryPolicy)
+	assert.Equal(t, 3, retryPolicy.RetryMax, "options given after the profile take precedence")
+	assert.Equal(t, 100*time.Millisecond, retryPolicy.RetryWaitMin)
+	assert.Equal(t, 2*time.Second, retryPolicy.RetryWaitMax)
+
+	staging := New(WithProfileFromFile(path, "staging"))
+	require.NoError(t, staging.profileErr)
+	token, err = staging.tokens.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "staging-token", token)
+	assert.Equal(t, "https://api.staging.border0.com/api/v1", staging.baseURL)
+	assert.Equal(t, "https://portal.staging.border0.com", staging.portalBaseURL)
+
+	for _, name := range []string{"invalid", "incomplete", "missing"} {
+		api := New(WithProfileFromFile(path, name))
+		_, err := api.Sockets(ctx)
+		assert.ErrorContains(t, err, "failed to load Border0 profile ["+name+"]", "api calls fail when the profile can't be loaded")
+	}
+}
+
+// authorizationRecorder is a stand-in for the Border0 API which records the authorization
+// header of the last request.
+func authorizationRecorder(t *testing.T) (*httptest.Server, func() string) {
+	t.Helper()
+	var mu sync.Mutex
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.Write([]byte(`{"list":[]}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return got
+	}
+}
+
+func Test_WithProfileFromFile_tokenPrecedence(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := writeTestConfigFile(t)
+	ts, authorization := authorizationRecorder(t)
+
+	tests := []struct {
+		name    string
+		options []Option
+		want    string
+	}{
+		{
+			name:    "token file of the profile",
+			options: []Option{WithBaseURL(ts.URL), WithProfileFromFile(path, "staging"), WithBaseURL(ts.URL)},
+			want:    "Bearer staging-token",
+		},
+		{
+			name:    "auth token after the profile",
+			options: []Option{WithProfileFromFile(path, "staging"), WithBaseURL(ts.URL), WithAuthToken("explicit")},
+			want:    "Bearer explicit",
+		},
+		{
+			name:    "token source after the profile",
+			options: []Option{WithProfileFromFile(path, "staging"), WithBaseURL(ts.URL), WithTokenSource(StaticTokenSource("explicit"))},
+			want:    "Bearer explicit",
+		},
+		{
+			name:    "auth token before the profile",
+			options: []Option{WithAuthToken("explicit"), WithProfileFromFile(path, "staging"), WithBaseURL(ts.URL)},
+			want:    "Bearer staging-token",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := New(append(test.options, WithRetryMax(0))...)
+			_, err := api.Sockets(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, authorization())
+		})
+	}
+}
+
+func Test_New_profileEnvVar(t *testing.T) {
+	ctx := context.Background()
+	path := writeTestConfigFile(t)
+	ts, authorization := authorizationRecorder(t)
+	t.Setenv("BORDER0_CONFIG_FILE", path)
+	t.Setenv("BORDER0_PROFILE", "nope")
+
+	api := New(WithAuthToken("explicit"), WithBaseURL(ts.URL), WithRetryMax(0))
+	_, err := api.Sockets(ctx)
+	require.NoError(t, err, "the profile of the env var is not used by explicitly configured clients")
+	assert.Equal(t, "Bearer explicit", authorization())
+
+	api = New(WithAuthToken("explicit"), WithRetryMax(0))
+	_, err = api.Sockets(ctx)
+	assert.EqualError(t, err, "failed to load Border0 profile [nope]: profile [nope] not found in Border0 config file "+path, "the base url of the profile would be used")
+
+	api = New(WithProfileFromFile(path, "staging"), WithBaseURL(ts.URL), WithRetryMax(0))
+	_, err = api.Sockets(ctx)
+	require.NoError(t, err, "explicit profiles replace the profile of the env var")
+	assert.Equal(t, "Bearer staging-token", authorization())
+}
+
+func Test_saveProfileToken(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestConfigFile(t)
+	require.NoError(t, saveProfileToken(path, "prod", "new-prod-token"))
+	require.NoError(t, saveProfileToken(path, "dev", "dev-token"))
+
+	prod, err := LoadProfile(path, "prod")
+	require.NoError(t, err)
+	assert.Empty(t, prod.Token, "the plaintext token of older versions is removed")
+	assert.Equal(t, &ProfileStoredToken{File: filepath.Join(filepath.Dir(path), "tokens", "prod")}, prod.StoredToken)
+	token, err := prod.StoredToken.store().Load()
+	require.NoError(t, err)
+	assert.Equal(t, "new-prod-token", token)
+	assert.Equal(t, pointer.To(2), prod.RetryMax, "other settings of the profile are kept")
+	dev, err := LoadProfile(path, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{StoredToken: &ProfileStoredToken{File: filepath.Join(filepath.Dir(path), "tokens", "dev")}}, dev)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"unknown": true`, "everything else in the file is kept")
+	assert.NotContains(t, string(b), "new-prod-token", "tokens are kept out of the config file")
+
+	newPath := filepath.Join(t.TempDir(), ".border0", "config")
+	require.NoError(t, saveProfileToken(newPath, "prod", "prod-token"))
+	prod, err = LoadProfile(newPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{StoredToken: &ProfileStoredToken{File: filepath.Join(filepath.Dir(newPath), "tokens", "prod")}}, prod)
+
+	assert.EqualError(t, saveProfileToken(newPath, "../prod", "prod-token"), "invalid profile name [../prod]")
+}
+
+func Test_saveProfileToken_encrypted(t *testing.T) {
+	key, err := nacl.GenerateKey()
+	require.NoError(t, err)
+	t.Setenv(auth.DefaultTokenKeyEnvVar, key.String())
+
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, saveProfileToken(path, "prod", "prod-token"))
+
+	prod, err := LoadProfile(path, "prod")
+	require.NoError(t, err)
+	require.NotNil(t, prod.StoredToken)
+	assert.True(t, prod.StoredToken.Encrypted)
+	b, err := os.ReadFile(prod.StoredToken.File)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "prod-token", "the token is encrypted")
+
+	api := New(WithProfileFromFile(path, "prod"))
+	token, err := api.readStoredToken()
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", token)
+}
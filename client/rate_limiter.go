@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits the rate of requests sent by an APIClient, with separate limits for
+// reads (GET, HEAD and OPTIONS requests) and writes (all other requests). It is shared by
+// all goroutines using the client, and when the API server responds with a 429, it pauses
+// all of them, not just the one that got the 429.
+type rateLimiter struct {
+	read  *tokenBucket // nil if reads are not limited
+	write *tokenBucket // nil if writes are not limited
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+func newRateLimiter() *rateLimiter { return &rateLimiter{} }
+
+// wait blocks until a request with the given method is allowed to be sent, or until the
+// context is done.
+func (l *rateLimiter) wait(ctx context.Context, method string) error {
+	// wait out any pause first, so that requests are not let through all at once after it
+	for {
+		l.mu.Lock()
+		pause := time.Until(l.pausedUntil)
+		l.mu.Unlock()
+		if pause <= 0 {
+			break
+		}
+		if err := sleep(ctx, pause); err != nil {
+			return err
+		}
+	}
+
+	bucket := l.write
+	if isReadMethod(method) {
+		bucket = l.read
+	}
+	if bucket == nil {
+		return nil
+	}
+	return bucket.wait(ctx)
+}
+
+// pause pauses all requests for the given duration, unless they are already paused for longer.
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// tokenBucket is a token bucket that hands out tokens in the order they are requested.
+type tokenBucket struct {
+	rate  float64 // tokens per second
+	burst float64 // bucket capacity
+
+	mu     sync.Mutex
+	tokens float64 // available tokens, negative when tokens are reserved ahead of time
+	last   time.Time
+}
+
+// newTokenBucket returns a token bucket allowing requests at the given steady
+// rate, with bursts of up to the given number of requests.
+func newTokenBucket(requestsPerSecond float64, burst int) *tokenBucket {
+	capacity := float64(burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		rate:   requestsPerSecond,
+		burst:  capacity,
+		tokens: capacity,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token from the bucket, returning how long to wait until it is available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token to the bucket.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return nil // unlimited
+	}
+	if err := sleep(ctx, b.reserve()); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// sleep sleeps for the given duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tokenBucket_wait(t *testing.T) {
+	t.Parallel()
+
+	bucket := newTokenBucket(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, bucket.wait(context.Background()))
+	}
+	// 2 requests are allowed right away (burst), the other 2 at 100 requests per second
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func Test_tokenBucket_wait_cancelled(t *testing.T) {
+	t.Parallel()
+
+	bucket := newTokenBucket(1, 1)
+	require.NoError(t, bucket.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, bucket.wait(ctx), context.DeadlineExceeded)
+
+	// the cancelled reservation was returned to the bucket
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	assert.Greater(t, bucket.tokens, -1.0)
+}
+
+func Test_rateLimiter_methodClasses(t *testing.T) {
+	t.Parallel()
+
+	limiter := newRateLimiter()
+	limiter.write = newTokenBucket(1, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// reads are not limited
+	for i := 0; i < 10; i++ {
+		require.NoError(t, limiter.wait(ctx, http.MethodGet))
+	}
+	// writes are
+	require.NoError(t, limiter.wait(ctx, http.MethodPost))
+	assert.ErrorIs(t, limiter.wait(ctx, http.MethodDelete), context.DeadlineExceeded)
+}
+
+func Test_rateLimiter_pause(t *testing.T) {
+	t.Parallel()
+
+	limiter := newRateLimiter()
+	limiter.pause(30 * time.Millisecond)
+	limiter.pause(time.Millisecond) // shorter pauses don't shorten the current one
+
+	start := time.Now()
+	require.NoError(t, limiter.wait(context.Background(), http.MethodGet))
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+}
+
+func Test_APIClient_request_tooManyRequestsPausesAllCallers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests      atomic.Int32
+		mu            sync.Mutex
+		rateLimitedAt time.Time
+		requestTimes  []time.Time
+	)
+	firstRequestReceived := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if requests.Add(1) == 1 {
+			rateLimitedAt = time.Now()
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(firstRequestReceived)
+			return
+		}
+		requestTimes = append(requestTimes, time.Now())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	api := New(
+		WithBaseURL(ts.URL),
+		WithReadRateLimit(1000, 100),
+		WithBackoff(func(_, _ time.Duration, _ int) time.Duration { return 100 * time.Millisecond }),
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := api.request(context.Background(), http.MethodGet, "/rate-limited", nil, nil)
+		assert.NoError(t, err)
+	}()
+
+	<-firstRequestReceived
+	time.Sleep(10 * time.Millisecond) // let the first caller pause the limiter
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := api.request(context.Background(), http.MethodGet, "/other", nil, nil)
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	require.Len(t, requestTimes, 2)
+	for _, requestTime := range requestTimes {
+		assert.GreaterOrEqual(t, requestTime.Sub(rateLimitedAt), 90*time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt of an api call is retried, and how long
+// to wait before retrying it. Regardless of the policy, the client never waits past the
+// deadline of the call's context.
+type RetryPolicy interface {
+	Retry(ctx context.Context, req RetryRequest) (wait time.Duration, retry bool)
+}
+
+// RetryRequest describes a failed attempt of an api call.
+type RetryRequest struct {
+	Method     string // HTTP method of the call
+	Path       string // path of the call, relative to the base url
+	StatusCode int    // response status code, zero if no response was received
+	Err        error  // error returned by the attempt
+	Attempt    int    // attempt number, starting at 1
+}
+
+// DefaultRetryPolicy is the RetryPolicy used by the client unless another one is set
+// with WithRetryPolicy. It retries:
+//
+//   - 404 responses, to handle cross-region replication latency
+//   - 429 responses, respecting the Retry-After header when present
+//   - 5xx responses, but only for idempotent requests, so that non-idempotent writes
+//     (e.g. creating a socket or a policy) never end up creating duplicates
+type DefaultRetryPolicy struct {
+	RetryMax     int           // maximum number of retries of 5xx responses
+	RetryWaitMin time.Duration // minimum time to wait between retries of 5xx responses
+	RetryWaitMax time.Duration // maximum time to wait between retries of 5xx responses
+	Backoff      Backoff       // used to calculate the wait time between retries
+}
+
+// ensure DefaultRetryPolicy implements RetryPolicy at compile-time.
+var _ RetryPolicy = (*DefaultRetryPolicy)(nil)
+
+// Retry decides whether the failed attempt is retried, and how long to wait before it.
+func (p *DefaultRetryPolicy) Retry(_ context.Context, req RetryRequest) (time.Duration, bool) {
+	retryCount := req.Attempt - 1
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff
+	}
+
+	switch {
+	// Retry on 404 to handle cross-region replication latency.
+	case req.StatusCode == http.StatusNotFound:
+		if retryCount >= notFoundRetryMax {
+			return 0, false
+		}
+		return backoff(notFoundRetryWaitMin, notFoundRetryWaitMax, retryCount), true
+
+	// Retry on 429 to handle rate limits being exceeded temporarily. The request was
+	// not processed by the server, so it is safe to retry regardless of the method.
+	case req.StatusCode == http.StatusTooManyRequests:
+		if retryCount >= tooManyRequestsRetryMax {
+			return 0, false
+		}
+		waitMin, waitMax := tooManyRequestsRetryWaitMin, tooManyRequestsRetryWaitMax
+		if retryAfter := retryAfterFrom(req.Err); retryAfter != nil {
+			// Respect server's Retry-After value, even if it's longer than our maximum,
+			// but use our minimum if server suggests a shorter wait, which could be zero.
+			waitMin = max(*retryAfter, tooManyRequestsRetryWaitMin)
+			waitMax = waitMin
+		}
+		return backoff(waitMin, waitMax, retryCount), true
+
+	// Retry on 5xx to handle temporary api issues, only when it's safe to do so.
+	case req.StatusCode >= http.StatusInternalServerError:
+		if retryCount >= p.RetryMax || !IsIdempotent(req.Method, req.Path) {
+			return 0, false
+		}
+		return backoff(p.RetryWaitMin, p.RetryWaitMax, retryCount), true
+	}
+
+	return 0, false
+}
+
+// idempotentPostPathSuffixes are the paths of POST endpoints which are safe to retry.
+var idempotentPostPathSuffixes = []string{
+	"/signkey",                    // signing a socket key has no side effects
+	"/auth/web_identity/exchange", // exchanging a token has no side effects
+}
+
+// IsIdempotent reports whether sending a request with the given method and path more
+// than once has the same effect as sending it once. GET, HEAD, OPTIONS, PUT and DELETE
+// requests are idempotent, as are a few known POST endpoints without side effects.
+func IsIdempotent(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		path, _, _ = strings.Cut(path, "?")
+		for _, suffix := range idempotentPostPathSuffixes {
+			if strings.HasSuffix(path, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequestError is the error returned by api calls that failed. It holds the history of
+// all the attempts made, and wraps the error returned by the last one.
+type RequestError struct {
+	Method   string
+	Path     string
+	Attempts []Attempt
+	Err      error
+}
+
+// Error returns string representation of a RequestError.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("failed after %d %s: %v", len(e.Attempts), attemptOrAttempts(len(e.Attempts)), e.Err)
+}
+
+// Unwrap returns the error returned by the last attempt.
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// Attempts returns the history of attempts of a failed api call, if the
+// given error (or any error it wraps) is a RequestError.
+func Attempts(err error) ([]Attempt, bool) {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		return nil, false
+	}
+	return reqErr.Attempts, true
+}
+
+// exceedsDeadline reports whether waiting for the given duration would go past the
+// deadline of the given context.
+func exceedsDeadline(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(wait).After(deadline)
+}
+
+func attemptOrAttempts(attempt int) string {
+	if attempt == 1 {
+		return "attempt"
+	}
+	return "attempts"
+}
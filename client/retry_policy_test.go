@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DefaultRetryPolicy_Retry(t *testing.T) {
+	t.Parallel()
+
+	linearBackoff := func(min, max time.Duration, attemptNum int) time.Duration {
+		return min * time.Duration(attemptNum+1)
+	}
+	policy := &DefaultRetryPolicy{
+		RetryMax:     2,
+		RetryWaitMin: time.Second,
+		RetryWaitMax: 10 * time.Second,
+		Backoff:      linearBackoff,
+	}
+
+	tests := []struct {
+		name      string
+		given     RetryRequest
+		wantWait  time.Duration
+		wantRetry bool
+	}{
+		{
+			name:      "get is retried on 5xx",
+			given:     RetryRequest{Method: http.MethodGet, Path: "/sockets", StatusCode: http.StatusInternalServerError, Attempt: 1},
+			wantWait:  time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "put is retried on 5xx with backoff",
+			given:     RetryRequest{Method: http.MethodPut, Path: "/socket/abc", StatusCode: http.StatusBadGateway, Attempt: 2},
+			wantWait:  2 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "5xx is not retried once retry max is reached",
+			given:     RetryRequest{Method: http.MethodDelete, Path: "/socket/abc", StatusCode: http.StatusServiceUnavailable, Attempt: 3},
+			wantRetry: false,
+		},
+		{
+			name:      "post is not retried on 5xx",
+			given:     RetryRequest{Method: http.MethodPost, Path: "/socket", StatusCode: http.StatusInternalServerError, Attempt: 1},
+			wantRetry: false,
+		},
+		{
+			name:      "idempotent post is retried on 5xx",
+			given:     RetryRequest{Method: http.MethodPost, Path: "/socket/abc/signkey", StatusCode: http.StatusInternalServerError, Attempt: 1},
+			wantWait:  time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "post is retried on 429",
+			given:     RetryRequest{Method: http.MethodPost, Path: "/socket", StatusCode: http.StatusTooManyRequests, Attempt: 1},
+			wantWait:  tooManyRequestsRetryWaitMin,
+			wantRetry: true,
+		},
+		{
+			name:      "post is retried on 404",
+			given:     RetryRequest{Method: http.MethodPost, Path: "/socket/abc/signkey", StatusCode: http.StatusNotFound, Attempt: 3},
+			wantWait:  3 * notFoundRetryWaitMin,
+			wantRetry: true,
+		},
+		{
+			name:      "404 is not retried once not found retry max is reached",
+			given:     RetryRequest{Method: http.MethodGet, Path: "/socket/abc", StatusCode: http.StatusNotFound, Attempt: notFoundRetryMax + 1},
+			wantRetry: false,
+		},
+		{
+			name:      "4xx is not retried",
+			given:     RetryRequest{Method: http.MethodGet, Path: "/sockets", StatusCode: http.StatusBadRequest, Attempt: 1},
+			wantRetry: false,
+		},
+		{
+			name:      "network error is not retried",
+			given:     RetryRequest{Method: http.MethodGet, Path: "/sockets", Attempt: 1},
+			wantRetry: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotWait, gotRetry := policy.Retry(context.Background(), test.given)
+			assert.Equal(t, test.wantRetry, gotRetry)
+			assert.Equal(t, test.wantWait, gotWait)
+		})
+	}
+}
+
+func Test_IsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodGet, path: "/sockets", want: true},
+		{method: http.MethodHead, path: "/sockets", want: true},
+		{method: http.MethodOptions, path: "/sockets", want: true},
+		{method: http.MethodPut, path: "/socket/abc", want: true},
+		{method: http.MethodDelete, path: "/socket/abc", want: true},
+		{method: http.MethodPost, path: "/socket", want: false},
+		{method: http.MethodPatch, path: "/socket/abc", want: false},
+		{method: http.MethodPost, path: "/socket/abc/signkey", want: true},
+		{method: http.MethodPost, path: "/socket/abc/signkey?x=y", want: true},
+		{method: http.MethodPost, path: "/auth/web_identity/exchange", want: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, IsIdempotent(test.method, test.path))
+		})
+	}
+}
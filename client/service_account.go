@@ -13,6 +13,7 @@ type ServiceAccountService interface {
 	UpdateServiceAccount(ctx context.Context, in *ServiceAccount) (out *ServiceAccount, err error)
 	DeleteServiceAccount(ctx context.Context, name string) (err error)
 	ServiceAccountTokens(ctx context.Context, serviceAccountName string) (out *ServiceAccountTokens, err error)
+	ServiceAccountTokensPaginator(ctx context.Context, serviceAccountName string, pageSize int) *Paginator[ServiceAccountToken]
 	CreateServiceAccountToken(ctx context.Context, serviceAccountName string, in *ServiceAccountToken) (out *ServiceAccountToken, err error)
 	DeleteServiceAccountToken(ctx context.Context, serviceAccountName, tokenID string) (err error)
 }
@@ -80,6 +81,19 @@ func (api *APIClient) ServiceAccountTokens(ctx context.Context, serviceAccountNa
 	return out, nil
 }
 
+// ServiceAccountTokensPaginator returns a paginator to iterate pages of tokens for a service account. The
+// Border0 API returns all tokens of a service account at once, so they are all fetched with the first page.
+func (api *APIClient) ServiceAccountTokensPaginator(ctx context.Context, serviceAccountName string, pageSize int) *Paginator[ServiceAccountToken] {
+	list := func(ctx context.Context, api *APIClient) ([]ServiceAccountToken, error) {
+		tokens, err := api.ServiceAccountTokens(ctx, serviceAccountName)
+		if err != nil {
+			return nil, err
+		}
+		return tokens.List, nil
+	}
+	return newListPaginator(api, list, pageSize)
+}
+
 // CreateServiceAccountToken creates a new token for a service account. The token is used to authenticate connector with the
 // Border0 API. The token can be created with or without a expiration date. If ExpiresAt field is not set, token will not expire.
 func (api *APIClient) CreateServiceAccountToken(ctx context.Context, serviceAccountName string, in *ServiceAccountToken) (out *ServiceAccountToken, err error) {
@@ -2,21 +2,29 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/borderzero/border0-go/lib/types/selector"
 	"github.com/borderzero/border0-go/types/service"
 )
 
 const defaultPageSizeSockets = 100
 
+// tagSelectorFilter is the key of the tag selector in the filters of sockets paginators. The
+// Border0 API does not filter sockets by tags, tag selectors are applied by the client.
+const tagSelectorFilter = "tag_selector"
+
 type socketFilters struct {
 	name       string
 	search     string
 	socketType string
+	tags       selector.Selector
 }
 
 type SocketFilter func(*socketFilters)
@@ -36,13 +44,50 @@ func WithSearch(search string) SocketFilter {
 	return func(sf *socketFilters) { sf.search = search }
 }
 
+// WithTagSelector sets the socket filter for selecting sockets by tags, e.g.
+//
+//	client.WithTagSelector(selector.MustParse("env=prod,team in (payments,risk),!legacy"))
+//
+// The Border0 API does not filter sockets by tags, so the selector is applied by the client
+// to the sockets listed by the API, see ResumeSocketsPaginator.
+func WithTagSelector(tags selector.Selector) SocketFilter {
+	return func(sf *socketFilters) { sf.tags = tags }
+}
+
+// SocketFilterValues returns the query parameters the given socket filters translate to
+// when listing sockets with the Border0 API. Tag selectors are kept whole in the tag_selector
+// parameter, which is applied by the client and not sent to the API.
+func SocketFilterValues(filters ...SocketFilter) url.Values {
+	filter := &socketFilters{}
+	for _, setFilter := range filters {
+		setFilter(filter)
+	}
+
+	params := url.Values{}
+	if filter.name != "" {
+		params.Add("name", filter.name)
+	}
+	if filter.search != "" {
+		params.Add("search", filter.search)
+	}
+	if filter.socketType != "" {
+		params.Add("socket_type", filter.socketType)
+	}
+	if !filter.tags.Empty() {
+		params.Add(tagSelectorFilter, filter.tags.String())
+	}
+	return params
+}
+
 // SocketService is an interface for API client methods that interact with Border0 API to manage sockets.
 type SocketService interface {
 	Socket(ctx context.Context, idOrName string) (out *Socket, err error)
 	Sockets(ctx context.Context, filters ...SocketFilter) (out []Socket, err error)
 	SocketsPaginator(ctx context.Context, pageSize int, filters ...SocketFilter) *Paginator[Socket]
+	ResumeSocketsPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[Socket]
 	CreateSocket(ctx context.Context, in *Socket) (out *Socket, err error)
 	UpdateSocket(ctx context.Context, idOrName string, in *Socket) (out *Socket, err error)
+	EnsureSocket(ctx context.Context, desired *Socket, opts ...ReconcileOption) (report *EnsureSocketReport, err error)
 	DeleteSocket(ctx context.Context, idOrName string) (err error)
 	SocketConnectors(ctx context.Context, idOrName string) (out *SocketConnectors, err error)
 	SocketUpstreamConfigs(ctx context.Context, idOrName string) (out *SocketUpstreamConfigs, err error)
@@ -79,37 +124,57 @@ func (api *APIClient) Sockets(ctx context.Context, filters ...SocketFilter) (out
 
 // SocketsPaginator returns a paginator to iterate pages of sockets.
 func (api *APIClient) SocketsPaginator(ctx context.Context, pageSize int, filters ...SocketFilter) *Paginator[Socket] {
+	return api.ResumeSocketsPaginator(ctx, Checkpoint{PageSize: pageSize, Filters: SocketFilterValues(filters...)})
+}
+
+// ResumeSocketsPaginator returns a paginator to iterate pages of sockets, which resumes from
+// the given checkpoint of a sockets paginator, with the same filters.
+//
+// When sockets are selected by tags (see WithTagSelector), the sockets not selected are left
+// out of the pages of the API, so pages can have fewer sockets than the page size, and pages
+// without any selected socket are skipped.
+func (api *APIClient) ResumeSocketsPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[Socket] {
+	pageSize := checkpoint.PageSize
 	if pageSize <= 0 {
 		pageSize = defaultPageSizeSockets
 	}
+	tags, tagsErr := selector.Parse(checkpoint.Filters.Get(tagSelectorFilter))
 
-	filter := &socketFilters{}
-	for _, setFilter := range filters {
-		setFilter(filter)
-	}
-
-	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []Socket, nextPage int, err error) {
-		params := url.Values{}
-		params.Add("page", strconv.Itoa(page))
-		params.Add("page_size", strconv.Itoa(size))
-		if filter.name != "" {
-			params.Add("name", filter.name)
+	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []Socket, pages pagination, err error) {
+		if tagsErr != nil {
+			return nil, pagination{}, tagsErr
 		}
-		if filter.search != "" {
-			params.Add("search", filter.search)
-		}
-		if filter.socketType != "" {
-			params.Add("socket_type", filter.socketType)
-		}
-		path := fmt.Sprintf("/sockets?%s", params.Encode())
+		params := url.Values{}
+		maps.Copy(params, checkpoint.Filters)
+		params.Del(tagSelectorFilter)
+		params.Set("page_size", strconv.Itoa(size))
+		for {
+			params.Set("page", strconv.Itoa(page))
+			path := fmt.Sprintf("/sockets?%s", params.Encode())
 
-		var res paginatedResponse[Socket]
-		if _, err = api.request(ctx, http.MethodGet, path, nil, &res); err != nil {
-			return nil, 0, err
+			var res paginatedResponse[Socket]
+			if _, err = api.request(ctx, http.MethodGet, path, nil, &res); err != nil {
+				return nil, pagination{}, err
+			}
+			if tags.Empty() {
+				return res.List, res.Pagination, nil
+			}
+
+			for _, socket := range res.List {
+				if tags.Matches(socket.Tags) {
+					items = append(items, socket)
+				}
+			}
+			// pages of the api may be skipped, so the pages can't be prefetched
+			pages = res.Pagination
+			pages.TotalPages = 0
+			if len(items) > 0 || pages.NextPage <= 0 {
+				return items, pages, nil
+			}
+			page = pages.NextPage
 		}
-		return res.List, res.Pagination.NextPage, nil
 	}
-	return newPaginator(api, fetch, pageSize)
+	return newPaginator(api, fetch, pageSize).resume(checkpoint)
 }
 
 // CreateSocket creates a new socket in your Border0 organization. Socket name must be unique within your organization,
@@ -134,6 +199,39 @@ func (api *APIClient) UpdateSocket(ctx context.Context, idOrName string, in *Soc
 	return out, nil
 }
 
+// EnsureSocket makes sure the socket with the name of the desired socket exists and matches
+// the desired socket: it's created if it does not exist, and updated if it has drifted (see
+// ReconcileSocket), it's left alone otherwise. The report tells what was changed.
+func (api *APIClient) EnsureSocket(ctx context.Context, desired *Socket, opts ...ReconcileOption) (report *EnsureSocketReport, err error) {
+	if desired.Name == "" {
+		return nil, errors.New("the desired socket has no name")
+	}
+	current, err := api.Socket(ctx, desired.Name)
+	if NotFound(err) {
+		created, err := api.CreateSocket(ctx, desired)
+		if err != nil {
+			return nil, err
+		}
+		return &EnsureSocketReport{Socket: created, Created: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	update, changes, err := ReconcileSocket(current, desired, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return &EnsureSocketReport{Socket: current}, nil
+	}
+	updated, err := api.UpdateSocket(ctx, current.SocketID, update)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureSocketReport{Socket: updated, Changes: changes}, nil
+}
+
 // DeleteSocket deletes a socket in your Border0 organization. If the socket does not exist, no error will be returned.
 func (api *APIClient) DeleteSocket(ctx context.Context, idOrName string) (err error) {
 	_, err = api.request(ctx, http.MethodDelete, fmt.Sprintf("/socket/%s", idOrName), nil, nil)
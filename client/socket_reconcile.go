@@ -0,0 +1,204 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/borderzero/border0-go/lib/types/jsoneq"
+)
+
+// ignoredSocketFields are the (json) fields of sockets which are never reconciled: the socket id
+// and dns name are populated by the server, so desired sockets don't know them, and policies are
+// managed with other endpoints (AttachPoliciesToSocket and the like).
+var ignoredSocketFields = []string{"socket_id", "dnsname", "policies"}
+
+// wholeSocketFields are the (json) fields of sockets, at any depth, holding maps of labels or
+// selectors, which are reconciled as a whole rather than key by key like other objects: keys
+// missing from the desired value are removed.
+var wholeSocketFields = []string{"tags", "namespace_selectors_allowlist"}
+
+// socketPruneOptions make empty values the same as unset values in socket diffs.
+var socketPruneOptions = []jsoneq.Option{jsoneq.PruneEmptyObjects(), jsoneq.PruneEmptySlices(), jsoneq.PruneEmptyStrings()}
+
+type reconcileOptions struct {
+	clearFields []string
+}
+
+// ReconcileOption is a function that can be passed to ReconcileSocket and EnsureSocket to
+// change how sockets are reconciled.
+type ReconcileOption func(*reconcileOptions)
+
+// ClearSocketFields sets the (json) fields of the socket, e.g. "description" or "tags", which
+// are cleared when they are left empty in the desired socket. Fields left empty in the desired
+// socket are not reconciled otherwise, see ReconcileSocket.
+func ClearSocketFields(fields ...string) ReconcileOption {
+	return func(o *reconcileOptions) { o.clearFields = append(o.clearFields, fields...) }
+}
+
+// SocketChange is a change of a field of a socket, see ReconcileSocket.
+type SocketChange struct {
+	Field   string // json name of the field, e.g. "upstream_configuration"
+	Current any    // json value of the field before the change, nil if unset
+	Desired any    // json value of the field after the change, nil if cleared
+}
+
+// EnsureSocketReport is the outcome of EnsureSocket.
+type EnsureSocketReport struct {
+	Socket  *Socket        // the socket as it is after EnsureSocket
+	Created bool           // whether the socket was created
+	Changes []SocketChange // changes of the fields of the socket if it was updated, sorted by field
+}
+
+// Changed reports whether the socket was created or updated.
+func (r *EnsureSocketReport) Changed() bool { return r.Created || len(r.Changes) > 0 }
+
+// ReconcileSocket compares the current socket with the desired one, and returns the changes
+// to make for the current socket to match the desired one, and the socket to update the current
+// socket with (see UpdateSocket) to make them. There are no changes if the sockets already match.
+//
+// Only the fields set in the desired socket are reconciled, so that the fields the server fills
+// in by default (e.g. the display name or the upstream type) are left alone: fields left at their
+// zero value (empty strings, false, zero, empty lists and objects) are not reconciled, unless
+// they are cleared with ClearSocketFields. Objects, like the upstream configuration, are
+// reconciled field by field the same way, while lists and maps of labels (tags, and selectors of
+// the upstream configuration) are reconciled as a whole, so extra tags are removed. Sockets are
+// compared semantically, by their json representation, so the order of lists (e.g. connector
+// ids) does not matter. The fields populated by the server only (socket id and dns name) and
+// policies are never reconciled.
+//
+// The socket to update the current socket with is the current socket with the changes made,
+// so cleared fields are only cleared if UpdateSocket replaces the whole socket.
+func ReconcileSocket(current, desired *Socket, opts ...ReconcileOption) (*Socket, []SocketChange, error) {
+	options := &reconcileOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	currentFields, err := socketFields(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	desiredFields, err := socketFields(desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	pruneUnsetFields(desiredFields)
+
+	fields := make([]string, 0, len(desiredFields)+len(options.clearFields))
+	for field := range desiredFields {
+		fields = append(fields, field)
+	}
+	for _, field := range options.clearFields {
+		if _, ok := desiredFields[field]; !ok && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []SocketChange
+	for _, field := range fields {
+		if slices.Contains(ignoredSocketFields, field) {
+			continue
+		}
+		got := currentFields[field]
+		want := mergeSocketField(field, got, desiredFields[field])
+		gotJSON, err := json.Marshal(got)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode socket field %s: %w", field, err)
+		}
+		wantJSON, err := json.Marshal(want)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode socket field %s: %w", field, err)
+		}
+		if jsoneq.AreEqual(string(gotJSON), string(wantJSON), socketPruneOptions...) {
+			continue
+		}
+		changes = append(changes, SocketChange{Field: field, Current: got, Desired: want})
+		if want == nil {
+			delete(currentFields, field)
+		} else {
+			currentFields[field] = want
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+
+	b, err := json.Marshal(currentFields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode socket: %w", err)
+	}
+	update := new(Socket)
+	if err := json.Unmarshal(b, update); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode socket: %w", err)
+	}
+	return update, changes, nil
+}
+
+// socketFields returns the json fields of the given socket, without the empty ones.
+func socketFields(socket *Socket) (map[string]any, error) {
+	b, err := json.Marshal(socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode socket: %w", err)
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode socket: %w", err)
+	}
+	jsoneq.Prune(fields, socketPruneOptions...)
+	return fields, nil
+}
+
+// pruneUnsetFields recursively removes the fields left at their zero value from the given
+// json object, so that only the fields which are set remain. Lists, and the fields reconciled
+// as a whole, are kept as they are unless empty.
+func pruneUnsetFields(fields map[string]any) {
+	for field, value := range fields {
+		switch v := value.(type) {
+		case nil:
+			delete(fields, field)
+		case bool:
+			if !v {
+				delete(fields, field)
+			}
+		case float64:
+			if v == 0 {
+				delete(fields, field)
+			}
+		case map[string]any:
+			if !slices.Contains(wholeSocketFields, field) {
+				pruneUnsetFields(v)
+			}
+			if len(v) == 0 {
+				delete(fields, field)
+			}
+		}
+	}
+}
+
+// mergeSocketField returns the current json value of the given socket field with the desired
+// value set on it: objects are merged field by field, other values and the fields reconciled as
+// a whole are replaced by the desired one.
+func mergeSocketField(field string, current, desired any) any {
+	if slices.Contains(wholeSocketFields, field) {
+		return desired
+	}
+	currentObject, ok := current.(map[string]any)
+	if !ok {
+		return desired
+	}
+	desiredObject, ok := desired.(map[string]any)
+	if !ok {
+		return desired
+	}
+	merged := make(map[string]any, len(currentObject))
+	for field, value := range currentObject {
+		merged[field] = value
+	}
+	for field, value := range desiredObject {
+		merged[field] = mergeSocketField(field, currentObject[field], value)
+	}
+	return merged
+}
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/borderzero/border0-go/types/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReconcileSocket(t *testing.T) {
+	t.Parallel()
+
+	// testSocket returns a new socket as it's currently configured
+	testSocket := func() *Socket {
+		return &Socket{
+			Name:             "test-name",
+			SocketID:         "test-id",
+			SocketType:       "http",
+			Description:      "test description",
+			RecordingEnabled: true,
+			Tags:             map[string]string{"env": "prod", "team": "infra"},
+			ConnectorIDs:     []string{"connector-1", "connector-2"},
+			UpstreamConfig: &service.Configuration{
+				ServiceType: service.ServiceTypeHttp,
+				HttpServiceConfiguration: &service.HttpServiceConfiguration{
+					HttpServiceType: service.HttpServiceTypeStandard,
+					StandardHttpServiceConfiguration: &service.StandardHttpServiceConfiguration{
+						HostnameAndPort: service.HostnameAndPort{Hostname: "localhost", Port: 8080},
+					},
+				},
+			},
+			Policies: []Policy{{ID: "policy-id", Name: "policy-name"}},
+			DNS:      "test-name-org.border0.io",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		desired     func(*Socket)
+		opts        []ReconcileOption
+		wantChanges []SocketChange
+		wantUpdate  func(*Socket) // changes of the current socket to update it with, nil for no update
+	}{
+		{
+			name:    "same socket",
+			desired: func(*Socket) {},
+		},
+		{
+			name: "server populated fields and policies are ignored",
+			desired: func(s *Socket) {
+				s.SocketID = ""
+				s.DNS = "other.border0.io"
+				s.Policies = nil
+			},
+		},
+		{
+			name: "empty fields are the same as unset fields",
+			desired: func(s *Socket) {
+				s.DisplayName = ""
+				s.Tags = map[string]string{"env": "prod", "team": "infra", "owner": ""}
+				s.UpstreamType = ""
+			},
+		},
+		{
+			name: "fields left empty are not reconciled",
+			desired: func(s *Socket) {
+				s.Description = ""
+				s.RecordingEnabled = false
+				s.Tags = nil
+				s.ConnectorIDs = nil
+				s.UpstreamConfig = nil
+			},
+		},
+		{
+			name: "tags and description cleared",
+			desired: func(s *Socket) {
+				s.Description = ""
+				s.Tags = nil
+			},
+			opts: []ReconcileOption{ClearSocketFields("description", "tags")},
+			wantChanges: []SocketChange{
+				{Field: "description", Current: "test description"},
+				{Field: "tags", Current: map[string]any{"env": "prod", "team": "infra"}},
+			},
+			wantUpdate: func(s *Socket) {
+				s.Description = ""
+				s.Tags = nil
+			},
+		},
+		{
+			name: "connectors and upstream configuration cleared",
+			desired: func(s *Socket) {
+				s.ConnectorIDs = []string{}
+				s.UpstreamConfig = nil
+			},
+			opts: []ReconcileOption{ClearSocketFields("connector_ids", "upstream_configuration")},
+			wantChanges: []SocketChange{
+				{Field: "connector_ids", Current: []any{"connector-1", "connector-2"}},
+				{
+					Field: "upstream_configuration",
+					Current: map[string]any{
+						"service_type": "http",
+						"http_service_configuration": map[string]any{
+							"http_service_type":                   "standard",
+							"standard_http_service_configuration": map[string]any{"hostname": "localhost", "port": float64(8080)},
+						},
+					},
+				},
+			},
+			wantUpdate: func(s *Socket) {
+				s.ConnectorIDs = nil
+				s.UpstreamConfig = nil
+			},
+		},
+		{
+			name:    "fields set in the desired socket are not cleared",
+			desired: func(s *Socket) { s.Description = "new description" },
+			opts:    []ReconcileOption{ClearSocketFields("description")},
+			wantChanges: []SocketChange{
+				{Field: "description", Current: "test description", Desired: "new description"},
+			},
+			wantUpdate: func(s *Socket) { s.Description = "new description" },
+		},
+		{
+			name:    "order of lists does not matter",
+			desired: func(s *Socket) { s.ConnectorIDs = []string{"connector-2", "connector-1"} },
+		},
+		{
+			name:    "description changed",
+			desired: func(s *Socket) { s.Description = "new description" },
+			wantChanges: []SocketChange{
+				{Field: "description", Current: "test description", Desired: "new description"},
+			},
+			wantUpdate: func(s *Socket) { s.Description = "new description" },
+		},
+		{
+			name:    "recording disabled",
+			desired: func(s *Socket) { s.RecordingEnabled = false },
+			opts:    []ReconcileOption{ClearSocketFields("recording_enabled")},
+			wantChanges: []SocketChange{
+				{Field: "recording_enabled", Current: true},
+			},
+			wantUpdate: func(s *Socket) { s.RecordingEnabled = false },
+		},
+		{
+			name:    "tag removed",
+			desired: func(s *Socket) { s.Tags = map[string]string{"env": "prod"} },
+			wantChanges: []SocketChange{
+				{
+					Field:   "tags",
+					Current: map[string]any{"env": "prod", "team": "infra"},
+					Desired: map[string]any{"env": "prod"},
+				},
+			},
+			wantUpdate: func(s *Socket) { s.Tags = map[string]string{"env": "prod"} },
+		},
+		{
+			name: "tags and upstream port changed",
+			desired: func(s *Socket) {
+				s.Tags = map[string]string{"env": "staging", "team": "infra"}
+				s.UpstreamConfig = &service.Configuration{
+					ServiceType: service.ServiceTypeHttp,
+					HttpServiceConfiguration: &service.HttpServiceConfiguration{
+						HttpServiceType: service.HttpServiceTypeStandard,
+						StandardHttpServiceConfiguration: &service.StandardHttpServiceConfiguration{
+							HostnameAndPort: service.HostnameAndPort{Hostname: "localhost", Port: 9090},
+						},
+					},
+				}
+			},
+			wantChanges: []SocketChange{
+				{
+					Field:   "tags",
+					Current: map[string]any{"env": "prod", "team": "infra"},
+					Desired: map[string]any{"env": "staging", "team": "infra"},
+				},
+				{
+					Field: "upstream_configuration",
+					Current: map[string]any{
+						"service_type": "http",
+						"http_service_configuration": map[string]any{
+							"http_service_type":                   "standard",
+							"standard_http_service_configuration": map[string]any{"hostname": "localhost", "port": float64(8080)},
+						},
+					},
+					Desired: map[string]any{
+						"service_type": "http",
+						"http_service_configuration": map[string]any{
+							"http_service_type":                   "standard",
+							"standard_http_service_configuration": map[string]any{"hostname": "localhost", "port": float64(9090)},
+						},
+					},
+				},
+			},
+			wantUpdate: func(s *Socket) {
+				s.Tags = map[string]string{"env": "staging", "team": "infra"}
+				s.UpstreamConfig.HttpServiceConfiguration.StandardHttpServiceConfiguration.Port = 9090
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			desired := testSocket()
+			test.desired(desired)
+
+			update, changes, err := ReconcileSocket(testSocket(), desired, test.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantChanges, changes)
+			if test.wantUpdate == nil {
+				assert.Nil(t, update)
+				return
+			}
+			want := testSocket()
+			test.wantUpdate(want)
+			assert.Equal(t, want, update)
+		})
+	}
+}
+
+func Test_ReconcileSocket_serverDefaults(t *testing.T) {
+	t.Parallel()
+
+	// the socket as the GET endpoint returns it, with the fields the server fills in by default
+	current := &Socket{
+		Name:                 "test-name",
+		DisplayName:          "test-name",
+		SocketID:             "test-id",
+		SocketType:           "http",
+		UpstreamType:         "http",
+		UpstreamHTTPHostname: "localhost",
+		RecordingEnabled:     true,
+		ConnectorIDs:         []string{"connector-1"},
+		UpstreamConfig: &service.Configuration{
+			ServiceType: service.ServiceTypeHttp,
+			HttpServiceConfiguration: &service.HttpServiceConfiguration{
+				HttpServiceType: service.HttpServiceTypeStandard,
+				StandardHttpServiceConfiguration: &service.StandardHttpServiceConfiguration{
+					HostnameAndPort: service.HostnameAndPort{Hostname: "localhost", Port: 8080},
+					Scheme:          "http",
+					HostHeader:      "localhost",
+				},
+			},
+		},
+		Policies: []Policy{{ID: "policy-id", Name: "policy-name"}},
+		DNS:      "test-name-org.border0.io",
+	}
+	desired := &Socket{
+		Name:         "test-name",
+		SocketType:   "http",
+		ConnectorIDs: []string{"connector-1"},
+		UpstreamConfig: &service.Configuration{
+			ServiceType: service.ServiceTypeHttp,
+			HttpServiceConfiguration: &service.HttpServiceConfiguration{
+				HttpServiceType: service.HttpServiceTypeStandard,
+				StandardHttpServiceConfiguration: &service.StandardHttpServiceConfiguration{
+					HostnameAndPort: service.HostnameAndPort{Hostname: "localhost", Port: 8080},
+				},
+			},
+		},
+	}
+
+	update, changes, err := ReconcileSocket(current, desired)
+	require.NoError(t, err)
+	assert.Nil(t, changes)
+	assert.Nil(t, update)
+
+	// changing the port keeps the scheme and host header filled in by the server
+	desired.UpstreamConfig.HttpServiceConfiguration.StandardHttpServiceConfiguration.Port = 9090
+	update, changes, err = ReconcileSocket(current, desired)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "upstream_configuration", changes[0].Field)
+	require.NotNil(t, update)
+	assert.Equal(t, "test-name", update.DisplayName)
+	assert.Equal(t, "localhost", update.UpstreamHTTPHostname)
+	assert.Equal(t, &service.StandardHttpServiceConfiguration{
+		HostnameAndPort: service.HostnameAndPort{Hostname: "localhost", Port: 9090},
+		Scheme:          "http",
+		HostHeader:      "localhost",
+	}, update.UpstreamConfig.HttpServiceConfiguration.StandardHttpServiceConfiguration)
+}
@@ -2,15 +2,19 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/borderzero/border0-go/client/mocks"
+	"github.com/borderzero/border0-go/lib/types/selector"
 	"github.com/borderzero/border0-go/types/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_APIClient_Socket(t *testing.T) {
@@ -163,6 +167,148 @@ func Test_APIClient_Sockets(t *testing.T) {
 	}
 }
 
+func Test_APIClient_ResumeSocketsPaginator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	testSockets := []Socket{
+		{Name: "test-name-3", SocketID: "test-id-3", SocketType: "ssh"},
+		{Name: "test-name-4", SocketID: "test-id-4", SocketType: "ssh"},
+		{Name: "test-name-5", SocketID: "test-id-5", SocketType: "ssh"},
+	}
+
+	requester := new(mocks.ClientHTTPRequester)
+	for page, sockets := range [][]Socket{testSockets[:2], testSockets[2:]} {
+		path := fmt.Sprintf("%s/sockets?page=%d&page_size=2&socket_type=ssh", defaultBaseURL, page+2)
+		requester.On("Request", ctx, http.MethodGet, path, nil, new(paginatedResponse[Socket])).
+			Return(http.StatusOK, nil).
+			Run(func(args mock.Arguments) {
+				output := args.Get(4).(*paginatedResponse[Socket])
+				output.List = sockets
+				if page == 0 {
+					output.Pagination.NextPage = 3
+				}
+			}).
+			Once()
+	}
+
+	api := New(WithRetryMax(0))
+	api.http = requester
+
+	paginator := api.SocketsPaginator(ctx, 2, WithType("ssh"))
+	paginator.nextPage = 2
+	encoded, err := json.Marshal(paginator.Checkpoint())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"next_page":2,"page_size":2,"filters":{"socket_type":["ssh"]}}`, string(encoded))
+
+	var checkpoint Checkpoint
+	require.NoError(t, json.Unmarshal(encoded, &checkpoint))
+	resumed := api.ResumeSocketsPaginator(ctx, checkpoint)
+	sockets, err := Collect(resumed.Items(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, testSockets, sockets)
+	assert.Equal(t, Checkpoint{NextPage: 3, PageSize: 2, Filters: url.Values{"socket_type": {"ssh"}}, Done: true}, resumed.Checkpoint())
+	requester.AssertExpectations(t)
+}
+
+func Test_APIClient_SocketsPaginator_tagSelector(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pages := [][]Socket{
+		{
+			{Name: "test-name-1", Tags: map[string]string{"env": "prod", "team": "payments"}},
+			{Name: "test-name-2", Tags: map[string]string{"env": "prod", "team": "payments", "legacy": "true"}},
+		},
+		{
+			{Name: "test-name-3", Tags: map[string]string{"env": "prod", "team": "search"}},
+		},
+		{
+			{Name: "test-name-4", Tags: map[string]string{"env": "prod", "team": "risk"}},
+		},
+	}
+
+	requester := new(mocks.ClientHTTPRequester)
+	for page, sockets := range pages {
+		path := fmt.Sprintf("%s/sockets?page=%d&page_size=2", defaultBaseURL, page+1)
+		requester.On("Request", ctx, http.MethodGet, path, nil, new(paginatedResponse[Socket])).
+			Return(http.StatusOK, nil).
+			Run(func(args mock.Arguments) {
+				output := args.Get(4).(*paginatedResponse[Socket])
+				output.List = sockets
+				output.Pagination.TotalPages = len(pages)
+				if page+1 < len(pages) {
+					output.Pagination.NextPage = page + 2
+				}
+			}).
+			Once()
+	}
+
+	api := New(WithRetryMax(0))
+	api.http = requester
+
+	paginator := api.SocketsPaginator(ctx, 2, WithTagSelector(selector.MustParse("env=prod,team in (payments,risk),!legacy")))
+	var got [][]string
+	for result := range paginator.WithConcurrency(4).Iter(ctx) {
+		require.NoError(t, result.Err)
+		var names []string
+		for _, socket := range result.Items {
+			names = append(names, socket.Name)
+		}
+		got = append(got, names)
+	}
+	assert.Equal(t, [][]string{{"test-name-1"}, {"test-name-4"}}, got, "pages without selected sockets are skipped")
+	requester.AssertExpectations(t)
+
+	invalid := api.ResumeSocketsPaginator(ctx, Checkpoint{Filters: url.Values{"tag_selector": {"env in"}}})
+	_, err := invalid.Next(ctx)
+	assert.EqualError(t, err, `invalid selector "env in": expected '(', got end of selector`)
+}
+
+func Test_SocketFilterValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filters []SocketFilter
+		want    url.Values
+	}{
+		{
+			name:    "no filters",
+			filters: nil,
+			want:    url.Values{},
+		},
+		{
+			name:    "all filters",
+			filters: []SocketFilter{WithName("test-name"), WithSearch("test"), WithType("ssh")},
+			want:    url.Values{"name": {"test-name"}, "search": {"test"}, "socket_type": {"ssh"}},
+		},
+		{
+			name:    "last filter of a kind wins",
+			filters: []SocketFilter{WithType("ssh"), WithType("http")},
+			want:    url.Values{"socket_type": {"http"}},
+		},
+		{
+			name:    "tag selector",
+			filters: []SocketFilter{WithTagSelector(selector.MustParse("env=prod, team in (payments),region in (eu,us),!legacy"))},
+			want:    url.Values{"tag_selector": {"env=prod,team in (payments),region in (eu,us),!legacy"}},
+		},
+		{
+			name:    "empty tag selector",
+			filters: []SocketFilter{WithTagSelector(selector.MustParse(""))},
+			want:    url.Values{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, SocketFilterValues(test.filters...))
+		})
+	}
+}
+
 func Test_APIClient_CreateSocket(t *testing.T) {
 	t.Parallel()
 
@@ -304,6 +450,156 @@ func Test_APIClient_UpdateSocket(t *testing.T) {
 	}
 }
 
+func Test_APIClient_EnsureSocket(t *testing.T) {
+	t.Parallel()
+
+	currentSocket := &Socket{
+		Name:         "test-name",
+		SocketID:     "test-id",
+		SocketType:   "ssh",
+		Description:  "old description",
+		ConnectorIDs: []string{"connector-1", "connector-2"},
+		DNS:          "test-name-org.border0.io",
+	}
+	updatedSocket := &Socket{
+		Name:         "test-name",
+		SocketID:     "test-id",
+		SocketType:   "ssh",
+		Description:  "new description",
+		ConnectorIDs: []string{"connector-1", "connector-2"},
+		DNS:          "test-name-org.border0.io",
+	}
+
+	mockGet := func(ctx context.Context, requester *mocks.ClientHTTPRequester, current *Socket) {
+		requester.On("Request", ctx, http.MethodGet, defaultBaseURL+"/socket/test-name?activeOnly=true", nil, new(Socket)).
+			Return(http.StatusOK, nil).
+			Run(func(args mock.Arguments) {
+				output := args.Get(4).(*Socket)
+				*output = *current
+			})
+	}
+
+	tests := []struct {
+		name          string
+		mockRequester func(context.Context, *mocks.ClientHTTPRequester)
+		givenSocket   *Socket
+		wantReport    *EnsureSocketReport
+		wantErr       error
+	}{
+		{
+			name:          "socket without name",
+			mockRequester: func(context.Context, *mocks.ClientHTTPRequester) {},
+			givenSocket:   &Socket{SocketType: "ssh"},
+			wantErr:       errors.New("the desired socket has no name"),
+		},
+		{
+			name: "failed to get socket",
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(ctx, http.MethodGet, defaultBaseURL+"/socket/test-name?activeOnly=true", nil, new(Socket)).
+					Return(http.StatusBadRequest, errors.New("failed to get socket"))
+			},
+			givenSocket: &Socket{Name: "test-name", SocketType: "ssh"},
+			wantErr:     errors.New("failed after 1 attempt: failed to get socket"),
+		},
+		{
+			name: "socket created",
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				requester.EXPECT().
+					Request(ctx, http.MethodGet, defaultBaseURL+"/socket/test-name?activeOnly=true", nil, new(Socket)).
+					Return(http.StatusNotFound, Error{Code: http.StatusNotFound, Message: "socket not found"})
+				requester.On("Request", ctx, http.MethodPost, defaultBaseURL+"/socket", &Socket{Name: "test-name", SocketType: "ssh"}, new(Socket)).
+					Return(http.StatusOK, nil).
+					Run(func(args mock.Arguments) {
+						output := args.Get(4).(*Socket)
+						*output = Socket{Name: "test-name", SocketID: "test-id", SocketType: "ssh"}
+					})
+			},
+			givenSocket: &Socket{Name: "test-name", SocketType: "ssh"},
+			wantReport: &EnsureSocketReport{
+				Socket:  &Socket{Name: "test-name", SocketID: "test-id", SocketType: "ssh"},
+				Created: true,
+			},
+		},
+		{
+			name: "socket unchanged",
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				mockGet(ctx, requester, currentSocket)
+			},
+			givenSocket: &Socket{
+				Name:         "test-name",
+				SocketType:   "ssh",
+				Description:  "old description",
+				ConnectorIDs: []string{"connector-2", "connector-1"},
+			},
+			wantReport: &EnsureSocketReport{Socket: currentSocket},
+		},
+		{
+			name: "socket updated",
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				mockGet(ctx, requester, currentSocket)
+				requester.On("Request", ctx, http.MethodPut, defaultBaseURL+"/socket/test-id", updatedSocket, new(Socket)).
+					Return(http.StatusOK, nil).
+					Run(func(args mock.Arguments) {
+						output := args.Get(4).(*Socket)
+						*output = *updatedSocket
+					})
+			},
+			givenSocket: &Socket{
+				Name:         "test-name",
+				SocketType:   "ssh",
+				Description:  "new description",
+				ConnectorIDs: []string{"connector-1", "connector-2"},
+			},
+			wantReport: &EnsureSocketReport{
+				Socket:  updatedSocket,
+				Changes: []SocketChange{{Field: "description", Current: "old description", Desired: "new description"}},
+			},
+		},
+		{
+			name: "failed to update socket",
+			mockRequester: func(ctx context.Context, requester *mocks.ClientHTTPRequester) {
+				mockGet(ctx, requester, currentSocket)
+				requester.EXPECT().
+					Request(ctx, http.MethodPut, defaultBaseURL+"/socket/test-id", updatedSocket, new(Socket)).
+					Return(http.StatusBadRequest, errors.New("failed to update socket"))
+			},
+			givenSocket: &Socket{
+				Name:         "test-name",
+				SocketType:   "ssh",
+				Description:  "new description",
+				ConnectorIDs: []string{"connector-1", "connector-2"},
+			},
+			wantErr: errors.New("failed after 1 attempt: failed to update socket"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			requester := new(mocks.ClientHTTPRequester)
+			test.mockRequester(ctx, requester)
+
+			api := New(
+				WithRetryMax(0),
+			)
+			api.http = requester
+
+			gotReport, gotErr := api.EnsureSocket(ctx, test.givenSocket)
+
+			if test.wantErr == nil {
+				assert.NoError(t, gotErr)
+			} else {
+				assert.EqualError(t, gotErr, test.wantErr.Error())
+			}
+			assert.Equal(t, test.wantReport, gotReport)
+			requester.AssertExpectations(t)
+		})
+	}
+}
+
 func Test_APIClient_DeleteSocket(t *testing.T) {
 	t.Parallel()
 
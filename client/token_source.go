@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultTokenRefreshBefore is how long before a token's expiry the client refreshes it.
+const defaultTokenRefreshBefore = time.Minute
+
+// TokenSource supplies Border0 tokens to the API client. The client asks its token
+// source for a token on every request, so implementations are expected to cache tokens
+// (see NewCachingTokenSource, which the client wraps every token source with).
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// RefreshableTokenSource is a TokenSource that can be forced to refresh its token, e.g.
+// after the API server rejected it with a 401.
+type RefreshableTokenSource interface {
+	TokenSource
+	// Refresh discards the given stale token, if it is still the current token,
+	// and returns a fresh one.
+	Refresh(ctx context.Context, stale string) (string, error)
+}
+
+// TokenSourceFunc is an adapter to allow the use of ordinary functions as token sources.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) { return f(ctx) }
+
+// StaticTokenSource returns a TokenSource that always returns the given token.
+func StaticTokenSource(token string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) { return token, nil })
+}
+
+// FileTokenSource returns a TokenSource that reads the token from the given file, e.g. a
+// token file written by Authenticate or a token file rotated by an external process. The
+// file is read on every call, and may hold any kind of token (e.g. web identity tokens).
+func FileTokenSource(path string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return "", fmt.Errorf("token file %s is empty", path)
+		}
+		return token, nil
+	})
+}
+
+// WebIdentityTokenSource returns a TokenSource that exchanges web identity tokens (e.g.
+// OIDC tokens issued by a CI/CD platform) obtained from the given web identity token
+// source for Border0 service account tokens.
+func WebIdentityTokenSource(api *APIClient, organizationSubdomain, serviceAccountName string, webIdentityToken TokenSource) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (string, error) {
+		webIdentity, err := webIdentityToken.Token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get web identity token: %w", err)
+		}
+		out, err := api.ExchangeWebIdentityToken(unauthenticated(ctx), &WebIdentityTokenExchangeInput{
+			OrganizationSubdomain: organizationSubdomain,
+			ServiceAccountName:    serviceAccountName,
+			WebIdentityToken:      webIdentity,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to exchange web identity token: %w", err)
+		}
+		return out.Token, nil
+	})
+}
+
+// DeviceFlowTokenSource returns a TokenSource that obtains tokens by running the device
+// authorization flow, the same way Authenticate does, whenever a new token is needed.
+func DeviceFlowTokenSource(api *APIClient, opts ...auth.Option) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (string, error) {
+		if isNonInteractive(ctx) {
+			return "", nil
+		}
+		config, err := api.authConfig(opts...)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize authentication configuration: %v", err)
+		}
+		return api.authenticate(unauthenticated(ctx), config)
+	})
+}
+
+// storedTokenSource returns a TokenSource that reads the token stored by Authenticate (see
+// readStoredToken). When the client is set up to re-authenticate, expired stored tokens are
+// replaced by running the device authorization flow, the same way Authenticate does.
+func storedTokenSource(api *APIClient) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (string, error) {
+		token, err := api.readStoredToken()
+		if err != nil {
+			return "", err
+		}
+		if token == "" || !tokenExpired(token) || !api.reauth || isNonInteractive(ctx) {
+			return token, nil
+		}
+		api.logger.InfoContext(ctx, "Stored Border0 token has expired, authenticating again")
+		config, err := api.authConfig(api.reauthOpts...)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize authentication configuration: %v", err)
+		}
+		return api.authenticate(unauthenticated(ctx), config)
+	})
+}
+
+// readStoredToken returns the token stored by Authenticate: the token in the token store of
+// the client (see auth.WithTokenStore, and the token storage file by default), or the token of
+// the client's profile. The token is empty if none was stored.
+func (api *APIClient) readStoredToken() (string, error) {
+	config, err := api.authConfig(api.reauthOpts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize authentication configuration: %v", err)
+	}
+	if store := config.GetTokenStore(); store != nil {
+		return store.Load()
+	}
+	configFilePath, name := config.GetProfile()
+	profile, err := LoadProfile(configFilePath, name)
+	if err != nil {
+		return "", err
+	}
+	if profile.StoredToken != nil {
+		return profile.StoredToken.store().Load()
+	}
+	return profile.Token, nil
+}
+
+// CachingTokenSource is a RefreshableTokenSource that caches the tokens of another
+// token source. Tokens that are JWTs with an expiry are refreshed shortly before they
+// expire, other tokens are cached until refreshed explicitly. It is safe for concurrent
+// use, and concurrent callers share a single refresh, which callers stop waiting for when
+// their context is done. The refresh is canceled once no callers are waiting for it.
+type CachingTokenSource struct {
+	src           TokenSource
+	refreshBefore time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expiry  time.Time              // zero if the token does not expire
+	fetches map[string]*tokenFetch // fetches in progress, by key
+}
+
+// tokenFetch is a fetch of a token shared by the callers waiting on it.
+type tokenFetch struct {
+	done    chan struct{} // closed once token and err are set
+	token   string
+	err     error
+	waiters int // guarded by the mutex of the CachingTokenSource
+	cancel  context.CancelFunc
+}
+
+// ensure CachingTokenSource implements RefreshableTokenSource at compile-time.
+var _ RefreshableTokenSource = (*CachingTokenSource)(nil)
+
+// NewCachingTokenSource returns a CachingTokenSource wrapping the given token source.
+// Tokens are refreshed the given duration before they expire.
+func NewCachingTokenSource(src TokenSource, refreshBefore time.Duration) *CachingTokenSource {
+	if cts, ok := src.(*CachingTokenSource); ok {
+		return cts
+	}
+	return &CachingTokenSource{src: src, refreshBefore: refreshBefore}
+}
+
+// Token returns the cached token, refreshing it first if it is about to expire.
+func (c *CachingTokenSource) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token, expiry := c.token, c.expiry
+	c.mu.Unlock()
+
+	if token != "" && (expiry.IsZero() || time.Until(expiry) > c.refreshBefore) {
+		return token, nil
+	}
+	return c.fetch(ctx, token)
+}
+
+// Refresh discards the given stale token, if it is still the cached token, and returns
+// a fresh one. If the cached token was already refreshed, it is returned as is.
+func (c *CachingTokenSource) Refresh(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	if token != "" && token != stale {
+		return token, nil
+	}
+	return c.fetch(ctx, stale)
+}
+
+// fetch gets a new token from the underlying token source to replace the given stale token,
+// and caches it. The stale token is not replaced if it was already replaced while the caller
+// was waiting. The fetch is shared by all the callers waiting on it, so it is not canceled
+// when the caller who happened to start it goes away, but it is once none of them are waiting
+// anymore, so that interactive token sources (e.g. the device authorization flow) don't keep
+// running in the background. Non-interactive callers (see nonInteractive) don't share fetches
+// with interactive ones.
+func (c *CachingTokenSource) fetch(ctx context.Context, stale string) (string, error) {
+	key := "token"
+	if isNonInteractive(ctx) {
+		key = "non-interactive token"
+	}
+
+	c.mu.Lock()
+	f, ok := c.fetches[key]
+	if !ok {
+		var fetchCtx context.Context
+		f = &tokenFetch{done: make(chan struct{})}
+		fetchCtx, f.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		if c.fetches == nil {
+			c.fetches = make(map[string]*tokenFetch)
+		}
+		c.fetches[key] = f
+		go c.run(fetchCtx, key, f, stale)
+	}
+	f.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		if f.waiters--; f.waiters == 0 {
+			f.cancel()
+			if c.fetches[key] == f {
+				delete(c.fetches, key)
+			}
+		}
+		c.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// run runs the given fetch, see fetch.
+func (c *CachingTokenSource) run(ctx context.Context, key string, f *tokenFetch, stale string) {
+	defer f.cancel()
+	defer close(f.done)
+
+	c.mu.Lock()
+	current := c.token
+	c.mu.Unlock()
+	if current != "" && current != stale {
+		f.token = current
+	} else {
+		f.token, f.err = c.src.Token(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetches[key] == f {
+		delete(c.fetches, key)
+	}
+	if f.err != nil {
+		f.token = ""
+		return
+	}
+	c.token, c.expiry = f.token, tokenExpiry(f.token)
+}
+
+// tokenExpiry returns the expiry of a JWT, or the zero time if it has none.
+func tokenExpiry(token string) time.Time {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return time.Time{}
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}
+	}
+	return exp.Time
+}
+
+// tokenExpired reports whether the given token is a JWT which has expired.
+func tokenExpired(token string) bool {
+	expiry := tokenExpiry(token)
+	return !expiry.IsZero() && !time.Now().Before(expiry)
+}
+
+// swappableTokenSource is the token source of an APIClient. It allows swapping the
+// underlying token source (e.g. by Authenticate) while the client is in use.
+type swappableTokenSource struct {
+	mu  sync.RWMutex
+	src *CachingTokenSource
+}
+
+func newSwappableTokenSource(src TokenSource) *swappableTokenSource {
+	return &swappableTokenSource{src: NewCachingTokenSource(src, defaultTokenRefreshBefore)}
+}
+
+func (s *swappableTokenSource) set(src TokenSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src = NewCachingTokenSource(src, defaultTokenRefreshBefore)
+}
+
+func (s *swappableTokenSource) get() *CachingTokenSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.src
+}
+
+// Token returns a token from the current token source.
+func (s *swappableTokenSource) Token(ctx context.Context) (string, error) {
+	return s.get().Token(ctx)
+}
+
+// Refresh refreshes the token of the current token source.
+func (s *swappableTokenSource) Refresh(ctx context.Context, stale string) (string, error) {
+	return s.get().Refresh(ctx, stale)
+}
+
+// unauthenticatedKey is the context key marking requests that must be sent without a token.
+type unauthenticatedKey struct{}
+
+// unauthenticated marks the requests made with the returned context as requests that must
+// be sent without a token, e.g. requests made to obtain a token in the first place.
+func unauthenticated(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unauthenticatedKey{}, true)
+}
+
+func isUnauthenticated(ctx context.Context) bool {
+	v, _ := ctx.Value(unauthenticatedKey{}).(bool)
+	return v
+}
+
+// accessTokenKey is the context key of the token sent in the x-access-token header.
+type accessTokenKey struct{}
+
+// withAccessToken makes the requests made with the returned context send the given token in
+// the x-access-token header, e.g. the device authorization token when polling for the outcome
+// of the device authorization flow.
+func withAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+func accessTokenFrom(ctx context.Context) string {
+	v, _ := ctx.Value(accessTokenKey{}).(string)
+	return v
+}
+
+// nonInteractiveKey is the context key marking token requests which must not authenticate
+// interactively.
+type nonInteractiveKey struct{}
+
+// nonInteractive marks the token requests made with the returned context as requests that
+// must not run the device authorization flow, e.g. to check whether the client is authenticated.
+func nonInteractive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonInteractiveKey{}, true)
+}
+
+func isNonInteractive(ctx context.Context) bool {
+	v, _ := ctx.Value(nonInteractiveKey{}).(bool)
+	return v
+}
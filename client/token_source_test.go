@@ -0,0 +1,404 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-go/client/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTokenExpiringIn(t *testing.T, d time.Duration) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(d).Unix(),
+	}).SignedString([]byte("test-key"))
+	require.NoError(t, err)
+	return token
+}
+
+// countingTokenSource returns the given tokens in order, repeating the last one.
+func countingTokenSource(calls *atomic.Int32, tokens ...string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		n := int(calls.Add(1))
+		if n > len(tokens) {
+			n = len(tokens)
+		}
+		return tokens[n-1], nil
+	})
+}
+
+func Test_CachingTokenSource_Token(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		token     string
+		wantCalls int32
+	}{
+		{
+			name:      "opaque token is cached",
+			token:     "opaque-token",
+			wantCalls: 1,
+		},
+		{
+			name:      "token far from expiry is cached",
+			token:     testTokenExpiringIn(t, time.Hour),
+			wantCalls: 1,
+		},
+		{
+			name:      "token about to expire is refreshed every time",
+			token:     testTokenExpiringIn(t, 30*time.Second),
+			wantCalls: 3,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			cts := NewCachingTokenSource(countingTokenSource(&calls, test.token), time.Minute)
+			for i := 0; i < 3; i++ {
+				got, err := cts.Token(context.Background())
+				assert.NoError(t, err)
+				assert.Equal(t, test.token, got)
+			}
+			assert.Equal(t, test.wantCalls, calls.Load())
+		})
+	}
+}
+
+func Test_CachingTokenSource_Refresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var calls atomic.Int32
+	cts := NewCachingTokenSource(countingTokenSource(&calls, "first", "second"), time.Minute)
+
+	got, err := cts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "first", got)
+
+	// stale token is the current one, so it gets refreshed
+	got, err = cts.Refresh(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, "second", got)
+
+	// stale token was already refreshed by someone else, no new fetch
+	got, err = cts.Refresh(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, "second", got)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func Test_CachingTokenSource_sourceError(t *testing.T) {
+	t.Parallel()
+
+	cts := NewCachingTokenSource(TokenSourceFunc(func(context.Context) (string, error) {
+		return "", errors.New("no token for you")
+	}), time.Minute)
+
+	_, err := cts.Token(context.Background())
+	assert.EqualError(t, err, "no token for you")
+}
+
+func Test_CachingTokenSource_canceledWhileFetching(t *testing.T) {
+	t.Parallel()
+
+	fetching, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	cts := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		close(fetching)
+		<-release // e.g. a slow device authorization flow
+		return "slow-token", ctx.Err()
+	}), time.Minute)
+
+	// the first caller starts the fetch, and gives up while it is still running
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := cts.Token(ctx)
+		firstDone <- err
+	}()
+	<-fetching
+
+	// the second caller keeps waiting on the same fetch
+	secondDone := make(chan struct{})
+	var second string
+	var secondErr error
+	go func() {
+		defer close(secondDone)
+		second, secondErr = cts.Token(context.Background())
+	}()
+	assert.Eventually(t, func() bool { return cts.waiters("token") == 2 }, 5*time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-firstDone:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("canceled caller is still waiting on the fetch")
+	}
+
+	close(release)
+	<-secondDone
+	require.NoError(t, secondErr, "the fetch is not canceled with the caller who started it")
+	assert.Equal(t, "slow-token", second)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func Test_CachingTokenSource_canceledWithoutWaiters(t *testing.T) {
+	t.Parallel()
+
+	fetchDone := make(chan error)
+	var calls atomic.Int32
+	cts := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+		if calls.Add(1) > 1 {
+			return "second-token", nil
+		}
+		<-ctx.Done() // e.g. a device authorization flow nobody completes
+		fetchDone <- ctx.Err()
+		return "", ctx.Err()
+	}), time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := cts.Token(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case err := <-fetchDone:
+		assert.ErrorIs(t, err, context.Canceled, "the fetch is canceled once no callers are waiting")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fetch is still running without callers waiting on it")
+	}
+
+	// the next caller starts a new fetch
+	token, err := cts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second-token", token)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// waiters returns the number of callers waiting on the fetch with the given key.
+func (c *CachingTokenSource) waiters(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.fetches[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+func Test_FileTokenSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(path, []byte("  file-token\n"), 0600))
+
+	got, err := FileTokenSource(path).Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token", got)
+
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+	_, err = FileTokenSource(empty).Token(context.Background())
+	assert.EqualError(t, err, fmt.Sprintf("token file %s is empty", empty))
+
+	_, err = FileTokenSource(filepath.Join(dir, "missing")).Token(context.Background())
+	assert.ErrorContains(t, err, "failed to read token file")
+}
+
+func Test_storedTokenSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	valid := testTokenExpiringIn(t, time.Hour)
+	expired := testTokenExpiringIn(t, -time.Hour)
+	configFile := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(
+		`{"profiles": {"valid": {"token": %q}, "expired": {"token": %q}, "none": {}}}`, valid, expired,
+	)), 0600))
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(expired+"\n"), 0600))
+
+	tests := []struct {
+		name      string
+		options   []Option
+		wantToken string
+	}{
+		{
+			name:      "token of the profile",
+			options:   []Option{WithProfileFromFile(configFile, "valid")},
+			wantToken: valid,
+		},
+		{
+			name:      "no stored token",
+			options:   []Option{WithProfileFromFile(configFile, "none")},
+			wantToken: "",
+		},
+		{
+			name:      "expired tokens are used as they are without re-authentication",
+			options:   []Option{WithProfileFromFile(configFile, "expired")},
+			wantToken: expired,
+		},
+		{
+			name:      "token of the token storage file, not re-authenticated when not interactive",
+			options:   []Option{WithReauthentication(auth.WithTokenStorageFilePath(tokenFile))},
+			wantToken: expired,
+		},
+		{
+			name:      "token of the token store, which takes precedence over the profile",
+			options:   []Option{WithProfileFromFile(configFile, "valid"), WithReauthentication(auth.WithTokenStore(auth.NewMemoryTokenStore("stored")))},
+			wantToken: "stored",
+		},
+		{
+			name:      "tokens set with options take precedence",
+			options:   []Option{WithProfileFromFile(configFile, "valid"), WithAuthToken("explicit")},
+			wantToken: "explicit",
+		},
+		{
+			name:      "stored tokens disabled",
+			options:   []Option{WithProfileFromFile(configFile, "none"), WithStoredToken(false)},
+			wantToken: "",
+		},
+		{
+			name:      "plaintext token of the profile with stored tokens disabled",
+			options:   []Option{WithProfileFromFile(configFile, "valid"), WithStoredToken(false)},
+			wantToken: valid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			api := New(test.options...)
+			token, err := api.tokens.Token(nonInteractive(context.Background()))
+			require.NoError(t, err)
+			assert.Equal(t, test.wantToken, token)
+		})
+	}
+}
+
+func Test_WebIdentityTokenSource(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/web_identity/exchange", r.URL.Path)
+		assert.Empty(t, r.Header.Get(headerAuthorization))
+
+		var in WebIdentityTokenExchangeInput
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, WebIdentityTokenExchangeInput{
+			OrganizationSubdomain: "test-org",
+			ServiceAccountName:    "test-sa",
+			WebIdentityToken:      "oidc-token",
+		}, in)
+
+		w.Write([]byte(`{"token":"border0-token"}`))
+	}))
+	defer ts.Close()
+
+	api := New(WithBaseURL(ts.URL))
+	src := WebIdentityTokenSource(api, "test-org", "test-sa", StaticTokenSource("oidc-token"))
+
+	got, err := src.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "border0-token", got)
+}
+
+func Test_HTTPClient_Request_refreshesTokenOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		tokens       []string
+		wantCode     int
+		wantRequests int32
+	}{
+		{
+			name:         "token refreshed and request retried",
+			tokens:       []string{"stale", "fresh"},
+			wantCode:     http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "token source returns the same token, no retry",
+			tokens:       []string{"stale"},
+			wantCode:     http.StatusUnauthorized,
+			wantRequests: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if r.Header.Get(headerAuthorization) != "Bearer fresh" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer ts.Close()
+
+			var calls atomic.Int32
+			api := New(
+				WithBaseURL(ts.URL),
+				WithTokenSource(countingTokenSource(&calls, test.tokens...)),
+				WithRetryMax(0),
+			)
+
+			gotCode, _ := api.request(context.Background(), http.MethodGet, "/test", nil, nil)
+			assert.Equal(t, test.wantCode, gotCode)
+			assert.Equal(t, test.wantRequests, requests.Load())
+		})
+	}
+}
+
+func Test_APIClient_tokenSwapUnderConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	api := New(WithBaseURL(ts.URL), WithAuthToken("initial"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := api.request(context.Background(), http.MethodGet, "/test", nil, nil)
+			assert.NoError(t, err)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			api.tokens.set(StaticTokenSource(fmt.Sprintf("token-%d", i)))
+		}(i)
+	}
+	wg.Wait()
+}
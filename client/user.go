@@ -15,6 +15,7 @@ type UserService interface {
 	User(ctx context.Context, id string) (out *User, err error)
 	Users(ctx context.Context) (out *Users, err error)
 	UsersPaginator(ctx context.Context, pageSize int) *Paginator[User]
+	ResumeUsersPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[User]
 	CreateUser(ctx context.Context, in *User, opts ...UserOption) (out *User, err error)
 	UpdateUser(ctx context.Context, in *User) (out *User, err error)
 	DeleteUser(ctx context.Context, id string) (err error)
@@ -61,10 +62,17 @@ func (api *APIClient) Users(ctx context.Context) (out *Users, err error) {
 
 // UsersPaginator returns a paginator to iterate pages of users.
 func (api *APIClient) UsersPaginator(ctx context.Context, pageSize int) *Paginator[User] {
+	return api.ResumeUsersPaginator(ctx, Checkpoint{PageSize: pageSize})
+}
+
+// ResumeUsersPaginator returns a paginator to iterate pages of users, which resumes from
+// the given checkpoint of a users paginator.
+func (api *APIClient) ResumeUsersPaginator(ctx context.Context, checkpoint Checkpoint) *Paginator[User] {
+	pageSize := checkpoint.PageSize
 	if pageSize <= 0 {
 		pageSize = defaultPageSizeUsers
 	}
-	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []User, nextPage int, err error) {
+	fetch := func(ctx context.Context, api *APIClient, page, size int) (items []User, pages pagination, err error) {
 		params := url.Values{}
 		params.Add("page", strconv.Itoa(page))
 		params.Add("page_size", strconv.Itoa(size))
@@ -72,11 +80,11 @@ func (api *APIClient) UsersPaginator(ctx context.Context, pageSize int) *Paginat
 
 		var res paginatedResponse[User]
 		if _, err = api.request(ctx, http.MethodGet, path, nil, &res); err != nil {
-			return nil, 0, err
+			return nil, pagination{}, err
 		}
-		return res.List, res.Pagination.NextPage, nil
+		return res.List, res.Pagination, nil
 	}
-	return newPaginator(api, fetch, pageSize)
+	return newPaginator(api, fetch, pageSize).resume(checkpoint)
 }
 
 // CreateUser creates a new user in your Border0 organization. User email must
@@ -0,0 +1,314 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/borderzero/border0-go/lib/debouncer"
+	"github.com/borderzero/border0-go/lib/types/jsoneq"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultWatchInterval   = 30 * time.Second
+	defaultWatchDebounce   = time.Second
+	defaultWatchMaxWait    = 10 * time.Second
+	defaultWatchMaxBackoff = 5 * time.Minute
+)
+
+// EventType is the type of change of a resource, see Event.
+type EventType string
+
+// Types of changes of resources.
+const (
+	EventAdded    EventType = "added"
+	EventModified EventType = "modified"
+	EventDeleted  EventType = "deleted"
+)
+
+// Event is a change of a resource, see Watcher.
+type Event[T any] struct {
+	Type     EventType
+	ID       string
+	Object   T // the resource after the change, or as it was last seen if it was deleted
+	Previous T // the resource before the change, only set for modified resources
+}
+
+// Watcher watches a kind of resources, by listing them periodically with a paginator, and
+// comparing them with the resources of the previous listing by id. Changes are coalesced with
+// a debouncer before they're emitted, so a burst of changes of a resource results in a single
+// event, and listing is backed off when it fails.
+//
+// Watchers are created with NewWatcher, or with WatchSockets, WatchPolicies, WatchConnectors
+// and WatchGroups, and configured with the With... methods before Events is called.
+type Watcher[T any] struct {
+	list       func(ctx context.Context) *Paginator[T]
+	id         func(T) string
+	interval   time.Duration
+	debounce   time.Duration
+	maxWait    time.Duration
+	maxBackoff time.Duration
+}
+
+// NewWatcher returns a watcher of the resources listed by the paginators returned by the given
+// function, which are told apart by the ids returned by the given function.
+func NewWatcher[T any](list func(ctx context.Context) *Paginator[T], id func(T) string) *Watcher[T] {
+	return &Watcher[T]{
+		list:       list,
+		id:         id,
+		interval:   defaultWatchInterval,
+		debounce:   defaultWatchDebounce,
+		maxWait:    defaultWatchMaxWait,
+		maxBackoff: defaultWatchMaxBackoff,
+	}
+}
+
+// WatchSockets returns a watcher of the sockets matching the given filters.
+func WatchSockets(api SocketService, filters ...SocketFilter) *Watcher[Socket] {
+	return NewWatcher(
+		func(ctx context.Context) *Paginator[Socket] {
+			return api.SocketsPaginator(ctx, defaultPageSizeSockets, filters...)
+		},
+		func(socket Socket) string { return socket.SocketID },
+	)
+}
+
+// WatchPolicies returns a watcher of the policies.
+func WatchPolicies(api PolicyService) *Watcher[Policy] {
+	return NewWatcher(
+		func(ctx context.Context) *Paginator[Policy] { return api.PoliciesPaginator(ctx, defaultPageSize) },
+		func(policy Policy) string { return policy.ID },
+	)
+}
+
+// WatchConnectors returns a watcher of the connectors.
+func WatchConnectors(api ConnectorService) *Watcher[Connector] {
+	return NewWatcher(
+		func(ctx context.Context) *Paginator[Connector] { return api.ConnectorsPaginator(ctx, defaultPageSize) },
+		func(connector Connector) string { return connector.ConnectorID },
+	)
+}
+
+// WatchGroups returns a watcher of the groups. Groups are listed with their members, so
+// changes of group memberships are modified groups.
+func WatchGroups(api GroupService) *Watcher[Group] {
+	return NewWatcher(
+		func(ctx context.Context) *Paginator[Group] { return api.GroupsPaginator(ctx, defaultPageSizeGroups) },
+		func(group Group) string { return group.ID },
+	)
+}
+
+// WithInterval sets the time between listings of the resources. Defaults to 30 seconds.
+func (w *Watcher[T]) WithInterval(interval time.Duration) *Watcher[T] {
+	w.interval = interval
+	return w
+}
+
+// WithDebounce sets how long changes are held back for more changes to coalesce with them,
+// and the maximum time changes are held back for, even if they keep coming. Defaults to 1
+// second and 10 seconds.
+func (w *Watcher[T]) WithDebounce(debounce, maxWait time.Duration) *Watcher[T] {
+	w.debounce = debounce
+	w.maxWait = maxWait
+	return w
+}
+
+// WithMaxBackoff sets the maximum time between listings of the resources when listing them
+// keeps failing. The time between listings starts at the interval, and grows exponentially
+// with each failure. Defaults to 5 minutes.
+func (w *Watcher[T]) WithMaxBackoff(maxBackoff time.Duration) *Watcher[T] {
+	w.maxBackoff = maxBackoff
+	return w
+}
+
+// Events returns an iterator over the changes of the resources, until the context is done or
+// the caller stops iterating. The resources of the first listing are added resources. Errors
+// listing the resources are yielded along with the zero value of Event, and the watcher keeps
+// going, after backing off.
+func (w *Watcher[T]) Events(ctx context.Context) iter.Seq2[Event[T], error] {
+	return func(yield func(Event[T], error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		batches := make(chan []Event[T])
+		errs := make(chan error)
+
+		var mu sync.Mutex
+		pending := map[string]Event[T]{}
+		debounce := debouncer.New(func(struct{}) {
+			mu.Lock()
+			batch := make([]Event[T], 0, len(pending))
+			for _, event := range pending {
+				batch = append(batch, event)
+			}
+			pending = map[string]Event[T]{}
+			mu.Unlock()
+
+			if len(batch) == 0 {
+				return
+			}
+			sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID })
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+			}
+		}, debouncer.WithDebounceTime(w.debounce), debouncer.WithMaxWaitTime(w.maxWait))
+
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			wg.Wait()
+			debounce.Close()
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx, errs, func(events []Event[T]) {
+				mu.Lock()
+				for _, event := range events {
+					if merged, ok := coalesce(pending, event); ok {
+						pending[event.ID] = merged
+					} else {
+						delete(pending, event.ID)
+					}
+				}
+				mu.Unlock()
+				debounce.Do(struct{}{})
+			})
+		}()
+
+		for {
+			select {
+			case batch := <-batches:
+				for _, event := range batch {
+					if !yield(event, nil) {
+						return
+					}
+				}
+			case err := <-errs:
+				var zero Event[T]
+				if !yield(zero, err) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// poll lists the resources until the context is done, and calls changed with the changes of
+// each listing, if any. Errors are sent on the given channel.
+func (w *Watcher[T]) poll(ctx context.Context, errs chan<- error, changed func([]Event[T])) {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = w.interval
+	bo.MaxInterval = max(w.maxBackoff, w.interval)
+	bo.MaxElapsedTime = 0
+
+	var previous map[string]T
+	for {
+		wait := w.interval
+		current, err := w.snapshot(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			wait = bo.NextBackOff()
+			select {
+			case errs <- err:
+			case <-ctx.Done():
+				return
+			}
+		default:
+			bo.Reset()
+			if events := diff(previous, current); len(events) > 0 {
+				changed(events)
+			}
+			previous = current
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// snapshot lists the resources, by id.
+func (w *Watcher[T]) snapshot(ctx context.Context) (map[string]T, error) {
+	items, err := Collect(w.list(ctx).Items(ctx))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]T, len(items))
+	for _, item := range items {
+		snapshot[w.id(item)] = item
+	}
+	return snapshot, nil
+}
+
+// diff returns the changes between two listings of resources, by id.
+func diff[T any](previous, current map[string]T) []Event[T] {
+	var events []Event[T]
+	for id, object := range current {
+		old, ok := previous[id]
+		switch {
+		case !ok:
+			events = append(events, Event[T]{Type: EventAdded, ID: id, Object: object})
+		case !equal(old, object):
+			events = append(events, Event[T]{Type: EventModified, ID: id, Object: object, Previous: old})
+		}
+	}
+	for id, object := range previous {
+		if _, ok := current[id]; !ok {
+			events = append(events, Event[T]{Type: EventDeleted, ID: id, Object: object})
+		}
+	}
+	return events
+}
+
+// coalesce merges the given change of a resource with the pending change of the resource, if
+// any. It returns false if the changes cancel each other out, e.g. a resource which was added
+// and then deleted.
+func coalesce[T any](pending map[string]Event[T], next Event[T]) (Event[T], bool) {
+	prev, ok := pending[next.ID]
+	if !ok {
+		return next, true
+	}
+	switch {
+	case prev.Type == EventAdded && next.Type == EventDeleted:
+		return next, false
+	case prev.Type == EventAdded:
+		return Event[T]{Type: EventAdded, ID: next.ID, Object: next.Object}, true
+	case prev.Type == EventDeleted && next.Type == EventAdded:
+		if equal(prev.Object, next.Object) {
+			return next, false
+		}
+		return Event[T]{Type: EventModified, ID: next.ID, Object: next.Object, Previous: prev.Object}, true
+	case prev.Type == EventModified && next.Type == EventModified:
+		if equal(prev.Previous, next.Object) {
+			return next, false
+		}
+		return Event[T]{Type: EventModified, ID: next.ID, Object: next.Object, Previous: prev.Previous}, true
+	default:
+		return next, true
+	}
+}
+
+// equal reports whether two resources are the same, by their json representation, so that
+// the order of lists (e.g. the members of groups) does not matter.
+func equal[T any](a, b T) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return jsoneq.AreEqual(string(aJSON), string(bJSON))
+}
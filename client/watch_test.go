@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listings is a stand-in for the listings of sockets over time: each listing returns the next
+// sockets, and the last sockets once they're all listed. The first listings can be failed.
+type listings struct {
+	mu       sync.Mutex
+	sockets  [][]Socket
+	failures int
+	calls    int
+}
+
+func (l *listings) paginator(context.Context) *Paginator[Socket] {
+	return NewPaginator(defaultPageSizeSockets, func(context.Context, int, int) ([]Socket, int, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.calls++
+		if l.calls <= l.failures {
+			return nil, 0, errors.New("failed to list sockets")
+		}
+		sockets := l.sockets[0]
+		if len(l.sockets) > 1 {
+			l.sockets = l.sockets[1:]
+		}
+		return sockets, 0, nil
+	})
+}
+
+func socketID(socket Socket) string { return socket.SocketID }
+
+// collectEvents returns the first n events of the given watcher, or fails the test after a second.
+func collectEvents(t *testing.T, watcher *Watcher[Socket], n int) ([]Event[Socket], []error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var events []Event[Socket]
+	var errs []error
+	for event, err := range watcher.Events(ctx) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, event)
+		if len(events) == n {
+			break
+		}
+	}
+	require.Len(t, events, n, "timed out waiting for events")
+	return events, errs
+}
+
+func Test_Watcher_Events(t *testing.T) {
+	t.Parallel()
+
+	web := Socket{SocketID: "1", Name: "web", SocketType: "http"}
+	webUpdated := Socket{SocketID: "1", Name: "web", SocketType: "http", Description: "updated"}
+	db := Socket{SocketID: "2", Name: "db", SocketType: "database"}
+	ssh := Socket{SocketID: "3", Name: "ssh", SocketType: "ssh"}
+
+	tests := []struct {
+		name       string
+		listings   *listings
+		interval   time.Duration
+		debounce   time.Duration
+		wantEvents []Event[Socket]
+		wantErrs   int
+	}{
+		{
+			name:     "changes of each listing",
+			listings: &listings{sockets: [][]Socket{{web, db}, {webUpdated, db}, {webUpdated}}},
+			interval: 30 * time.Millisecond,
+			debounce: time.Millisecond,
+			wantEvents: []Event[Socket]{
+				{Type: EventAdded, ID: "1", Object: web},
+				{Type: EventAdded, ID: "2", Object: db},
+				{Type: EventModified, ID: "1", Object: webUpdated, Previous: web},
+				{Type: EventDeleted, ID: "2", Object: db},
+			},
+		},
+		{
+			name:     "bursts of changes are coalesced",
+			listings: &listings{sockets: [][]Socket{{web}, {webUpdated, db}, {webUpdated, ssh}, {web, ssh}}},
+			interval: time.Millisecond,
+			debounce: 100 * time.Millisecond,
+			wantEvents: []Event[Socket]{
+				{Type: EventAdded, ID: "1", Object: web},
+				{Type: EventAdded, ID: "3", Object: ssh},
+			},
+		},
+		{
+			name:     "listing errors are yielded",
+			listings: &listings{sockets: [][]Socket{{web}}, failures: 2},
+			interval: time.Millisecond,
+			debounce: time.Millisecond,
+			wantEvents: []Event[Socket]{
+				{Type: EventAdded, ID: "1", Object: web},
+			},
+			wantErrs: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			watcher := NewWatcher(test.listings.paginator, socketID).
+				WithInterval(test.interval).
+				WithDebounce(test.debounce, time.Second).
+				WithMaxBackoff(10 * time.Millisecond)
+
+			events, errs := collectEvents(t, watcher, len(test.wantEvents))
+			assert.Equal(t, test.wantEvents, events)
+			assert.Len(t, errs, test.wantErrs)
+			for _, err := range errs {
+				assert.EqualError(t, err, "failed to list sockets")
+			}
+		})
+	}
+}
+
+func Test_Watcher_Events_stop(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &listings{sockets: [][]Socket{{{SocketID: "1", Name: "web"}}}}
+	watcher := NewWatcher(l.paginator, socketID).WithInterval(time.Millisecond).WithDebounce(time.Millisecond, time.Second)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range watcher.Events(ctx) {
+			cancel()
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the watcher did not stop when its context was canceled")
+	}
+}
+
+func Test_diff(t *testing.T) {
+	t.Parallel()
+
+	web := Socket{SocketID: "1", Name: "web", ConnectorIDs: []string{"a", "b"}}
+	reordered := Socket{SocketID: "1", Name: "web", ConnectorIDs: []string{"b", "a"}}
+	updated := Socket{SocketID: "1", Name: "web", Description: "updated"}
+	db := Socket{SocketID: "2", Name: "db"}
+
+	tests := []struct {
+		name     string
+		previous map[string]Socket
+		current  map[string]Socket
+		want     []Event[Socket]
+	}{
+		{
+			name:    "first listing",
+			current: map[string]Socket{"1": web},
+			want:    []Event[Socket]{{Type: EventAdded, ID: "1", Object: web}},
+		},
+		{
+			name:     "no changes",
+			previous: map[string]Socket{"1": web, "2": db},
+			current:  map[string]Socket{"1": reordered, "2": db},
+		},
+		{
+			name:     "modified and deleted",
+			previous: map[string]Socket{"1": web, "2": db},
+			current:  map[string]Socket{"1": updated},
+			want: []Event[Socket]{
+				{Type: EventModified, ID: "1", Object: updated, Previous: web},
+				{Type: EventDeleted, ID: "2", Object: db},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, diff(test.previous, test.current))
+		})
+	}
+}
+
+func Test_coalesce(t *testing.T) {
+	t.Parallel()
+
+	web := Socket{SocketID: "1", Name: "web"}
+	updated := Socket{SocketID: "1", Name: "web", Description: "updated"}
+	updatedAgain := Socket{SocketID: "1", Name: "web", Description: "updated again"}
+
+	tests := []struct {
+		name     string
+		pending  *Event[Socket]
+		next     Event[Socket]
+		want     Event[Socket]
+		wantKeep bool
+	}{
+		{
+			name:     "nothing pending",
+			next:     Event[Socket]{Type: EventAdded, ID: "1", Object: web},
+			want:     Event[Socket]{Type: EventAdded, ID: "1", Object: web},
+			wantKeep: true,
+		},
+		{
+			name:     "added then modified",
+			pending:  &Event[Socket]{Type: EventAdded, ID: "1", Object: web},
+			next:     Event[Socket]{Type: EventModified, ID: "1", Object: updated, Previous: web},
+			want:     Event[Socket]{Type: EventAdded, ID: "1", Object: updated},
+			wantKeep: true,
+		},
+		{
+			name:    "added then deleted",
+			pending: &Event[Socket]{Type: EventAdded, ID: "1", Object: web},
+			next:    Event[Socket]{Type: EventDeleted, ID: "1", Object: web},
+		},
+		{
+			name:     "modified twice",
+			pending:  &Event[Socket]{Type: EventModified, ID: "1", Object: updated, Previous: web},
+			next:     Event[Socket]{Type: EventModified, ID: "1", Object: updatedAgain, Previous: updated},
+			want:     Event[Socket]{Type: EventModified, ID: "1", Object: updatedAgain, Previous: web},
+			wantKeep: true,
+		},
+		{
+			name:    "modified and reverted",
+			pending: &Event[Socket]{Type: EventModified, ID: "1", Object: updated, Previous: web},
+			next:    Event[Socket]{Type: EventModified, ID: "1", Object: web, Previous: updated},
+		},
+		{
+			name:     "modified then deleted",
+			pending:  &Event[Socket]{Type: EventModified, ID: "1", Object: updated, Previous: web},
+			next:     Event[Socket]{Type: EventDeleted, ID: "1", Object: updated},
+			want:     Event[Socket]{Type: EventDeleted, ID: "1", Object: updated},
+			wantKeep: true,
+		},
+		{
+			name:     "deleted then added back",
+			pending:  &Event[Socket]{Type: EventDeleted, ID: "1", Object: web},
+			next:     Event[Socket]{Type: EventAdded, ID: "1", Object: updated},
+			want:     Event[Socket]{Type: EventModified, ID: "1", Object: updated, Previous: web},
+			wantKeep: true,
+		},
+		{
+			name:    "deleted then added back unchanged",
+			pending: &Event[Socket]{Type: EventDeleted, ID: "1", Object: web},
+			next:    Event[Socket]{Type: EventAdded, ID: "1", Object: web},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			pending := map[string]Event[Socket]{}
+			if test.pending != nil {
+				pending[test.pending.ID] = *test.pending
+			}
+			got, keep := coalesce(pending, test.next)
+			assert.Equal(t, test.wantKeep, keep)
+			if test.wantKeep {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
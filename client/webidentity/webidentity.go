@@ -0,0 +1,208 @@
+// Package webidentity provides web identity token providers for CI/CD platforms and
+// other workload identity environments. Every provider is a client.TokenSource that
+// returns a web identity (OIDC) token, to be exchanged for a Border0 service account
+// token with client.WithWebIdentity.
+//
+// Example:
+//
+//	api := client.New(
+//		client.WithWebIdentity(
+//			"my-org",                              // Border0 organization subdomain
+//			"github-deployer",                     // Border0 service account name
+//			webidentity.GitHubActions("border0"), // audience of the GitHub Actions OIDC token
+//		),
+//	)
+package webidentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/borderzero/border0-go/client"
+)
+
+const (
+	envGitHubRequestURL   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envGitHubRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	envGitLabDefaultIDToken = "BORDER0_ID_TOKEN"
+	envGitLabJobJWTV2       = "CI_JOB_JWT_V2"
+	envGitLabJobJWT         = "CI_JOB_JWT"
+
+	// DefaultKubernetesTokenPath is the path of the service account token
+	// mounted into Kubernetes pods by default.
+	DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// ensure providers implement client.TokenSource at compile-time.
+var (
+	_ client.TokenSource = (*GitHubActionsProvider)(nil)
+	_ client.TokenSource = (*GitLabCIProvider)(nil)
+	_ client.TokenSource = (*FileProvider)(nil)
+	_ client.TokenSource = (*EnvProvider)(nil)
+)
+
+// GitHubActionsProvider provides GitHub Actions OIDC tokens. The workflow job must
+// have the `id-token: write` permission.
+type GitHubActionsProvider struct {
+	Audience     string       // audience of the requested token, optional
+	RequestURL   string       // defaults to the ACTIONS_ID_TOKEN_REQUEST_URL env var
+	RequestToken string       // defaults to the ACTIONS_ID_TOKEN_REQUEST_TOKEN env var
+	HTTPClient   *http.Client // defaults to http.DefaultClient
+}
+
+// GitHubActions returns a provider of GitHub Actions OIDC tokens for the given audience.
+func GitHubActions(audience string) *GitHubActionsProvider {
+	return &GitHubActionsProvider{Audience: audience}
+}
+
+// Token requests a new OIDC token from the GitHub Actions token endpoint.
+func (p *GitHubActionsProvider) Token(ctx context.Context) (string, error) {
+	requestURL := valueOrEnv(p.RequestURL, envGitHubRequestURL)
+	requestToken := valueOrEnv(p.RequestToken, envGitHubRequestToken)
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("%s and %s must be set, make sure the job has the id-token: write permission", envGitHubRequestURL, envGitHubRequestToken)
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub Actions token request url: %w", err)
+	}
+	if p.Audience != "" {
+		query := u.Query()
+		query.Set("audience", p.Audience)
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub Actions token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request GitHub Actions token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request GitHub Actions token: unexpected status code %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode GitHub Actions token response: %w", err)
+	}
+	if out.Value == "" {
+		return "", errors.New("GitHub Actions token response has no token")
+	}
+	return out.Value, nil
+}
+
+// GitLabCIProvider provides GitLab CI OIDC tokens. GitLab exposes ID tokens, configured
+// with the `id_tokens` keyword of a job, as env vars.
+type GitLabCIProvider struct {
+	Variable string // env var holding the ID token, see GitLabCI for the defaults
+}
+
+// GitLabCI returns a provider of GitLab CI OIDC tokens read from the given env var, as
+// named under the job's `id_tokens` keyword. If no env var name is given, BORDER0_ID_TOKEN
+// is used, falling back to the (deprecated) CI_JOB_JWT_V2 and CI_JOB_JWT env vars.
+func GitLabCI(variable string) *GitLabCIProvider {
+	return &GitLabCIProvider{Variable: variable}
+}
+
+// Token returns the ID token from the environment.
+func (p *GitLabCIProvider) Token(context.Context) (string, error) {
+	variables := []string{p.Variable}
+	if p.Variable == "" {
+		variables = []string{envGitLabDefaultIDToken, envGitLabJobJWTV2, envGitLabJobJWT}
+	}
+	for _, variable := range variables {
+		if token := strings.TrimSpace(os.Getenv(variable)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("no GitLab CI ID token found in %s, configure one with the id_tokens keyword", strings.Join(variables, ", "))
+}
+
+// FileProvider provides web identity tokens read from a file. The file is read on every
+// call, so tokens rotated by an external process (e.g. the kubelet) are picked up.
+type FileProvider struct {
+	Path string
+}
+
+// File returns a provider of web identity tokens read from the given file.
+func File(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+// KubernetesServiceAccount returns a provider of Kubernetes service account tokens read
+// from the given file, typically a projected service account token volume with Border0
+// as the audience. If no path is given, DefaultKubernetesTokenPath is used.
+func KubernetesServiceAccount(path string) *FileProvider {
+	if path == "" {
+		path = DefaultKubernetesTokenPath
+	}
+	return File(path)
+}
+
+// Token reads the token from the file, see client.FileTokenSource.
+func (p *FileProvider) Token(ctx context.Context) (string, error) {
+	return client.FileTokenSource(p.Path).Token(ctx)
+}
+
+// EnvProvider provides web identity tokens read from an env var.
+type EnvProvider struct {
+	Variable string
+}
+
+// Env returns a provider of web identity tokens read from the given env var.
+func Env(variable string) *EnvProvider {
+	return &EnvProvider{Variable: variable}
+}
+
+// Token reads the token from the env var.
+func (p *EnvProvider) Token(context.Context) (string, error) {
+	token := strings.TrimSpace(os.Getenv(p.Variable))
+	if token == "" {
+		return "", fmt.Errorf("web identity token env var %s is not set", p.Variable)
+	}
+	return token, nil
+}
+
+// Detect returns the provider for the environment the process runs in: GitHub Actions
+// (requesting tokens for the given audience), GitLab CI, or Kubernetes, in that order.
+func Detect(audience string) (client.TokenSource, error) {
+	if os.Getenv(envGitHubRequestURL) != "" {
+		return GitHubActions(audience), nil
+	}
+	if os.Getenv("GITLAB_CI") != "" {
+		return GitLabCI(""), nil
+	}
+	if _, err := os.Stat(DefaultKubernetesTokenPath); err == nil {
+		return KubernetesServiceAccount(""), nil
+	}
+	return nil, errors.New("no supported web identity environment detected")
+}
+
+func valueOrEnv(value, variable string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(variable)
+}
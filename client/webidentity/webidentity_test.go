@@ -0,0 +1,196 @@
+package webidentity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/borderzero/border0-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GitHubActionsProvider_Token(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		givenToken string
+		wantToken  string
+		wantErr    string
+	}{
+		{
+			name: "happy path",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+				assert.Equal(t, "border0", r.URL.Query().Get("audience"))
+				assert.Equal(t, "1", r.URL.Query().Get("api-version"))
+				w.Write([]byte(`{"value":"github-oidc-token"}`))
+			},
+			givenToken: "request-token",
+			wantToken:  "github-oidc-token",
+		},
+		{
+			name: "token endpoint rejects the request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			givenToken: "request-token",
+			wantErr:    "failed to request GitHub Actions token: unexpected status code 403",
+		},
+		{
+			name: "empty token in response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{}`))
+			},
+			givenToken: "request-token",
+			wantErr:    "GitHub Actions token response has no token",
+		},
+		{
+			name:    "missing request token",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			wantErr: "ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN must be set, make sure the job has the id-token: write permission",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(test.handler)
+			defer ts.Close()
+
+			provider := GitHubActions("border0")
+			provider.RequestURL = ts.URL + "/token?api-version=1"
+			provider.RequestToken = test.givenToken
+			provider.HTTPClient = ts.Client()
+
+			got, err := provider.Token(context.Background())
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantToken, got)
+		})
+	}
+}
+
+func Test_GitHubActionsProvider_Token_fromEnv(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer env-request-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"value":"github-oidc-token"}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv(envGitHubRequestURL, ts.URL)
+	t.Setenv(envGitHubRequestToken, "env-request-token")
+
+	got, err := GitHubActions("").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "github-oidc-token", got)
+
+	detected, err := Detect("border0")
+	assert.NoError(t, err)
+	assert.IsType(t, &GitHubActionsProvider{}, detected)
+}
+
+func Test_GitLabCIProvider_Token(t *testing.T) {
+	t.Setenv(envGitLabJobJWTV2, "deprecated-token")
+
+	got, err := GitLabCI("").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "deprecated-token", got)
+
+	t.Setenv(envGitLabDefaultIDToken, "id-token")
+	got, err = GitLabCI("").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "id-token", got)
+
+	_, err = GitLabCI("MY_ID_TOKEN").Token(context.Background())
+	assert.EqualError(t, err, "no GitLab CI ID token found in MY_ID_TOKEN, configure one with the id_tokens keyword")
+
+	t.Setenv("MY_ID_TOKEN", "custom-token")
+	got, err = GitLabCI("MY_ID_TOKEN").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "custom-token", got)
+}
+
+func Test_EnvProvider_Token(t *testing.T) {
+	_, err := Env("BORDER0_TEST_WEB_IDENTITY_TOKEN").Token(context.Background())
+	assert.EqualError(t, err, "web identity token env var BORDER0_TEST_WEB_IDENTITY_TOKEN is not set")
+
+	t.Setenv("BORDER0_TEST_WEB_IDENTITY_TOKEN", "env-token\n")
+	got, err := Env("BORDER0_TEST_WEB_IDENTITY_TOKEN").Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "env-token", got)
+}
+
+func Test_FileProvider_Token(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "token")
+	provider := KubernetesServiceAccount(path)
+
+	_, err := provider.Token(context.Background())
+	assert.ErrorContains(t, err, "failed to read token file")
+
+	require.NoError(t, os.WriteFile(path, []byte("first-token\n"), 0600))
+	got, err := provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "first-token", got)
+
+	// rotated token is picked up
+	require.NoError(t, os.WriteFile(path, []byte("second-token\n"), 0600))
+	got, err = provider.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "second-token", got)
+
+	assert.Equal(t, DefaultKubernetesTokenPath, KubernetesServiceAccount("").Path)
+}
+
+func Test_WithWebIdentity(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("k8s-token"), 0600))
+
+	var exchanges atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/web_identity/exchange":
+			exchanges.Add(1)
+			var in client.WebIdentityTokenExchangeInput
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			assert.Equal(t, "k8s-token", in.WebIdentityToken)
+			assert.Equal(t, "test-org", in.OrganizationSubdomain)
+			assert.Equal(t, "test-sa", in.ServiceAccountName)
+			w.Write([]byte(`{"token":"border0-token"}`))
+		case "/connector/test-id":
+			assert.Equal(t, "Bearer border0-token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"connector_id":"test-id"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	api := client.New(
+		client.WithBaseURL(ts.URL),
+		client.WithWebIdentity("test-org", "test-sa", KubernetesServiceAccount(path)),
+	)
+
+	for i := 0; i < 2; i++ {
+		connector, err := api.Connector(context.Background(), "test-id")
+		require.NoError(t, err)
+		assert.Equal(t, "test-id", connector.ConnectorID)
+	}
+	assert.Equal(t, int32(1), exchanges.Load())
+}
@@ -0,0 +1,485 @@
+// Package qrcode encodes short texts, like URLs, into QR codes (ISO/IEC 18004), and renders
+// them for terminals. Only the byte mode and versions 1 to 10 are supported, which is plenty
+// for URLs of up to a couple hundred bytes.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is the error correction level of a QR code.
+type Level int
+
+const (
+	// LevelL recovers about 7% of the data, it's the most compact level.
+	LevelL Level = iota
+	// LevelM recovers about 15% of the data.
+	LevelM
+)
+
+// maxVersion is the highest version (size) of QR codes supported.
+const maxVersion = 10
+
+// ErrTooLong is returned when the text does not fit into the largest QR code supported.
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// blocks is the error correction block structure of a version and level: the number of
+// error correction codewords per block, and the number of data codewords of each block.
+type blocks struct {
+	ecPerBlock int
+	data       []int
+}
+
+// blockTable holds the block structures of each level, indexed by version - 1.
+var blockTable = map[Level][maxVersion]blocks{
+	LevelL: {
+		{7, []int{19}},
+		{10, []int{34}},
+		{15, []int{55}},
+		{20, []int{80}},
+		{26, []int{108}},
+		{18, []int{68, 68}},
+		{20, []int{78, 78}},
+		{24, []int{97, 97}},
+		{30, []int{116, 116}},
+		{18, []int{68, 68, 69, 69}},
+	},
+	LevelM: {
+		{10, []int{16}},
+		{16, []int{28}},
+		{26, []int{44}},
+		{18, []int{32, 32}},
+		{24, []int{43, 43}},
+		{16, []int{27, 27, 27, 27}},
+		{18, []int{31, 31, 31, 31}},
+		{22, []int{38, 38, 39, 39}},
+		{22, []int{36, 36, 36, 37, 37}},
+		{26, []int{43, 43, 43, 43, 44}},
+	},
+}
+
+// alignmentTable holds the centers of the alignment patterns, indexed by version - 1.
+var alignmentTable = [maxVersion][]int{
+	{},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// Code is a QR code.
+type Code struct {
+	size     int
+	modules  [][]bool // dark modules, indexed by row and column
+	function [][]bool // modules of function patterns, which are not masked
+}
+
+// Encode encodes the given text into the smallest QR code that fits it at the given level.
+func Encode(text string, level Level) (*Code, error) {
+	levelBlocks, ok := blockTable[level]
+	if !ok {
+		return nil, fmt.Errorf("invalid error correction level %d", level)
+	}
+	for version := 1; version <= maxVersion; version++ {
+		if data, ok := encodeData([]byte(text), version, levelBlocks[version-1]); ok {
+			return newCode(version, level, addErrorCorrection(data, levelBlocks[version-1])), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// Size returns the number of modules on each side of the QR code, without the quiet zone.
+func (c *Code) Size() int { return c.size }
+
+// Dark reports whether the module at the given column and row is dark.
+func (c *Code) Dark(x, y int) bool { return c.modules[y][x] }
+
+// Terminal returns the QR code drawn with block characters, two rows of modules per line,
+// with the 4 modules wide quiet zone of the spec around it, which scanners need to find the
+// code. Light modules are drawn, so that the code is dark on light in terminals with a dark
+// background, the most common ones. Most QR code scanners read codes inverted all the same.
+func (c *Code) Terminal() string {
+	const quietZone = 4
+	light := func(x, y int) bool {
+		x, y = x-quietZone, y-quietZone
+		return x < 0 || y < 0 || x >= c.size || y >= c.size || !c.modules[y][x]
+	}
+	var b strings.Builder
+	for y := 0; y < c.size+2*quietZone; y += 2 {
+		for x := 0; x < c.size+2*quietZone; x++ {
+			top, bottom := light(x, y), y+1 < c.size+2*quietZone && light(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// encodeData returns the data codewords of the given bytes in the byte mode, padded to the
+// capacity of the given version, and whether they fit.
+func encodeData(text []byte, version int, structure blocks) ([]byte, bool) {
+	capacity := 0
+	for _, n := range structure.data {
+		capacity += n
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	if 4+countBits+8*len(text) > 8*capacity {
+		return nil, false
+	}
+
+	var bits bitBuffer
+	bits.append(0b0100, 4) // byte mode
+	bits.append(len(text), countBits)
+	for _, b := range text {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, 8*capacity-bits.len())) // terminator
+	bits.append(0, (8-bits.len()%8)%8)
+	data := bits.bytes()
+	for pad := byte(0xEC); len(data) < capacity; pad ^= 0xEC ^ 0x11 {
+		data = append(data, pad)
+	}
+	return data, true
+}
+
+// addErrorCorrection splits the data codewords into blocks, and returns the codewords of
+// the blocks interleaved, followed by their error correction codewords interleaved.
+func addErrorCorrection(data []byte, structure blocks) []byte {
+	generator := rsGenerator(structure.ecPerBlock)
+	var dataBlocks, ecBlocks [][]byte
+	for _, n := range structure.data {
+		dataBlocks = append(dataBlocks, data[:n])
+		ecBlocks = append(ecBlocks, rsRemainder(data[:n], generator))
+		data = data[n:]
+	}
+	var codewords []byte
+	for i := 0; i < structure.data[len(structure.data)-1]; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+	for i := 0; i < structure.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			codewords = append(codewords, block[i])
+		}
+	}
+	return codewords
+}
+
+// newCode draws a QR code of the given version with the given codewords, with the mask that
+// has the lowest penalty.
+func newCode(version int, level Level, codewords []byte) *Code {
+	size := 17 + 4*version
+	c := &Code{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for y := range size {
+		c.modules[y], c.function[y] = make([]bool, size), make([]bool, size)
+	}
+	c.drawFunctionPatterns(version)
+	c.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		c.applyMask(mask)
+		c.drawFormat(level, mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // masks are undone by applying them again
+	}
+	c.applyMask(best)
+	c.drawFormat(level, best)
+	return c
+}
+
+// set sets the module at the given column and row as a module of a function pattern.
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x], c.function[y][x] = dark, true
+}
+
+// drawFunctionPatterns draws the finder, timing, and alignment patterns, and the version
+// information, and reserves the modules of the format information.
+func (c *Code) drawFunctionPatterns(version int) {
+	for i := range c.size {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, center := range [][2]int{{3, 3}, {c.size - 4, 3}, {3, c.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x >= 0 && y >= 0 && x < c.size && y < c.size {
+					distance := max(abs(dx), abs(dy))
+					c.set(x, y, distance != 2 && distance != 4)
+				}
+			}
+		}
+	}
+	centers := alignmentTable[version-1]
+	for i, cx := range centers {
+		for j, cy := range centers {
+			last := len(centers) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // overlaps a finder pattern
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	c.drawFormat(LevelL, 0) // reserves the modules, drawn for real once the mask is chosen
+
+	if version >= 7 {
+		bits := version<<12 | bchRemainder(version, 0x1F25, 12)
+		for i := range 18 {
+			a, b := c.size-11+i%3, i/3
+			c.set(a, b, bits>>i&1 == 1)
+			c.set(b, a, bits>>i&1 == 1)
+		}
+	}
+}
+
+// formatBits returns the 15 bits of the format information of the given level and mask.
+func formatBits(level Level, mask int) int {
+	data := mask
+	if level == LevelL {
+		data |= 0b01 << 3
+	}
+	return (data<<10 | bchRemainder(data, 0x537, 10)) ^ 0x5412
+}
+
+// drawFormat draws both copies of the format information, and the dark module.
+func (c *Code) drawFormat(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := range 6 {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := range 8 {
+		c.set(c.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.size-15+i, bit(i))
+	}
+	c.set(8, c.size-8, true)
+}
+
+// drawCodewords draws the codewords in the zigzag order, two columns at a time from the
+// bottom right corner, skipping the modules of function patterns.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vertical := range c.size {
+			y := vertical
+			if upward {
+				y = c.size - 1 - vertical
+			}
+			for _, x := range []int{right, right - 1} {
+				if !c.function[y][x] && i < 8*len(codewords) {
+					c.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the modules which are not part of function patterns where the given mask
+// pattern is true.
+func (c *Code) applyMask(mask int) {
+	for y := range c.size {
+		for x := range c.size {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !c.function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the QR code is to scan, the lower the better.
+func (c *Code) penalty() int {
+	penalty, dark := 0, 0
+	finderLike := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	for i := range c.size {
+		row := func(j int) bool { return c.modules[i][j] }
+		column := func(j int) bool { return c.modules[j][i] }
+		for _, line := range []func(int) bool{row, column} {
+			// runs of five or more modules of the same color
+			run := 1
+			for j := 1; j <= c.size; j++ {
+				if j < c.size && line(j) == line(j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			// patterns looking like finder patterns, with light modules before or after them
+			for j := 0; j+len(finderLike) <= c.size; j++ {
+				forward, backward := true, true
+				for k, want := range finderLike {
+					forward = forward && line(j+k) == want
+					backward = backward && line(j+len(finderLike)-1-k) == want
+				}
+				if forward {
+					penalty += 40
+				}
+				if backward {
+					penalty += 40
+				}
+			}
+		}
+		for j := range c.size {
+			if c.modules[i][j] {
+				dark++
+			}
+			// 2x2 blocks of the same color
+			if i+1 < c.size && j+1 < c.size {
+				color := c.modules[i][j]
+				if c.modules[i][j+1] == color && c.modules[i+1][j] == color && c.modules[i+1][j+1] == color {
+					penalty += 3
+				}
+			}
+		}
+	}
+	// deviation of the proportion of dark modules from 50%
+	percent := 100 * dark / (c.size * c.size)
+	return penalty + 10*(abs(percent-50)/5)
+}
+
+// bchRemainder returns the remainder of the division of the given value, shifted left by
+// the given number of bits, by the given generator polynomial.
+func bchRemainder(value, generator, bits int) int {
+	remainder := value
+	for range bits {
+		remainder = remainder<<1 ^ (remainder>>(bits-1))*generator
+	}
+	return remainder
+}
+
+// gfMultiply multiplies two elements of GF(2^8), with the QR code polynomial 0x11D.
+func gfMultiply(a, b byte) byte {
+	var product byte
+	for i := 7; i >= 0; i-- {
+		carry := product >> 7
+		product = product<<1 ^ carry*0x1D
+		product ^= (b >> i & 1) * a
+	}
+	return product
+}
+
+// rsGenerator returns the coefficients of the Reed-Solomon generator polynomial of the given
+// degree, from the highest to the lowest power, without the leading 1.
+func rsGenerator(degree int) []byte {
+	generator := make([]byte, degree)
+	generator[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range generator {
+			generator[j] = gfMultiply(generator[j], root)
+			if j+1 < degree {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return generator
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords of the given data.
+func rsRemainder(data, generator []byte) []byte {
+	remainder := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[len(remainder)-1] = 0
+		for i, coefficient := range generator {
+			remainder[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return remainder
+}
+
+// bitBuffer is a sequence of bits.
+type bitBuffer []bool
+
+// append appends the given number of low bits of the given value, most significant first.
+func (b *bitBuffer) append(value, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// len returns the number of bits in the buffer.
+func (b bitBuffer) len() int { return len(b) }
+
+// bytes returns the bits packed into bytes, the buffer must hold a whole number of bytes.
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,195 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decode reads the text back from the given QR code: it reads the mask from the format
+// information, unmasks the codewords, checks their error correction codewords, and decodes
+// the byte mode data.
+func decode(t *testing.T, c *Code, level Level) string {
+	t.Helper()
+
+	var bits int
+	for i := range 15 {
+		if i < 8 && c.Dark(c.size-1-i, 8) || i >= 8 && c.Dark(8, c.size-15+i) {
+			bits |= 1 << i
+		}
+	}
+	mask := -1
+	for m := range 8 {
+		if formatBits(level, m) == bits {
+			mask = m
+		}
+	}
+	require.NotEqual(t, -1, mask, "the format information holds the level and the mask")
+
+	version := (c.size - 17) / 4
+	structure := blockTable[level][version-1]
+	unmasked := &Code{size: c.size, modules: make([][]bool, c.size), function: c.function}
+	for y := range c.size {
+		unmasked.modules[y] = append([]bool(nil), c.modules[y]...)
+	}
+	unmasked.applyMask(mask)
+
+	total := structure.ecPerBlock * len(structure.data)
+	for _, n := range structure.data {
+		total += n
+	}
+	codewords := make([]byte, total)
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := range c.size {
+			y := vertical
+			if upward {
+				y = c.size - 1 - vertical
+			}
+			for _, x := range []int{right, right - 1} {
+				if !c.function[y][x] && i < 8*total {
+					if unmasked.modules[y][x] {
+						codewords[i/8] |= 1 << (7 - i%8)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	dataBlocks := make([][]byte, len(structure.data))
+	for j := 0; j < structure.data[len(structure.data)-1]; j++ {
+		for b, n := range structure.data {
+			if j < n {
+				dataBlocks[b] = append(dataBlocks[b], codewords[0])
+				codewords = codewords[1:]
+			}
+		}
+	}
+	ecBlocks := make([][]byte, len(structure.data))
+	for range structure.ecPerBlock {
+		for b := range structure.data {
+			ecBlocks[b] = append(ecBlocks[b], codewords[0])
+			codewords = codewords[1:]
+		}
+	}
+	var data []byte
+	for b := range dataBlocks {
+		assert.Equal(t, rsRemainder(dataBlocks[b], rsGenerator(structure.ecPerBlock)), ecBlocks[b])
+		data = append(data, dataBlocks[b]...)
+	}
+
+	require.Equal(t, byte(0b0100), data[0]>>4, "byte mode")
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	read := func(offset, n int) int {
+		value := 0
+		for k := offset; k < offset+n; k++ {
+			value = value<<1 | int(data[k/8]>>(7-k%8)&1)
+		}
+		return value
+	}
+	length := read(4, countBits)
+	text := make([]byte, length)
+	for k := range text {
+		text[k] = byte(read(4+countBits+8*k, 8))
+	}
+	return string(text)
+}
+
+func Test_Encode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		text        string
+		level       Level
+		wantVersion int
+	}{
+		{
+			name:        "smallest version",
+			text:        "border0",
+			level:       LevelL,
+			wantVersion: 1,
+		},
+		{
+			name:        "login url",
+			text:        "https://portal.border0.com/login?device_identifier=4d9f5e0c-4b1e-4e5a-9a77-0c1d1e6f1a2b",
+			level:       LevelM,
+			wantVersion: 6,
+		},
+		{
+			name:        "version with version information",
+			text:        strings.Repeat("a", 150),
+			level:       LevelL,
+			wantVersion: 7,
+		},
+		{
+			name:        "largest version",
+			text:        strings.Repeat("b", 200),
+			level:       LevelM,
+			wantVersion: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := Encode(test.text, test.level)
+			require.NoError(t, err)
+			assert.Equal(t, 17+4*test.wantVersion, code.Size())
+			assert.Equal(t, test.text, decode(t, code, test.level))
+
+			// finder pattern in the top left corner
+			for _, y := range []int{0, 6} {
+				for x := range 7 {
+					assert.True(t, code.Dark(x, y))
+				}
+			}
+			assert.False(t, code.Dark(7, 0))
+		})
+	}
+
+	_, err := Encode(strings.Repeat("c", 300), LevelL)
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func Test_formatBits(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0b111011111000100, formatBits(LevelL, 0))
+	assert.Equal(t, 0b101010000010010, formatBits(LevelM, 0))
+	assert.Equal(t, 0b100000011001110, formatBits(LevelM, 5))
+	assert.Equal(t, 0x07C94, 7<<12|bchRemainder(7, 0x1F25, 12), "version information")
+}
+
+func Test_rsRemainder(t *testing.T) {
+	t.Parallel()
+
+	// "HELLO WORLD" at the level M, in the alphanumeric mode
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, want, rsRemainder(data, rsGenerator(10)))
+}
+
+func Test_Code_Terminal(t *testing.T) {
+	t.Parallel()
+
+	code, err := Encode("border0", LevelL)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(code.Terminal(), "\n"), "\n")
+	assert.Len(t, lines, (code.Size()+8+1)/2, "two rows of modules per line")
+	assert.Equal(t, strings.Repeat("█", code.Size()+8), lines[0], "quiet zone")
+	assert.Equal(t, strings.Repeat("█", code.Size()+8), lines[1], "quiet zone")
+	assert.Equal(t, "████ ▄▄▄▄▄ ", string([]rune(lines[2])[:11]), "top of the finder pattern")
+	assert.Equal(t, strings.Repeat("█", 4), string([]rune(lines[2])[code.Size()+4:]), "quiet zone")
+}
@@ -0,0 +1,93 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// handler is a slog.Handler that redacts secrets before passing records on.
+type handler struct {
+	inner slog.Handler
+}
+
+// NewHandler returns a slog.Handler that redacts secrets from log records before passing
+// them on to the given handler. Log messages and string attribute values are scrubbed of
+// bearer tokens, JSON web tokens and SSH certificates, values of sensitive attribute keys
+// (e.g. "password" or "token") are replaced entirely, and structured values are redacted
+// through their JSON representation.
+func NewHandler(inner slog.Handler) slog.Handler {
+	if h, ok := inner.(*handler); ok {
+		return h // already redacting
+	}
+	return &handler{inner: inner}
+}
+
+// Enabled reports whether the inner handler handles records at the given level.
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle redacts the record and passes it on to the inner handler.
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, String(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(Attr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a new redacting handler with the given (redacted) attributes.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, Attr(a))
+	}
+	return &handler{inner: h.inner.WithAttrs(redacted)}
+}
+
+// WithGroup returns a new redacting handler with the given group name.
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{inner: h.inner.WithGroup(name)}
+}
+
+// Attr returns a redacted copy of a log attribute.
+func Attr(a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return slog.Attr{Key: a.Key, Value: value(a.Value)}
+}
+
+func value(v slog.Value) slog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(String(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		redacted := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			redacted = append(redacted, Attr(a))
+		}
+		return slog.GroupValue(redacted...)
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case nil:
+			return v
+		case error:
+			return slog.StringValue(String(x.Error()))
+		case fmt.Stringer:
+			return slog.StringValue(String(fmt.Sprint(x)))
+		}
+		switch reflect.Indirect(reflect.ValueOf(v.Any())).Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			return slog.AnyValue(JSON(v.Any()))
+		}
+		return slog.StringValue(String(fmt.Sprint(v.Any())))
+	default:
+		return v
+	}
+}
@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Redacted is the placeholder that replaces redacted values.
+const Redacted = "[REDACTED]"
+
+var (
+	// bearer tokens, e.g. in Authorization headers
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	// JSON web tokens, e.g. Border0 api tokens or device authorization tokens
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+	// OpenSSH certificates, e.g. signed socket keys
+	sshCertPattern = regexp.MustCompile(`([a-z0-9-]+-cert-v01@openssh\.com)\s+[A-Za-z0-9+/]+=*`)
+)
+
+// sensitiveKeys are keys (e.g. JSON fields or log attribute keys) whose values are always redacted.
+var sensitiveKeys = map[string]struct{}{
+	"password":              {},
+	"secret":                {},
+	"token":                 {},
+	"key":                   {},
+	"private_key":           {},
+	"client_key":            {},
+	"client_key_data":       {},
+	"client_secret":         {},
+	"api_key":               {},
+	"authorization":         {},
+	"signed_ssh_cert":       {},
+	"aws_secret_access_key": {},
+	"gcp_credentials_json":  {},
+}
+
+// sensitiveKeySuffixes are key suffixes whose values are always redacted.
+var sensitiveKeySuffixes = []string{"_password", "_secret", "_token"}
+
+// IsSensitiveKey returns true if values under the given key should always be redacted.
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := sensitiveKeys[key]; ok {
+		return true
+	}
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// String scrubs secrets (bearer tokens, JSON web tokens and SSH certificates) from free text.
+func String(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = sshCertPattern.ReplaceAllString(s, "${1} "+Redacted)
+	return s
+}
+
+// JSON returns a JSON-like representation (maps, slices and scalars) of the given value
+// with the values of all sensitive keys redacted and all strings scrubbed. Values that
+// cannot be represented as JSON are replaced with the placeholder altogether.
+func JSON(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Redacted
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return Redacted
+	}
+	return redactJSON(generic)
+}
+
+func redactJSON(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, e := range x {
+			if IsSensitiveKey(k) {
+				if s, ok := e.(string); ok && s == "" {
+					continue // nothing to hide, keep empty values for readability
+				}
+				x[k] = Redacted
+				continue
+			}
+			x[k] = redactJSON(e)
+		}
+		return x
+	case []any:
+		for i := range x {
+			x[i] = redactJSON(x[i])
+		}
+		return x
+	case string:
+		return String(x)
+	default:
+		return x
+	}
+}
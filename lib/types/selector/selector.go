@@ -0,0 +1,296 @@
+// Package selector implements selectors of tags (or labels), in the grammar of Kubernetes
+// label selectors, e.g. "env=prod,team in (payments,risk),!legacy".
+package selector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operator is the operator of a requirement.
+type Operator string
+
+// Operators of requirements.
+const (
+	Equals       Operator = "="     // key=value, or key==value
+	NotEquals    Operator = "!="    // key!=value, also matches tags without the key
+	In           Operator = "in"    // key in (value1,value2)
+	NotIn        Operator = "notin" // key notin (value1,value2), also matches tags without the key
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a requirement of a selector on the tag with the given key.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string // one value for Equals and NotEquals, none for Exists and DoesNotExist
+}
+
+// Matches reports whether the given tags meet the requirement.
+func (r Requirement) Matches(tags map[string]string) bool {
+	value, ok := tags[r.Key]
+	switch r.Operator {
+	case Equals, In:
+		return ok && slices.Contains(r.Values, value)
+	case NotEquals, NotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	default:
+		return false
+	}
+}
+
+// String returns the requirement in the selector grammar.
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	default:
+		return fmt.Sprintf("%s%s%s", r.Key, r.Operator, strings.Join(r.Values, ","))
+	}
+}
+
+// Selector selects tags meeting all of its requirements. The empty selector selects all tags.
+type Selector []Requirement
+
+// Matches reports whether the given tags meet all the requirements of the selector.
+func (s Selector) Matches(tags map[string]string) bool {
+	for _, requirement := range s {
+		if !requirement.Matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector has no requirements, and so selects all tags.
+func (s Selector) Empty() bool { return len(s) == 0 }
+
+// String returns the selector in the selector grammar, which parses back into the same selector.
+func (s Selector) String() string {
+	requirements := make([]string, 0, len(s))
+	for _, requirement := range s {
+		requirements = append(requirements, requirement.String())
+	}
+	return strings.Join(requirements, ",")
+}
+
+// MustParse is Parse for selectors known to be valid, it panics if the selector is invalid.
+func MustParse(selector string) Selector {
+	s, err := Parse(selector)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Parse parses a selector: a comma separated list of requirements, which are either
+//
+//	key=value, key==value  the tag has the value
+//	key!=value             the tag does not have the value, or there's no such tag
+//	key in (v1,v2)         the tag has one of the values
+//	key notin (v1,v2)      the tag has none of the values, or there's no such tag
+//	key                    the tag exists
+//	!key                   the tag does not exist
+//
+// Keys and values can't contain spaces, commas, parentheses, '=' and '!'. Values can be empty.
+// The empty string is the empty selector.
+func Parse(selector string) (Selector, error) {
+	p := &parser{input: selector}
+	s, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	return s, nil
+}
+
+// token kinds of the lexer.
+const (
+	tokenEnd = iota
+	tokenWord
+	tokenEquals
+	tokenDoubleEquals
+	tokenNotEquals
+	tokenNot
+	tokenComma
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// describe returns the token for error messages.
+func (t token) describe() string {
+	if t.kind == tokenEnd {
+		return "end of selector"
+	}
+	return fmt.Sprintf("%q at position %d", t.text, t.pos+1)
+}
+
+// parser is a recursive descent parser of selectors.
+type parser struct {
+	input string
+	pos   int
+	next  *token // token peeked, not consumed yet
+}
+
+func (p *parser) parse() (Selector, error) {
+	var s Selector
+	if p.peek().kind == tokenEnd {
+		return s, nil
+	}
+	for {
+		requirement, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, requirement)
+
+		switch t := p.lex(); t.kind {
+		case tokenEnd:
+			return s, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("expected ',' or end of selector, got %s", t.describe())
+		}
+	}
+}
+
+func (p *parser) requirement() (Requirement, error) {
+	if p.peek().kind == tokenNot {
+		p.lex()
+		key, err := p.word("key")
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: DoesNotExist}, nil
+	}
+
+	key, err := p.word("key")
+	if err != nil {
+		return Requirement{}, err
+	}
+	switch t := p.peek(); {
+	case t.kind == tokenEquals || t.kind == tokenDoubleEquals || t.kind == tokenNotEquals:
+		p.lex()
+		operator := Equals
+		if t.kind == tokenNotEquals {
+			operator = NotEquals
+		}
+		value := ""
+		if p.peek().kind == tokenWord {
+			value = p.lex().text
+		}
+		return Requirement{Key: key, Operator: operator, Values: []string{value}}, nil
+	case t.kind == tokenWord && (t.text == string(In) || t.text == string(NotIn)):
+		p.lex()
+		values, err := p.values()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: Operator(t.text), Values: values}, nil
+	case t.kind == tokenEnd || t.kind == tokenComma:
+		return Requirement{Key: key, Operator: Exists}, nil
+	default:
+		return Requirement{}, fmt.Errorf("expected an operator after key %q, got %s", key, t.describe())
+	}
+}
+
+// values parses the parenthesized list of values of the in and notin operators.
+func (p *parser) values() ([]string, error) {
+	if t := p.lex(); t.kind != tokenOpen {
+		return nil, fmt.Errorf("expected '(', got %s", t.describe())
+	}
+	var values []string
+	for {
+		value, err := p.word("value")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch t := p.lex(); t.kind {
+		case tokenClose:
+			return values, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("expected ',' or ')', got %s", t.describe())
+		}
+	}
+}
+
+// word consumes the next token, which must be a key or a value.
+func (p *parser) word(what string) (string, error) {
+	t := p.lex()
+	if t.kind != tokenWord {
+		return "", fmt.Errorf("expected a %s, got %s", what, t.describe())
+	}
+	return t.text, nil
+}
+
+// peek returns the next token, without consuming it.
+func (p *parser) peek() token {
+	if p.next == nil {
+		t := p.scan()
+		p.next = &t
+	}
+	return *p.next
+}
+
+// lex consumes the next token.
+func (p *parser) lex() token {
+	t := p.peek()
+	p.next = nil
+	return t
+}
+
+// scan reads the next token of the input.
+func (p *parser) scan() token {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+	start := p.pos
+	if start == len(p.input) {
+		return token{kind: tokenEnd, pos: start}
+	}
+	punctuation := func(kind, length int) token {
+		p.pos += length
+		return token{kind: kind, text: p.input[start:p.pos], pos: start}
+	}
+	rest := p.input[start:]
+	switch {
+	case strings.HasPrefix(rest, "=="):
+		return punctuation(tokenDoubleEquals, 2)
+	case strings.HasPrefix(rest, "!="):
+		return punctuation(tokenNotEquals, 2)
+	case rest[0] == '=':
+		return punctuation(tokenEquals, 1)
+	case rest[0] == '!':
+		return punctuation(tokenNot, 1)
+	case rest[0] == ',':
+		return punctuation(tokenComma, 1)
+	case rest[0] == '(':
+		return punctuation(tokenOpen, 1)
+	case rest[0] == ')':
+		return punctuation(tokenClose, 1)
+	}
+	for p.pos < len(p.input) && !isSpace(p.input[p.pos]) && !strings.ContainsRune("=!,()", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	return token{kind: tokenWord, text: p.input[start:p.pos], pos: start}
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
@@ -0,0 +1,164 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		given      string
+		want       Selector
+		wantString string
+		wantErr    string
+	}{
+		{
+			name:       "empty selector",
+			given:      "  ",
+			want:       nil,
+			wantString: "",
+		},
+		{
+			name:  "all operators",
+			given: "env=prod, tier==web,region!=eu, team in (payments, risk),stage notin (dev),owner,!legacy",
+			want: Selector{
+				{Key: "env", Operator: Equals, Values: []string{"prod"}},
+				{Key: "tier", Operator: Equals, Values: []string{"web"}},
+				{Key: "region", Operator: NotEquals, Values: []string{"eu"}},
+				{Key: "team", Operator: In, Values: []string{"payments", "risk"}},
+				{Key: "stage", Operator: NotIn, Values: []string{"dev"}},
+				{Key: "owner", Operator: Exists},
+				{Key: "legacy", Operator: DoesNotExist},
+			},
+			wantString: "env=prod,tier=web,region!=eu,team in (payments,risk),stage notin (dev),owner,!legacy",
+		},
+		{
+			name:       "empty value",
+			given:      "env=,team",
+			want:       Selector{{Key: "env", Operator: Equals, Values: []string{""}}, {Key: "team", Operator: Exists}},
+			wantString: "env=,team",
+		},
+		{
+			name:       "keywords as keys and values",
+			given:      "in=notin,notin in (in)",
+			want:       Selector{{Key: "in", Operator: Equals, Values: []string{"notin"}}, {Key: "notin", Operator: In, Values: []string{"in"}}},
+			wantString: "in=notin,notin in (in)",
+		},
+		{
+			name:       "keys with prefixes",
+			given:      "border0.com/managed-by=terraform",
+			want:       Selector{{Key: "border0.com/managed-by", Operator: Equals, Values: []string{"terraform"}}},
+			wantString: "border0.com/managed-by=terraform",
+		},
+		{
+			name:    "missing key",
+			given:   "=prod",
+			wantErr: `invalid selector "=prod": expected a key, got "=" at position 1`,
+		},
+		{
+			name:    "missing key after not",
+			given:   "env,!",
+			wantErr: `invalid selector "env,!": expected a key, got end of selector`,
+		},
+		{
+			name:    "trailing comma",
+			given:   "env=prod,",
+			wantErr: `invalid selector "env=prod,": expected a key, got end of selector`,
+		},
+		{
+			name:    "unknown operator",
+			given:   "env like prod",
+			wantErr: `invalid selector "env like prod": expected an operator after key "env", got "like" at position 5`,
+		},
+		{
+			name:    "missing comma",
+			given:   "env=prod team=web",
+			wantErr: `invalid selector "env=prod team=web": expected ',' or end of selector, got "team" at position 10`,
+		},
+		{
+			name:    "in without values",
+			given:   "team in payments",
+			wantErr: `invalid selector "team in payments": expected '(', got "payments" at position 9`,
+		},
+		{
+			name:    "empty set of values",
+			given:   "team in ()",
+			wantErr: `invalid selector "team in ()": expected a value, got ")" at position 10`,
+		},
+		{
+			name:    "unclosed set of values",
+			given:   "team notin (payments,risk",
+			wantErr: `invalid selector "team notin (payments,risk": expected ',' or ')', got end of selector`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(test.given)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.wantString, got.String())
+
+			reparsed, err := Parse(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, reparsed, "selectors parse back from their string")
+		})
+	}
+}
+
+func Test_MustParse(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Selector{{Key: "env", Operator: Exists}}, MustParse("env"))
+	assert.Panics(t, func() { MustParse("env in") })
+}
+
+func Test_Selector_Matches(t *testing.T) {
+	t.Parallel()
+
+	tags := map[string]string{"env": "prod", "team": "payments", "owner": ""}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "env=prod", want: true},
+		{selector: "env=staging", want: false},
+		{selector: "owner=", want: true},
+		{selector: "env!=staging", want: true},
+		{selector: "env!=prod", want: false},
+		{selector: "region!=eu", want: true},
+		{selector: "team in (payments,risk)", want: true},
+		{selector: "team in (risk)", want: false},
+		{selector: "region in (eu)", want: false},
+		{selector: "team notin (risk)", want: true},
+		{selector: "team notin (payments,risk)", want: false},
+		{selector: "region notin (eu)", want: true},
+		{selector: "owner", want: true},
+		{selector: "region", want: false},
+		{selector: "!legacy", want: true},
+		{selector: "!env", want: false},
+		{selector: "env=prod,team in (payments,risk),!legacy", want: true},
+		{selector: "env=prod,team in (payments,risk),!owner", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.want, MustParse(test.selector).Matches(tags))
+		})
+	}
+}
@@ -12,13 +12,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/lib/redact"
 	"github.com/borderzero/border0-go/lib/types/pointer"
 	"github.com/borderzero/border0-go/lib/types/slice"
 	"github.com/cenkalti/backoff/v4"
@@ -36,6 +37,7 @@ type Listener struct {
 	tunnelServer string
 	errChan      chan error
 	readyChan    chan bool
+	logger       *slog.Logger // always wrapped with a redacting handler
 }
 
 const defaultTunnelServer = "tunnel.border0.com:22"
@@ -48,6 +50,7 @@ func New(options ...Option) *Listener {
 		tunnelServer: os.Getenv("BORDER0_TUNNEL_SERVER"),
 		errChan:      make(chan error),
 		readyChan:    make(chan bool),
+		logger:       slog.Default(),
 	}
 	if l.tunnelServer == "" {
 		l.tunnelServer = defaultTunnelServer
@@ -55,8 +58,12 @@ func New(options ...Option) *Listener {
 	for _, option := range options {
 		option(l)
 	}
+	l.logger = slog.New(redact.NewHandler(l.logger.Handler()))
 	if l.apiClient == nil {
-		l.apiClient = client.New(client.WithAuthToken(l.authToken))
+		l.apiClient = client.New(
+			client.WithAuthToken(l.authToken),
+			client.WithLogger(l.logger),
+		)
 	}
 	return l
 }
@@ -76,7 +83,7 @@ func (l *Listener) Start(ctx context.Context) error {
 
 	go func() {
 		for err := range l.errChan {
-			fmt.Println("Border0 listener:", err)
+			l.logger.Error("Border0 listener error", slog.Any("error", err))
 		}
 	}()
 
@@ -109,13 +116,13 @@ func (l *Listener) Addr() net.Addr {
 }
 
 func (l *Listener) connectTunnel(ctx context.Context) {
-	claims, err := l.apiClient.TokenClaims()
+	claims, err := l.apiClient.Claims(ctx)
 	if err != nil {
 		l.errChan <- fmt.Errorf("failed to get api token claims: %v", err)
 		return
 	}
-	userID, ok := claims["user_id"].(string)
-	if !ok {
+	userID := claims.UserID
+	if userID == "" {
 		l.errChan <- errors.New("can't find claim for user_id")
 		return
 	}
@@ -159,8 +166,8 @@ func (l *Listener) connectTunnel(ctx context.Context) {
 
 	if err != nil {
 		l.errChan <- fmt.Errorf("error connecting to server: %v", err)
-		log.Fatal(err)
-		return
+		l.logger.Error("failed to connect to Border0 tunnel server", slog.String("tunnel_server", l.tunnelServer), slog.Any("error", err))
+		os.Exit(1)
 	}
 }
 
@@ -189,7 +196,7 @@ func (l *Listener) connect(config *ssh.ClientConfig, retries backoff.BackOff) er
 	}
 	defer session.Close()
 
-	session.Stdout = os.Stdout
+	session.Stdout = &logWriter{logger: l.logger.With(slog.String("tunnel_server", l.tunnelServer))}
 
 	var modes ssh.TerminalModes
 	if err := session.RequestPty("xterm-256color", 80, 40, modes); err != nil {
@@ -248,7 +255,7 @@ func (l *Listener) keepAlive(sshClient *ssh.Client, done chan bool) {
 			}
 
 			if n >= max {
-				log.Println("ssh keepalive timeout, disconnecting")
+				l.logger.Warn("ssh keepalive timeout, disconnecting", slog.String("tunnel_server", l.tunnelServer))
 				sshClient.Close()
 				return
 			}
@@ -410,3 +417,24 @@ func generateSSHKeyPair() (*sshKeyPair, error) {
 		publicKey:  bytes.TrimSpace(ssh.MarshalAuthorizedKey(pub)),
 	}, nil
 }
+
+// logWriter is an io.Writer that logs every line written to it.
+type logWriter struct {
+	logger  *slog.Logger
+	partial []byte
+}
+
+// Write logs every complete line in p, buffering the trailing partial line.
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if line := strings.TrimSpace(string(w.partial[:i])); line != "" {
+			w.logger.Info("Border0 tunnel server output", slog.String("output", line))
+		}
+		w.partial = w.partial[i+1:]
+	}
+}
@@ -1,12 +1,16 @@
 package listen
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/borderzero/border0-go/client"
+	"github.com/borderzero/border0-go/lib/redact"
 	"github.com/borderzero/border0-go/listen/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -242,3 +246,21 @@ func Test_Listener_ensurePoliciesAttached(t *testing.T) {
 		})
 	}
 }
+
+func Test_logWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := &logWriter{logger: slog.New(redact.NewHandler(slog.NewTextHandler(&buf, nil)))}
+
+	n, err := w.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+	assert.Equal(t, 18, n)
+	assert.Contains(t, buf.String(), `output="first line"`)
+	assert.NotContains(t, buf.String(), "second")
+
+	_, err = w.Write([]byte("line with Bearer secret-token\n\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `output="second line with Bearer [REDACTED]"`)
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+}
@@ -232,6 +232,68 @@ func (_c *APIClientRequester_Authenticate_Call) RunAndReturn(run func(ctx contex
 	return _c
 }
 
+// Claims provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) Claims(ctx context.Context) (*client.Claims, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Claims")
+	}
+
+	var r0 *client.Claims
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (*client.Claims, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) *client.Claims); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Claims)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// APIClientRequester_Claims_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Claims'
+type APIClientRequester_Claims_Call struct {
+	*mock.Call
+}
+
+// Claims is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *APIClientRequester_Expecter) Claims(ctx interface{}) *APIClientRequester_Claims_Call {
+	return &APIClientRequester_Claims_Call{Call: _e.mock.On("Claims", ctx)}
+}
+
+func (_c *APIClientRequester_Claims_Call) Run(run func(ctx context.Context)) *APIClientRequester_Claims_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_Claims_Call) Return(claims *client.Claims, err error) *APIClientRequester_Claims_Call {
+	_c.Call.Return(claims, err)
+	return _c
+}
+
+func (_c *APIClientRequester_Claims_Call) RunAndReturn(run func(ctx context.Context) (*client.Claims, error)) *APIClientRequester_Claims_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Connector provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) Connector(ctx context.Context, id string) (*client.Connector, error) {
 	ret := _mock.Called(ctx, id)
@@ -442,6 +504,71 @@ func (_c *APIClientRequester_ConnectorTokens_Call) RunAndReturn(run func(ctx con
 	return _c
 }
 
+// ConnectorTokensPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ConnectorTokensPaginator(ctx context.Context, connectorID string, pageSize int) *client.Paginator[client.ConnectorToken] {
+	ret := _mock.Called(ctx, connectorID, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectorTokensPaginator")
+	}
+
+	var r0 *client.Paginator[client.ConnectorToken]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int) *client.Paginator[client.ConnectorToken]); ok {
+		r0 = returnFunc(ctx, connectorID, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.ConnectorToken])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ConnectorTokensPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConnectorTokensPaginator'
+type APIClientRequester_ConnectorTokensPaginator_Call struct {
+	*mock.Call
+}
+
+// ConnectorTokensPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - connectorID string
+//   - pageSize int
+func (_e *APIClientRequester_Expecter) ConnectorTokensPaginator(ctx interface{}, connectorID interface{}, pageSize interface{}) *APIClientRequester_ConnectorTokensPaginator_Call {
+	return &APIClientRequester_ConnectorTokensPaginator_Call{Call: _e.mock.On("ConnectorTokensPaginator", ctx, connectorID, pageSize)}
+}
+
+func (_c *APIClientRequester_ConnectorTokensPaginator_Call) Run(run func(ctx context.Context, connectorID string, pageSize int)) *APIClientRequester_ConnectorTokensPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ConnectorTokensPaginator_Call) Return(paginator *client.Paginator[client.ConnectorToken]) *APIClientRequester_ConnectorTokensPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ConnectorTokensPaginator_Call) RunAndReturn(run func(ctx context.Context, connectorID string, pageSize int) *client.Paginator[client.ConnectorToken]) *APIClientRequester_ConnectorTokensPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Connectors provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) Connectors(ctx context.Context) (*client.Connectors, error) {
 	ret := _mock.Called(ctx)
@@ -504,6 +631,65 @@ func (_c *APIClientRequester_Connectors_Call) RunAndReturn(run func(ctx context.
 	return _c
 }
 
+// ConnectorsPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ConnectorsPaginator(ctx context.Context, pageSize int) *client.Paginator[client.Connector] {
+	ret := _mock.Called(ctx, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectorsPaginator")
+	}
+
+	var r0 *client.Paginator[client.Connector]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) *client.Paginator[client.Connector]); ok {
+		r0 = returnFunc(ctx, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.Connector])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ConnectorsPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConnectorsPaginator'
+type APIClientRequester_ConnectorsPaginator_Call struct {
+	*mock.Call
+}
+
+// ConnectorsPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pageSize int
+func (_e *APIClientRequester_Expecter) ConnectorsPaginator(ctx interface{}, pageSize interface{}) *APIClientRequester_ConnectorsPaginator_Call {
+	return &APIClientRequester_ConnectorsPaginator_Call{Call: _e.mock.On("ConnectorsPaginator", ctx, pageSize)}
+}
+
+func (_c *APIClientRequester_ConnectorsPaginator_Call) Run(run func(ctx context.Context, pageSize int)) *APIClientRequester_ConnectorsPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ConnectorsPaginator_Call) Return(paginator *client.Paginator[client.Connector]) *APIClientRequester_ConnectorsPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ConnectorsPaginator_Call) RunAndReturn(run func(ctx context.Context, pageSize int) *client.Paginator[client.Connector]) *APIClientRequester_ConnectorsPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateConnector provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) CreateConnector(ctx context.Context, in *client.Connector) (*client.Connector, error) {
 	ret := _mock.Called(ctx, in)
@@ -1537,6 +1723,89 @@ func (_c *APIClientRequester_DeleteUser_Call) RunAndReturn(run func(ctx context.
 	return _c
 }
 
+// EnsureSocket provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) EnsureSocket(ctx context.Context, desired *client.Socket, opts ...client.ReconcileOption) (*client.EnsureSocketReport, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, desired, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, desired)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureSocket")
+	}
+
+	var r0 *client.EnsureSocketReport
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *client.Socket, ...client.ReconcileOption) (*client.EnsureSocketReport, error)); ok {
+		return returnFunc(ctx, desired, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *client.Socket, ...client.ReconcileOption) *client.EnsureSocketReport); ok {
+		r0 = returnFunc(ctx, desired, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.EnsureSocketReport)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *client.Socket, ...client.ReconcileOption) error); ok {
+		r1 = returnFunc(ctx, desired, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// APIClientRequester_EnsureSocket_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnsureSocket'
+type APIClientRequester_EnsureSocket_Call struct {
+	*mock.Call
+}
+
+// EnsureSocket is a helper method to define mock.On call
+//   - ctx context.Context
+//   - desired *client.Socket
+//   - opts ...client.ReconcileOption
+func (_e *APIClientRequester_Expecter) EnsureSocket(ctx interface{}, desired interface{}, opts ...interface{}) *APIClientRequester_EnsureSocket_Call {
+	return &APIClientRequester_EnsureSocket_Call{Call: _e.mock.On("EnsureSocket",
+		append([]interface{}{ctx, desired}, opts...)...)}
+}
+
+func (_c *APIClientRequester_EnsureSocket_Call) Run(run func(ctx context.Context, desired *client.Socket, opts ...client.ReconcileOption)) *APIClientRequester_EnsureSocket_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *client.Socket
+		if args[1] != nil {
+			arg1 = args[1].(*client.Socket)
+		}
+		var arg2 []client.ReconcileOption
+		var variadicArgs []client.ReconcileOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.ReconcileOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_EnsureSocket_Call) Return(report *client.EnsureSocketReport, err error) *APIClientRequester_EnsureSocket_Call {
+	_c.Call.Return(report, err)
+	return _c
+}
+
+func (_c *APIClientRequester_EnsureSocket_Call) RunAndReturn(run func(ctx context.Context, desired *client.Socket, opts ...client.ReconcileOption) (*client.EnsureSocketReport, error)) *APIClientRequester_EnsureSocket_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ExchangeWebIdentityToken provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) ExchangeWebIdentityToken(ctx context.Context, input *client.WebIdentityTokenExchangeInput) (*client.WebIdentityTokenExchangeOutput, error) {
 	ret := _mock.Called(ctx, input)
@@ -1794,6 +2063,81 @@ func (_c *APIClientRequester_GroupsPaginator_Call) RunAndReturn(run func(ctx con
 	return _c
 }
 
+// IsAuthenticated provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) IsAuthenticated(ctx context.Context, opts ...client.IsAuthenticatedOption) (bool, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, opts)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsAuthenticated")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...client.IsAuthenticatedOption) (bool, error)); ok {
+		return returnFunc(ctx, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...client.IsAuthenticatedOption) bool); ok {
+		r0 = returnFunc(ctx, opts...)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, ...client.IsAuthenticatedOption) error); ok {
+		r1 = returnFunc(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// APIClientRequester_IsAuthenticated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsAuthenticated'
+type APIClientRequester_IsAuthenticated_Call struct {
+	*mock.Call
+}
+
+// IsAuthenticated is a helper method to define mock.On call
+//   - ctx context.Context
+//   - opts ...client.IsAuthenticatedOption
+func (_e *APIClientRequester_Expecter) IsAuthenticated(ctx interface{}, opts ...interface{}) *APIClientRequester_IsAuthenticated_Call {
+	return &APIClientRequester_IsAuthenticated_Call{Call: _e.mock.On("IsAuthenticated",
+		append([]interface{}{ctx}, opts...)...)}
+}
+
+func (_c *APIClientRequester_IsAuthenticated_Call) Run(run func(ctx context.Context, opts ...client.IsAuthenticatedOption)) *APIClientRequester_IsAuthenticated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []client.IsAuthenticatedOption
+		var variadicArgs []client.IsAuthenticatedOption
+		if len(args) > 1 {
+			variadicArgs = args[1].([]client.IsAuthenticatedOption)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_IsAuthenticated_Call) Return(b bool, err error) *APIClientRequester_IsAuthenticated_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *APIClientRequester_IsAuthenticated_Call) RunAndReturn(run func(ctx context.Context, opts ...client.IsAuthenticatedOption) (bool, error)) *APIClientRequester_IsAuthenticated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Policies provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) Policies(ctx context.Context) ([]client.Policy, error) {
 	ret := _mock.Called(ctx)
@@ -1933,6 +2277,65 @@ func (_c *APIClientRequester_PoliciesByNames_Call) RunAndReturn(run func(ctx con
 	return _c
 }
 
+// PoliciesPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) PoliciesPaginator(ctx context.Context, pageSize int) *client.Paginator[client.Policy] {
+	ret := _mock.Called(ctx, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PoliciesPaginator")
+	}
+
+	var r0 *client.Paginator[client.Policy]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) *client.Paginator[client.Policy]); ok {
+		r0 = returnFunc(ctx, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.Policy])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_PoliciesPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PoliciesPaginator'
+type APIClientRequester_PoliciesPaginator_Call struct {
+	*mock.Call
+}
+
+// PoliciesPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pageSize int
+func (_e *APIClientRequester_Expecter) PoliciesPaginator(ctx interface{}, pageSize interface{}) *APIClientRequester_PoliciesPaginator_Call {
+	return &APIClientRequester_PoliciesPaginator_Call{Call: _e.mock.On("PoliciesPaginator", ctx, pageSize)}
+}
+
+func (_c *APIClientRequester_PoliciesPaginator_Call) Run(run func(ctx context.Context, pageSize int)) *APIClientRequester_PoliciesPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_PoliciesPaginator_Call) Return(paginator *client.Paginator[client.Policy]) *APIClientRequester_PoliciesPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_PoliciesPaginator_Call) RunAndReturn(run func(ctx context.Context, pageSize int) *client.Paginator[client.Policy]) *APIClientRequester_PoliciesPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Policy provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) Policy(ctx context.Context, id string) (*client.Policy, error) {
 	ret := _mock.Called(ctx, id)
@@ -2127,6 +2530,245 @@ func (_c *APIClientRequester_RemovePolicyFromSocket_Call) RunAndReturn(run func(
 	return _c
 }
 
+// ResumeGroupsPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ResumeGroupsPaginator(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Group] {
+	ret := _mock.Called(ctx, checkpoint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeGroupsPaginator")
+	}
+
+	var r0 *client.Paginator[client.Group]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.Checkpoint) *client.Paginator[client.Group]); ok {
+		r0 = returnFunc(ctx, checkpoint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.Group])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ResumeGroupsPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeGroupsPaginator'
+type APIClientRequester_ResumeGroupsPaginator_Call struct {
+	*mock.Call
+}
+
+// ResumeGroupsPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - checkpoint client.Checkpoint
+func (_e *APIClientRequester_Expecter) ResumeGroupsPaginator(ctx interface{}, checkpoint interface{}) *APIClientRequester_ResumeGroupsPaginator_Call {
+	return &APIClientRequester_ResumeGroupsPaginator_Call{Call: _e.mock.On("ResumeGroupsPaginator", ctx, checkpoint)}
+}
+
+func (_c *APIClientRequester_ResumeGroupsPaginator_Call) Run(run func(ctx context.Context, checkpoint client.Checkpoint)) *APIClientRequester_ResumeGroupsPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 client.Checkpoint
+		if args[1] != nil {
+			arg1 = args[1].(client.Checkpoint)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeGroupsPaginator_Call) Return(paginator *client.Paginator[client.Group]) *APIClientRequester_ResumeGroupsPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeGroupsPaginator_Call) RunAndReturn(run func(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Group]) *APIClientRequester_ResumeGroupsPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeSocketsPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ResumeSocketsPaginator(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Socket] {
+	ret := _mock.Called(ctx, checkpoint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeSocketsPaginator")
+	}
+
+	var r0 *client.Paginator[client.Socket]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.Checkpoint) *client.Paginator[client.Socket]); ok {
+		r0 = returnFunc(ctx, checkpoint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.Socket])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ResumeSocketsPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeSocketsPaginator'
+type APIClientRequester_ResumeSocketsPaginator_Call struct {
+	*mock.Call
+}
+
+// ResumeSocketsPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - checkpoint client.Checkpoint
+func (_e *APIClientRequester_Expecter) ResumeSocketsPaginator(ctx interface{}, checkpoint interface{}) *APIClientRequester_ResumeSocketsPaginator_Call {
+	return &APIClientRequester_ResumeSocketsPaginator_Call{Call: _e.mock.On("ResumeSocketsPaginator", ctx, checkpoint)}
+}
+
+func (_c *APIClientRequester_ResumeSocketsPaginator_Call) Run(run func(ctx context.Context, checkpoint client.Checkpoint)) *APIClientRequester_ResumeSocketsPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 client.Checkpoint
+		if args[1] != nil {
+			arg1 = args[1].(client.Checkpoint)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeSocketsPaginator_Call) Return(paginator *client.Paginator[client.Socket]) *APIClientRequester_ResumeSocketsPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeSocketsPaginator_Call) RunAndReturn(run func(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.Socket]) *APIClientRequester_ResumeSocketsPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeUsersPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ResumeUsersPaginator(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.User] {
+	ret := _mock.Called(ctx, checkpoint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeUsersPaginator")
+	}
+
+	var r0 *client.Paginator[client.User]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.Checkpoint) *client.Paginator[client.User]); ok {
+		r0 = returnFunc(ctx, checkpoint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.User])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ResumeUsersPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeUsersPaginator'
+type APIClientRequester_ResumeUsersPaginator_Call struct {
+	*mock.Call
+}
+
+// ResumeUsersPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - checkpoint client.Checkpoint
+func (_e *APIClientRequester_Expecter) ResumeUsersPaginator(ctx interface{}, checkpoint interface{}) *APIClientRequester_ResumeUsersPaginator_Call {
+	return &APIClientRequester_ResumeUsersPaginator_Call{Call: _e.mock.On("ResumeUsersPaginator", ctx, checkpoint)}
+}
+
+func (_c *APIClientRequester_ResumeUsersPaginator_Call) Run(run func(ctx context.Context, checkpoint client.Checkpoint)) *APIClientRequester_ResumeUsersPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 client.Checkpoint
+		if args[1] != nil {
+			arg1 = args[1].(client.Checkpoint)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeUsersPaginator_Call) Return(paginator *client.Paginator[client.User]) *APIClientRequester_ResumeUsersPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ResumeUsersPaginator_Call) RunAndReturn(run func(ctx context.Context, checkpoint client.Checkpoint) *client.Paginator[client.User]) *APIClientRequester_ResumeUsersPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ServerInfo provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ServerInfo(ctx context.Context) (*client.ServerInfo, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServerInfo")
+	}
+
+	var r0 *client.ServerInfo
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (*client.ServerInfo, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) *client.ServerInfo); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.ServerInfo)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// APIClientRequester_ServerInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServerInfo'
+type APIClientRequester_ServerInfo_Call struct {
+	*mock.Call
+}
+
+// ServerInfo is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *APIClientRequester_Expecter) ServerInfo(ctx interface{}) *APIClientRequester_ServerInfo_Call {
+	return &APIClientRequester_ServerInfo_Call{Call: _e.mock.On("ServerInfo", ctx)}
+}
+
+func (_c *APIClientRequester_ServerInfo_Call) Run(run func(ctx context.Context)) *APIClientRequester_ServerInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ServerInfo_Call) Return(out *client.ServerInfo, err error) *APIClientRequester_ServerInfo_Call {
+	_c.Call.Return(out, err)
+	return _c
+}
+
+func (_c *APIClientRequester_ServerInfo_Call) RunAndReturn(run func(ctx context.Context) (*client.ServerInfo, error)) *APIClientRequester_ServerInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ServiceAccount provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) ServiceAccount(ctx context.Context, name string) (*client.ServiceAccount, error) {
 	ret := _mock.Called(ctx, name)
@@ -2263,6 +2905,71 @@ func (_c *APIClientRequester_ServiceAccountTokens_Call) RunAndReturn(run func(ct
 	return _c
 }
 
+// ServiceAccountTokensPaginator provides a mock function for the type APIClientRequester
+func (_mock *APIClientRequester) ServiceAccountTokensPaginator(ctx context.Context, serviceAccountName string, pageSize int) *client.Paginator[client.ServiceAccountToken] {
+	ret := _mock.Called(ctx, serviceAccountName, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServiceAccountTokensPaginator")
+	}
+
+	var r0 *client.Paginator[client.ServiceAccountToken]
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int) *client.Paginator[client.ServiceAccountToken]); ok {
+		r0 = returnFunc(ctx, serviceAccountName, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.Paginator[client.ServiceAccountToken])
+		}
+	}
+	return r0
+}
+
+// APIClientRequester_ServiceAccountTokensPaginator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServiceAccountTokensPaginator'
+type APIClientRequester_ServiceAccountTokensPaginator_Call struct {
+	*mock.Call
+}
+
+// ServiceAccountTokensPaginator is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serviceAccountName string
+//   - pageSize int
+func (_e *APIClientRequester_Expecter) ServiceAccountTokensPaginator(ctx interface{}, serviceAccountName interface{}, pageSize interface{}) *APIClientRequester_ServiceAccountTokensPaginator_Call {
+	return &APIClientRequester_ServiceAccountTokensPaginator_Call{Call: _e.mock.On("ServiceAccountTokensPaginator", ctx, serviceAccountName, pageSize)}
+}
+
+func (_c *APIClientRequester_ServiceAccountTokensPaginator_Call) Run(run func(ctx context.Context, serviceAccountName string, pageSize int)) *APIClientRequester_ServiceAccountTokensPaginator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *APIClientRequester_ServiceAccountTokensPaginator_Call) Return(paginator *client.Paginator[client.ServiceAccountToken]) *APIClientRequester_ServiceAccountTokensPaginator_Call {
+	_c.Call.Return(paginator)
+	return _c
+}
+
+func (_c *APIClientRequester_ServiceAccountTokensPaginator_Call) RunAndReturn(run func(ctx context.Context, serviceAccountName string, pageSize int) *client.Paginator[client.ServiceAccountToken]) *APIClientRequester_ServiceAccountTokensPaginator_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SignSocketKey provides a mock function for the type APIClientRequester
 func (_mock *APIClientRequester) SignSocketKey(ctx context.Context, idOrName string, in *client.SocketKeyToSign) (*client.SignedSocketKey, error) {
 	ret := _mock.Called(ctx, idOrName, in)
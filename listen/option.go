@@ -1,6 +1,8 @@
 package listen
 
 import (
+	"log/slog"
+
 	"github.com/borderzero/border0-go/client"
 	"github.com/borderzero/border0-go/lib/types/pointer"
 )
@@ -47,3 +49,14 @@ func WithTunnelServer(server string) Option {
 		l.tunnelServer = server
 	}
 }
+
+// WithLogger sets the logger used for all messages logged by the Listener, including the
+// output of the tunnel server session. Secrets such as tokens and signed SSH certificates
+// are always redacted before reaching the logger's handler. The logger is also passed on
+// to the API client created by the Listener, unless one is given with WithAPIClient. If
+// not set, slog.Default() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Listener) {
+		l.logger = logger
+	}
+}
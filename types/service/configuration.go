@@ -2,8 +2,10 @@ package service
 
 import (
 	"fmt"
+	"log/slog"
 	"net/netip"
 
+	"github.com/borderzero/border0-go/lib/redact"
 	"github.com/borderzero/border0-go/lib/types/nilcheck"
 )
 
@@ -80,6 +82,12 @@ type Configuration struct {
 	DEPRECATED_SubnetRoutesServiceConfiguration *SubnetRouterServiceConfiguration `json:"subnet_routes_service_configuration,omitempty"`
 }
 
+// LogValue implements slog.LogValuer so that credentials (passwords,
+// keys, tokens, etc.) never end up in logs in plaintext.
+func (c Configuration) LogValue() slog.Value {
+	return slog.AnyValue(redact.JSON(c))
+}
+
 type validatable interface {
 	Validate() error
 }
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"bytes"
 	"errors"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -186,3 +188,34 @@ func Test_ConnectorSocketConfiguration_Validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_Configuration_LogValue(t *testing.T) {
+	t.Parallel()
+
+	config := &Configuration{
+		ServiceType: ServiceTypeDatabase,
+		DatabaseServiceConfiguration: &DatabaseServiceConfiguration{
+			DatabaseServiceType: DatabaseServiceTypeStandard,
+			Standard: &StandardDatabaseServiceConfiguration{
+				HostnameAndPort: HostnameAndPort{
+					Hostname: "db.internal",
+					Port:     5432,
+				},
+				UsernameAndPasswordAuth: &DatabaseUsernameAndPasswordAuthConfiguration{
+					UsernameAndPassword: UsernameAndPassword{
+						Username: "admin",
+						Password: "hunter2",
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("upstream", "config", config)
+
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.Contains(t, buf.String(), `"password":"[REDACTED]"`)
+	assert.Contains(t, buf.String(), `"username":"admin"`)
+	assert.Contains(t, buf.String(), `"hostname":"db.internal"`)
+}